@@ -19,11 +19,18 @@ package dinghy
 import (
 	"context"
 	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
 	"github.com/armory/dinghy/pkg/database"
 	"github.com/armory/dinghy/pkg/dinghyfile"
 	"github.com/armory/dinghy/pkg/execution"
+	"github.com/armory/dinghy/pkg/git/github"
+	"github.com/armory/dinghy/pkg/git/gitlab"
+	"github.com/armory/dinghy/pkg/git/stash"
+	"github.com/armory/dinghy/pkg/health"
 	"github.com/armory/dinghy/pkg/logevents"
+	"github.com/armory/dinghy/pkg/notifiers"
 	"github.com/armory/dinghy/pkg/settings/global"
 	"github.com/armory/dinghy/pkg/settings/source"
 	"github.com/armory/go-yaml-tools/pkg/tls/server"
@@ -32,6 +39,7 @@ import (
 	"os/signal"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/armory/dinghy/pkg/debug"
 
@@ -41,6 +49,7 @@ import (
 
 	"github.com/armory/dinghy/pkg/cache"
 	"github.com/armory/dinghy/pkg/events"
+	"github.com/armory/dinghy/pkg/tracing"
 	"github.com/armory/dinghy/pkg/util"
 	"github.com/armory/dinghy/pkg/web"
 	"github.com/go-redis/redis"
@@ -61,11 +70,83 @@ func NewRedisOptions(redisOptions global.Redis) *redis.Options {
 		MaxRetries: 5,
 		Addr:       url,
 		Password:   redisOptions.Password,
-		DB:         0,
+		DB:         redisOptions.Database,
 		TLSConfig:  tlsConfig,
 	}
 }
 
+// NewRedisClient connects to the Cluster, the Sentinel master or the single
+// node configured
+func NewRedisClient(redisOptions global.Redis) (redis.UniversalClient, error) {
+	options := NewRedisOptions(redisOptions)
+	tlsConfig, err := newRedisTLSConfig(redisOptions)
+	if err != nil {
+		return nil, err
+	}
+	options.TLSConfig = tlsConfig
+	if redisOptions.Username != "" {
+		// AUTH with a single argument is always the default user
+		options.Password = ""
+		options.OnConnect = func(conn *redis.Conn) error {
+			return conn.Do("AUTH", redisOptions.Username, redisOptions.Password).Err()
+		}
+	}
+
+	switch {
+	case redisOptions.Cluster.Enabled:
+		if redisOptions.Database != 0 {
+			return nil, errors.New("redis cluster only supports database 0")
+		}
+		addresses := redisOptions.Cluster.Addresses
+		if len(addresses) == 0 {
+			addresses = []string{options.Addr}
+		}
+		return redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:      addresses,
+			MaxRetries: options.MaxRetries,
+			Password:   options.Password,
+			OnConnect:  options.OnConnect,
+			TLSConfig:  options.TLSConfig,
+		}), nil
+	case redisOptions.Sentinel.MasterName != "":
+		if len(redisOptions.Sentinel.Addresses) == 0 {
+			return nil, errors.New("redis sentinel needs the addresses of the sentinels")
+		}
+		return redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:    redisOptions.Sentinel.MasterName,
+			SentinelAddrs: redisOptions.Sentinel.Addresses,
+			MaxRetries:    options.MaxRetries,
+			Password:      options.Password,
+			DB:            options.DB,
+			OnConnect:     options.OnConnect,
+			TLSConfig:     options.TLSConfig,
+		}), nil
+	}
+	return redis.NewClient(options), nil
+}
+
+func newRedisTLSConfig(redisOptions global.Redis) (*tls.Config, error) {
+	if !redisOptions.TLS.Enabled && !strings.HasPrefix(redisOptions.BaseURL, "rediss://") {
+		return nil, nil
+	}
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         redisOptions.TLS.ServerName,
+		InsecureSkipVerify: redisOptions.TLS.InsecureSkipVerify,
+	}
+	if redisOptions.TLS.CAFile != "" {
+		pem, err := os.ReadFile(redisOptions.TLS.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate found in %s", redisOptions.TLS.CAFile)
+		}
+	}
+	return tlsConfig, nil
+}
+
 func Setup(sourceConfiguration source.SourceConfiguration, log *logr.Logger) (*logr.Logger, *web.WebAPI) {
 	// We need to initialize the configuration for the start-up.
 	config, err := sourceConfiguration.LoadSetupSettings(log)
@@ -98,6 +179,25 @@ func Setup(sourceConfiguration source.SourceConfiguration, log *logr.Logger) (*l
 		}
 	}
 
+	shutdownTracing := func(context.Context) error { return nil }
+	if config.Tracing.Enabled {
+		serviceName := config.Tracing.ServiceName
+		if serviceName == "" {
+			serviceName = config.InstanceId
+		}
+		shutdown, err := tracing.Init(context.Background(), tracing.Config{
+			ServiceName: serviceName,
+			Endpoint:    config.Tracing.Endpoint,
+			URLPath:     config.Tracing.URLPath,
+			Insecure:    config.Tracing.Insecure,
+		})
+		if err != nil {
+			log.Warnf("unable to setup tracing: %s", err.Error())
+		} else {
+			shutdownTracing = shutdown
+		}
+	}
+
 	client := setupPlankClient(config, log)
 	clientReadOnly := util.PlankReadOnly{Plank: client}
 
@@ -112,39 +212,48 @@ func Setup(sourceConfiguration source.SourceConfiguration, log *logr.Logger) (*l
 	go func() {
 		<-stop
 		log.Info("Stopping Dinghy")
+		if err := shutdownTracing(context.Background()); err != nil {
+			log.Warnf("failed to flush traces: %s", err.Error())
+		}
 		cancel()
 		os.Exit(1)
 	}()
 
+	readiness := health.NewMonitor(
+		time.Duration(config.Readiness.IntervalSeconds)*time.Second,
+		time.Duration(config.Readiness.TimeoutSeconds)*time.Second,
+		log,
+	)
+
 	var api *web.WebAPI
 	var logEventsClient logevents.LogEventsClient
+	janitor := newLogEventJanitor(config, log)
+	logEventTTL := config.LogEventTTLMinutes
+	if janitor != nil {
+		logEventTTL = (janitor.Retention.Longest() + janitor.Interval) / time.Minute
+	}
 	var persitenceManager dinghyfile.DependencyManager
 	var persitenceManagerReadOnly dinghyfile.DependencyManager
+	// the replicas take turns purging the log events through Redis, in full
+	// SQL mode every replica with the retention enabled purges
+	var locker logevents.Locker
 
 	// Full SQL mode
 	if config.SQL.Enabled && !config.SQL.EventLogsOnly {
 
-		sqlClient, sqlerr := database.NewMySQLClient(&database.SQLConfig{
-			DbUrl:    config.SQL.BaseUrl,
-			User:     config.SQL.User,
-			Password: config.SQL.Password,
-			DbName:   config.SQL.DatabaseName,
-		}, log, ctx, stop)
-
-		if sqlerr != nil {
-			log.Fatalf("SQL Server at %s could not be contacted: %v", config.SQL.BaseUrl, err)
-		}
+		sqlClient := newSQLClient(config, log, ctx, stop)
 
 		sqlClientReadOnly := database.SQLReadOnly{
 			Client: sqlClient,
 			Logger: sqlClient.Logger,
 		}
 
-		logEventsClient = &(logevents.LogEventSQLClient{SQLClient: sqlClient, MinutesTTL: config.LogEventTTLMinutes})
+		logEventsClient = &(logevents.LogEventSQLClient{SQLClient: sqlClient, MinutesTTL: logEventTTL})
 		persitenceManager = sqlClient
 		persitenceManagerReadOnly = &sqlClientReadOnly
+		readiness.AddCheck(health.NewCheck("sql", sqlClient.Ping))
 
-		redisClient := cache.NewRedisCache(NewRedisOptions(config.SpinnakerSupplied.Redis), log, ctx, stop, false)
+		redisClient := newRedisCache(config, log, ctx, stop, false)
 
 		var migration execution.Execution
 
@@ -160,18 +269,9 @@ func Setup(sourceConfiguration source.SourceConfiguration, log *logr.Logger) (*l
 
 	} else if config.SQL.Enabled && config.SQL.EventLogsOnly {
 		// Hybrid SQL mode just for eventlogs
-		sqlClient, sqlerr := database.NewMySQLClient(&database.SQLConfig{
-			DbUrl:    config.SQL.BaseUrl,
-			User:     config.SQL.User,
-			Password: config.SQL.Password,
-			DbName:   config.SQL.DatabaseName,
-		}, log, ctx, stop)
-
-		if sqlerr != nil {
-			log.Fatalf("SQL Server at %s could not be contacted: %v", config.SQL.BaseUrl, err)
-		}
+		sqlClient := newSQLClient(config, log, ctx, stop)
 
-		redisClient := cache.NewRedisCache(NewRedisOptions(config.SpinnakerSupplied.Redis), log, ctx, stop, true)
+		redisClient := newRedisCache(config, log, ctx, stop, true)
 		if _, err := redisClient.Client.Ping().Result(); err != nil {
 			log.Fatalf("Redis Server at %s could not be contacted: %v", config.SpinnakerSupplied.Redis.BaseURL, err)
 		}
@@ -181,13 +281,16 @@ func Setup(sourceConfiguration source.SourceConfiguration, log *logr.Logger) (*l
 			Logger: redisClient.Logger,
 		}
 
-		logEventsClient = &(logevents.LogEventSQLClient{SQLClient: sqlClient, MinutesTTL: config.LogEventTTLMinutes})
+		logEventsClient = &(logevents.LogEventSQLClient{SQLClient: sqlClient, MinutesTTL: logEventTTL})
 		persitenceManager = redisClient
 		persitenceManagerReadOnly = &redisClientReadOnly
+		locker = redisClient
+		readiness.AddCheck(health.NewCheck("sql", sqlClient.Ping))
+		readiness.AddCheck(redisCheck(redisClient))
 
 	} else {
 		// Redis mode
-		redisClient := cache.NewRedisCache(NewRedisOptions(config.SpinnakerSupplied.Redis), log, ctx, stop, true)
+		redisClient := newRedisCache(config, log, ctx, stop, true)
 		if _, err := redisClient.Client.Ping().Result(); err != nil {
 			log.Fatalf("Redis Server at %s could not be contacted: %v", config.SpinnakerSupplied.Redis.BaseURL, err)
 		}
@@ -197,18 +300,53 @@ func Setup(sourceConfiguration source.SourceConfiguration, log *logr.Logger) (*l
 			Logger: redisClient.Logger,
 		}
 
-		logEventsClient = logevents.LogEventRedisClient{RedisClient: redisClient, MinutesTTL: config.LogEventTTLMinutes}
+		logEventsClient = logevents.LogEventRedisClient{RedisClient: redisClient, MinutesTTL: logEventTTL}
 		persitenceManager = redisClient
+		locker = redisClient
 		persitenceManagerReadOnly = &redisClientReadOnly
+		readiness.AddCheck(redisCheck(redisClient))
+
+	}
 
+	addDependencyChecks(readiness, config)
+	readiness.Start(ctx)
+	if purger, ok := logEventsClient.(logevents.Purger); ok && janitor != nil {
+		janitor.Client = purger
+		janitor.Locker = locker
+		janitor.Start(ctx)
 	}
 
 	api = web.NewWebAPI(sourceConfiguration, persitenceManager, ec, log, persitenceManagerReadOnly, &clientReadOnly, logEventsClient, log)
 	api.MetricsHandler = new(web.NoOpMetricsHandler)
+	api.Readiness = readiness
 	api.AddDinghyfileUnmarshaller(&dinghyfile.DinghyJsonUnmarshaller{})
 	if config.ParserFormat == "json" {
 		api.SetDinghyfileParser(dinghyfile.NewDinghyfileParser(&dinghyfile.PipelineBuilder{}))
 	}
+	if config.Notifiers.Slack.Enabled {
+		api.AddNotifier(notifiers.NewSlackNotifier(config.Notifiers, log))
+	}
+	for _, w := range config.Notifiers.Webhooks {
+		if n, err := notifiers.NewWebhookNotifier(w, config.Notifiers.BaseURL, log); err != nil {
+			log.Warnf("Webhook notifier disabled: %s", err.Error())
+		} else {
+			api.AddNotifier(n)
+		}
+	}
+	if config.Notifiers.Email.Enabled {
+		if n, err := notifiers.NewEmailNotifier(config.Notifiers, log); err != nil {
+			log.Warnf("Email notifier disabled: %s", err.Error())
+		} else {
+			api.AddNotifier(n)
+		}
+	}
+	if config.Drift.Enabled {
+		if reconciler, err := api.NewDriftReconciler(config, client); err != nil {
+			log.Warnf("Drift detection disabled: %s", err.Error())
+		} else {
+			reconciler.Start(ctx)
+		}
+	}
 	return log, api
 }
 
@@ -228,6 +366,99 @@ func setupPlankClient(settings *global.Settings, log *logr.Logger) *plank.Client
 	return client
 }
 
+// newRedisCache connects to the configured Redis
+func newRedisCache(config *global.Settings, log *logr.Logger, ctx context.Context, stop chan os.Signal, startMonitor bool) *cache.RedisCache {
+	client, err := NewRedisClient(config.SpinnakerSupplied.Redis)
+	if err != nil {
+		log.Fatalf("Invalid Redis configuration: %v", err)
+	}
+	return cache.NewUniversalRedisCache(client, log, ctx, stop, startMonitor)
+}
+
+// newLogEventJanitor returns the janitor of the log event retention, nil when
+// disabled. The backends keep the log events an interval longer than the
+// retention so the janitor archives them before they expire.
+func newLogEventJanitor(config *global.Settings, log *logr.Logger) *logevents.Janitor {
+	settings := config.LogEventRetention
+	if !settings.Enabled {
+		return nil
+	}
+	janitor := &logevents.Janitor{
+		Retention: logevents.Retention{
+			MaxAge:        time.Duration(settings.MaxAgeMinutes) * time.Minute,
+			MaxPerRepo:    settings.MaxPerRepo,
+			FailureMaxAge: time.Duration(settings.FailureMaxAgeMinutes) * time.Minute,
+		},
+		Interval:   time.Duration(settings.IntervalMinutes) * time.Minute,
+		ArchiveDir: settings.ArchiveDir,
+		Logger:     log,
+	}
+	if janitor.Retention.MaxAge <= 0 {
+		janitor.Retention.MaxAge = config.LogEventTTLMinutes * time.Minute
+	}
+	if janitor.Interval <= 0 {
+		janitor.Interval = logevents.DefaultRetentionInterval
+	}
+	return janitor
+}
+
+// newSQLClient connects to the configured database, SQLite has no Liquibase
+// changelog so the log events table is created here
+func newSQLClient(config *global.Settings, log *logr.Logger, ctx context.Context, stop chan os.Signal) *database.SQLClient {
+	sqlClient, err := database.NewSQLClient(&database.SQLConfig{
+		Dialect:  config.SQL.Dialect,
+		DbUrl:    config.SQL.BaseUrl,
+		User:     config.SQL.User,
+		Password: config.SQL.Password,
+		DbName:   config.SQL.DatabaseName,
+		SSLMode:  config.SQL.SSLMode,
+	}, log, ctx, stop)
+
+	if err != nil {
+		log.Fatalf("SQL Server at %s could not be contacted: %v", config.SQL.BaseUrl, err)
+	}
+	if sqlClient.Client.Dialector.Name() == database.DialectSQLite {
+		if err := sqlClient.Client.AutoMigrate(&logevents.LogEventSQL{}); err != nil {
+			log.Fatalf("Failed to create the log events table in %s: %v", config.SQL.DatabaseName, err)
+		}
+	}
+	return sqlClient
+}
+
+func redisCheck(c *cache.RedisCache) health.Check {
+	return health.NewCheck("redis", func(ctx context.Context) error {
+		return c.Ping(ctx)
+	})
+}
+
+// addDependencyChecks registers the readiness checks for Spinnaker and the
+// configured git providers
+func addDependencyChecks(m *health.Monitor, settings *global.Settings) {
+	if settings.SpinnakerSupplied.Front50.BaseURL != "" {
+		m.AddCheck(health.NewHTTPCheck("front50", settings.SpinnakerSupplied.Front50.BaseURL+"/health", settings.Http.NewClient()))
+	}
+	if settings.Readiness.GitTokenCheckDisabled {
+		return
+	}
+	if settings.GitHubToken != "" {
+		gh := github.Config{Endpoint: settings.GithubEndpoint, Token: settings.GitHubToken}
+		m.AddCheck(health.NewCheck("github", gh.ValidateToken))
+	}
+	if settings.GitLabToken != "" {
+		m.AddCheck(health.NewCheck("gitlab", func(ctx context.Context) error {
+			return gitlab.ValidateToken(ctx, settings)
+		}))
+	}
+	if settings.StashToken != "" {
+		stashConfig := stash.Config{
+			Username: settings.StashUsername,
+			Token:    settings.StashToken,
+			Endpoint: settings.StashEndpoint,
+		}
+		m.AddCheck(health.NewCheck("stash", stashConfig.ValidateToken))
+	}
+}
+
 func AddUnmarshaller(u dinghyfile.DinghyJsonUnmarshaller, api *web.WebAPI) {
 	api.AddDinghyfileUnmarshaller(u)
 }
@@ -0,0 +1,77 @@
+/*
+* Copyright 2026 Armory, Inc.
+
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+
+*    http://www.apache.org/licenses/LICENSE-2.0
+
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/armory/dinghy/pkg/backup"
+)
+
+func backupCommand(c *client, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+	flags := flag.NewFlagSet("backup "+args[0], flag.ContinueOnError)
+	flags.SetOutput(ioutil.Discard)
+	output := flags.String("o", "", "file to write the archive to, stdout when empty")
+	if err := flags.Parse(args[1:]); err != nil {
+		return errUsage
+	}
+
+	switch {
+	case args[0] == "export" && flags.NArg() == 0:
+		data, err := c.send("GET", "/v1/backup", nil)
+		if err != nil {
+			return err
+		}
+		if *output == "" {
+			_, err = out.Write(data)
+			return err
+		}
+		if err := ioutil.WriteFile(*output, data, 0600); err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(out, "backup written to %s\n", *output)
+		return err
+	case args[0] == "import" && flags.NArg() == 1 && *output == "":
+		f, err := os.Open(flags.Arg(0))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		var report backup.Report
+		if err := c.do("POST", "/v1/backup", f, &report); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "imported %d nodes, %d edges, %d raw data\n", report.Nodes, report.Edges, report.RawData)
+		fmt.Fprintf(out, "imported %d owners, %d managed dinghyfiles, %d renders\n", report.Owners, report.Managed, report.Renders)
+		fmt.Fprintf(out, "%d/%d log events restored, %d present\n", report.LogEventsRestored, report.LogEvents, report.LogEventsPresent)
+		for _, m := range report.Mismatches {
+			fmt.Fprintf(out, "  mismatch: %s\n", m)
+		}
+		if len(report.Mismatches) > 0 {
+			return fmt.Errorf("%d mismatches after the import", len(report.Mismatches))
+		}
+		return nil
+	}
+	return errUsage
+}
@@ -0,0 +1,73 @@
+/*
+* Copyright 2026 Armory, Inc.
+
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+
+*    http://www.apache.org/licenses/LICENSE-2.0
+
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackup(t *testing.T) {
+	const archive = "{\"kind\":\"header\",\"version\":1}\n{\"kind\":\"node\",\"url\":\"a\"}\n"
+	var imported []string
+	mismatches := `[]`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET /v1/backup":
+			w.Write([]byte(archive))
+		case "POST /v1/backup":
+			body, _ := ioutil.ReadAll(r.Body)
+			imported = append(imported, string(body))
+			w.Write([]byte(`{"nodes":1,"edges":0,"rawData":0,"owners":2,"managed":1,"renders":4,"logEvents":3,"logEventsRestored":2,"logEventsPresent":3,"mismatches":` + mismatches + `}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	out, errOut := &bytes.Buffer{}, &bytes.Buffer{}
+	assert.Equal(t, 0, run([]string{"-url", server.URL, "backup", "export"}, out, errOut), errOut.String())
+	assert.Equal(t, archive, out.String())
+
+	file := filepath.Join(t.TempDir(), "dinghy.jsonl")
+	out.Reset()
+	assert.Equal(t, 0, run([]string{"-url", server.URL, "backup", "export", "-o", file}, out, errOut), errOut.String())
+	assert.Equal(t, "backup written to "+file+"\n", out.String())
+	data, err := ioutil.ReadFile(file)
+	assert.Nil(t, err)
+	assert.Equal(t, archive, string(data))
+
+	out.Reset()
+	assert.Equal(t, 0, run([]string{"-url", server.URL, "backup", "import", file}, out, errOut), errOut.String())
+	assert.Equal(t, "imported 1 nodes, 0 edges, 0 raw data\nimported 2 owners, 1 managed dinghyfiles, 4 renders\n2/3 log events restored, 3 present\n", out.String())
+	assert.Equal(t, []string{archive}, imported)
+
+	mismatches = `["a depends on [] instead of [b]"]`
+	out.Reset()
+	assert.Equal(t, 1, run([]string{"-url", server.URL, "backup", "import", file}, out, errOut))
+	assert.Contains(t, out.String(), "  mismatch: a depends on [] instead of [b]\n")
+	assert.Contains(t, errOut.String(), "1 mismatches after the import")
+
+	assert.Equal(t, 2, run([]string{"-url", server.URL, "backup", "import"}, out, errOut))
+	assert.Equal(t, 2, run([]string{"-url", server.URL, "backup", "export", "extra"}, out, errOut))
+}
@@ -0,0 +1,82 @@
+/*
+* Copyright 2026 Armory, Inc.
+
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+
+*    http://www.apache.org/licenses/LICENSE-2.0
+
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var errUsage = errors.New("invalid arguments")
+
+// client calls the Dinghy admin API
+type client struct {
+	url   string
+	token string
+	http  *http.Client
+}
+
+func newClient(url, token string) *client {
+	return &client{
+		url:   strings.TrimSuffix(url, "/"),
+		token: token,
+		http:  &http.Client{Timeout: 5 * time.Minute},
+	}
+}
+
+// do sends the request and decodes a JSON response into result, when set
+func (c *client) do(method, path string, body io.Reader, result interface{}) error {
+	data, err := c.send(method, path, body)
+	if err != nil || result == nil {
+		return err
+	}
+	return json.Unmarshal(data, result)
+}
+
+// send sends the request and returns the body of a successful response
+func (c *client) send(method, path string, body io.Reader) ([]byte, error) {
+	req, err := http.NewRequest(method, c.url+path, body)
+	if err != nil {
+		return nil, err
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, strings.TrimSpace(string(data)))
+	}
+	return data, nil
+}
@@ -0,0 +1,109 @@
+/*
+* Copyright 2026 Armory, Inc.
+
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+
+*    http://www.apache.org/licenses/LICENSE-2.0
+
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"strings"
+
+	"github.com/armory/dinghy/pkg/depgraph"
+	"github.com/armory/dinghy/pkg/web"
+)
+
+func graph(c *client, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+	flags := flag.NewFlagSet("graph "+args[0], flag.ContinueOnError)
+	flags.SetOutput(ioutil.Discard)
+	asJSON := flags.Bool("json", false, "print the JSON response")
+	asDOT := flags.Bool("dot", false, "print the graph in the Graphviz DOT language")
+	if err := flags.Parse(args[1:]); err != nil || (*asJSON && *asDOT) {
+		return errUsage
+	}
+
+	var path string
+	switch {
+	case args[0] == "export" && flags.NArg() == 0:
+		path = "/v1/graph"
+		*asJSON = !*asDOT
+	case (args[0] == "dependents" || args[0] == "dependencies") && flags.NArg() == 1:
+		path = "/v1/graph/" + args[0] + "?url=" + url.QueryEscape(flags.Arg(0))
+	case args[0] == "orphans" && flags.NArg() == 0 && !*asDOT:
+		if !*asJSON {
+			var orphans []string
+			if err := c.do("GET", "/v1/graph/orphans", nil, &orphans); err != nil {
+				return err
+			}
+			for _, o := range orphans {
+				fmt.Fprintln(out, o)
+			}
+			return nil
+		}
+		path = "/v1/graph/orphans"
+	default:
+		return errUsage
+	}
+
+	if *asJSON || *asDOT {
+		if *asDOT {
+			sep := "?"
+			if strings.Contains(path, "?") {
+				sep = "&"
+			}
+			path += sep + "format=dot"
+		}
+		data, err := c.send("GET", path, nil)
+		if err != nil {
+			return err
+		}
+		_, err = out.Write(data)
+		return err
+	}
+
+	var result web.GraphTree
+	if err := c.do("GET", path, nil, &result); err != nil {
+		return err
+	}
+	printTree(out, result.Tree, "")
+	if args[0] == "dependents" {
+		fmt.Fprintf(out, "%d dinghyfiles impacted\n", len(result.Dinghyfiles))
+	}
+	return nil
+}
+
+func printTree(out io.Writer, t *depgraph.Tree, indent string) {
+	if t == nil {
+		return
+	}
+	if t.Cycle {
+		fmt.Fprintf(out, "%s%s (cycle)\n", indent, t.URL)
+		return
+	}
+	if t.Repeated {
+		fmt.Fprintf(out, "%s%s (see above)\n", indent, t.URL)
+		return
+	}
+	fmt.Fprintf(out, "%s%s\n", indent, t.URL)
+	for _, c := range t.Children {
+		printTree(out, c, indent+"  ")
+	}
+}
@@ -0,0 +1,106 @@
+/*
+* Copyright 2026 Armory, Inc.
+
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+
+*    http://www.apache.org/licenses/LICENSE-2.0
+
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGraph(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.RequestURI())
+		switch {
+		case r.URL.Query().Get("format") == "dot":
+			w.Write([]byte("digraph dinghy {\n}\n"))
+		case r.URL.Path == "/v1/graph/dependents":
+			w.Write([]byte(`{"tree":{"url":"wait.module","children":[{"url":"stage.module","children":[{"url":"app1/dinghyfile"},{"url":"wait.module","cycle":true}]},{"url":"app1/dinghyfile","repeated":true}]},"dinghyfiles":["app1/dinghyfile"]}`))
+		case r.URL.Path == "/v1/graph/dependencies":
+			w.Write([]byte(`{"tree":{"url":"app1/dinghyfile","children":[{"url":"stage.module"}]}}`))
+		case r.URL.Path == "/v1/graph/orphans":
+			w.Write([]byte(`["old.module","unused.module"]`))
+		case r.URL.Path == "/v1/graph":
+			w.Write([]byte(`{"nodes":[],"edges":[]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	cases := map[string]struct {
+		args     []string
+		code     int
+		expected string
+		request  string
+	}{
+		"dependents": {
+			args:     []string{"graph", "dependents", "wait.module"},
+			expected: "wait.module\n  stage.module\n    app1/dinghyfile\n    wait.module (cycle)\n  app1/dinghyfile (see above)\n1 dinghyfiles impacted\n",
+			request:  "/v1/graph/dependents?url=wait.module",
+		},
+		"dependencies": {
+			args:     []string{"graph", "dependencies", "app1/dinghyfile"},
+			expected: "app1/dinghyfile\n  stage.module\n",
+			request:  "/v1/graph/dependencies?url=app1%2Fdinghyfile",
+		},
+		"dependencies dot": {
+			args:     []string{"graph", "dependencies", "-dot", "app1/dinghyfile"},
+			expected: "digraph dinghy {\n}\n",
+			request:  "/v1/graph/dependencies?url=app1%2Fdinghyfile&format=dot",
+		},
+		"orphans": {
+			args:     []string{"graph", "orphans"},
+			expected: "old.module\nunused.module\n",
+			request:  "/v1/graph/orphans",
+		},
+		"export": {
+			args:     []string{"graph", "export"},
+			expected: `{"nodes":[],"edges":[]}`,
+			request:  "/v1/graph",
+		},
+		"export dot": {
+			args:     []string{"graph", "export", "-dot"},
+			expected: "digraph dinghy {\n}\n",
+			request:  "/v1/graph?format=dot",
+		},
+		"missing url": {
+			args: []string{"graph", "dependents"},
+			code: 2,
+		},
+		"both formats": {
+			args: []string{"graph", "export", "-json", "-dot"},
+			code: 2,
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			requests = nil
+			out, errOut := &bytes.Buffer{}, &bytes.Buffer{}
+			code := run(append([]string{"-url", server.URL}, c.args...), out, errOut)
+			assert.Equal(t, c.code, code, errOut.String())
+			assert.Equal(t, c.expected, out.String())
+			if c.request != "" {
+				assert.Equal(t, []string{c.request}, requests)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+/*
+* Copyright 2026 Armory, Inc.
+
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+
+*    http://www.apache.org/licenses/LICENSE-2.0
+
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+// Command dinghyctl calls the admin API of a running Dinghy.
+//
+//	dinghyctl [-url http://localhost:8081] [-token TOKEN] <command> [arguments]
+//
+// The token defaults to the DINGHY_TOKEN environment variable.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+// command runs a subcommand against the client, writing its output to out
+type command func(c *client, args []string, out io.Writer) error
+
+var commands = map[string]command{
+	"backup":  backupCommand,
+	"graph":   graph,
+	"renders": renders,
+	"resync":  resync,
+	"stale":   stale,
+}
+
+const usage = `usage: dinghyctl [-url URL] [-token TOKEN] <command> [arguments]
+
+commands:
+  backup export [-o FILE]
+  backup import <file>
+  graph dependents [-json|-dot] <url>
+  graph dependencies [-json|-dot] <url>
+  graph orphans [-json]
+  graph export [-dot]
+  renders list <application>
+  renders show <application> <id|commit>
+  renders rollback <application> <id|commit>
+  renders diff <application> <id|commit> <id|commit>
+  resync start [-org ORG] [-repo REPO] [-application APP] [-validate] [-concurrency N] [-wait]
+  resync status <id>
+  stale <application>
+`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, out, errOut io.Writer) int {
+	flags := flag.NewFlagSet("dinghyctl", flag.ContinueOnError)
+	flags.SetOutput(errOut)
+	flags.Usage = func() { fmt.Fprint(errOut, usage) }
+	url := flags.String("url", "http://localhost:8081", "Dinghy base URL")
+	token := flags.String("token", os.Getenv("DINGHY_TOKEN"), "admin bearer token")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+	cmd, ok := commands[flags.Arg(0)]
+	if !ok {
+		fmt.Fprintf(errOut, "unknown command %q\n", flags.Arg(0))
+		flags.Usage()
+		return 2
+	}
+	if err := cmd(newClient(*url, *token), flags.Args()[1:], out); err != nil {
+		if err == errUsage {
+			flags.Usage()
+			return 2
+		}
+		fmt.Fprintf(errOut, "dinghyctl: %s\n", err.Error())
+		return 1
+	}
+	return 0
+}
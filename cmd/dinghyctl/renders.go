@@ -0,0 +1,109 @@
+/*
+* Copyright 2026 Armory, Inc.
+
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+
+*    http://www.apache.org/licenses/LICENSE-2.0
+
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"text/tabwriter"
+	"time"
+
+	"github.com/armory/dinghy/pkg/history"
+)
+
+func renders(c *client, args []string, out io.Writer) error {
+	if len(args) < 2 {
+		return errUsage
+	}
+	path := "/v1/applications/" + url.PathEscape(args[1]) + "/renders"
+
+	switch {
+	case args[0] == "list" && len(args) == 2:
+		var list []history.Render
+		if err := c.do("GET", path, nil, &list); err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tCOMMIT\tDATE\tPUSHER\tDINGHYFILE")
+		for _, r := range list {
+			date := time.Unix(0, r.Date*int64(time.Millisecond)).UTC().Format(time.RFC3339)
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s/%s/%s@%s\n", r.ID, r.Commit, date, r.Pusher, r.Org, r.Repo, r.Path, r.Branch)
+		}
+		return w.Flush()
+	case args[0] == "show" && len(args) == 3:
+		var r history.Render
+		if err := c.do("GET", path+"/"+url.PathEscape(args[2]), nil, &r); err != nil {
+			return err
+		}
+		_, err := fmt.Fprintln(out, r.Dinghyfile)
+		return err
+	case args[0] == "rollback" && len(args) == 3:
+		var r history.Render
+		if err := c.do("POST", path+"/"+url.PathEscape(args[2])+"/rollback", nil, &r); err != nil {
+			return err
+		}
+		_, err := fmt.Fprintf(out, "%s rolled back to %s\n", r.Application, r.Commit)
+		return err
+	case args[0] == "diff" && len(args) == 4:
+		var d history.Diff
+		if err := c.do("GET", path+"/"+url.PathEscape(args[2])+"/diff/"+url.PathEscape(args[3]), nil, &d); err != nil {
+			return err
+		}
+		printDiff(out, d)
+		return nil
+	}
+	return errUsage
+}
+
+var changeSigns = map[string]string{history.Added: "+", history.Removed: "-", history.Changed: "~"}
+
+func printDiff(out io.Writer, d history.Diff) {
+	date := func(v history.Version) string {
+		return time.Unix(0, v.Date*int64(time.Millisecond)).UTC().Format(time.RFC3339)
+	}
+	fmt.Fprintf(out, "%s %s (%s) -> %s (%s)\n", d.Application, d.From.Commit, date(d.From), d.To.Commit, date(d.To))
+	if len(d.Dinghyfile) > 0 {
+		fmt.Fprintln(out, "dinghyfile changed")
+		printChanges(out, d.Dinghyfile)
+	}
+	for _, p := range d.Pipelines {
+		fmt.Fprintf(out, "pipeline %s %s\n", p.Name, p.Type)
+		printChanges(out, p.Changes)
+	}
+	if len(d.Dinghyfile) == 0 && len(d.Pipelines) == 0 {
+		fmt.Fprintln(out, "no changes")
+	}
+}
+
+func printChanges(out io.Writer, changes []history.Change) {
+	value := func(v interface{}) string {
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+	for _, c := range changes {
+		switch c.Type {
+		case history.Added:
+			fmt.Fprintf(out, "  %s %s: %s\n", changeSigns[c.Type], c.Path, value(c.To))
+		case history.Removed:
+			fmt.Fprintf(out, "  %s %s: %s\n", changeSigns[c.Type], c.Path, value(c.From))
+		default:
+			fmt.Fprintf(out, "  %s %s: %s -> %s\n", changeSigns[c.Type], c.Path, value(c.From), value(c.To))
+		}
+	}
+}
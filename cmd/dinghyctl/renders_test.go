@@ -0,0 +1,120 @@
+/*
+* Copyright 2026 Armory, Inc.
+
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+
+*    http://www.apache.org/licenses/LICENSE-2.0
+
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenders(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		if r.Header.Get("Authorization") != "Bearer s3cr3t" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":"missing or invalid credentials"}`))
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/applications/testapp/renders":
+			w.Write([]byte(`[{"id":"0-abc123-1a2b3c4d","application":"testapp","commit":"abc123","org":"org","repo":"repo","path":"dinghyfile","branch":"master","pusher":"dev","date":0}]`))
+		case "/v1/applications/testapp/renders/abc123":
+			w.Write([]byte(`{"application":"testapp","commit":"abc123","dinghyfile":"{}"}`))
+		case "/v1/applications/testapp/renders/abc123/diff/def456":
+			w.Write([]byte(`{"application":"testapp","from":{"commit":"abc123","date":0},"to":{"commit":"def456","date":60000},` +
+				`"dinghyfile":[{"path":"spec.email","type":"changed","from":"a@example.com","to":"b@example.com"}],` +
+				`"pipelines":[{"name":"deploy","type":"changed","changes":[{"path":"stages[0].waitTime","type":"changed","from":10,"to":30},{"path":"stages[1]","type":"added","to":{"type":"manualJudgment"}}]},{"name":"old","type":"removed"}]}`))
+		case "/v1/applications/testapp/renders/abc123/rollback":
+			w.Write([]byte(`{"application":"testapp","commit":"abc123"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	cases := map[string]struct {
+		args     []string
+		code     int
+		expected string
+		request  string
+	}{
+		"list": {
+			args:     []string{"renders", "list", "testapp"},
+			expected: "ID                 COMMIT  DATE                  PUSHER  DINGHYFILE\n0-abc123-1a2b3c4d  abc123  1970-01-01T00:00:00Z  dev     org/repo/dinghyfile@master\n",
+			request:  "GET /v1/applications/testapp/renders",
+		},
+		"show": {
+			args:     []string{"renders", "show", "testapp", "abc123"},
+			expected: "{}\n",
+			request:  "GET /v1/applications/testapp/renders/abc123",
+		},
+		"rollback": {
+			args:     []string{"renders", "rollback", "testapp", "abc123"},
+			expected: "testapp rolled back to abc123\n",
+			request:  "POST /v1/applications/testapp/renders/abc123/rollback",
+		},
+		"diff": {
+			args: []string{"renders", "diff", "testapp", "abc123", "def456"},
+			expected: "testapp abc123 (1970-01-01T00:00:00Z) -> def456 (1970-01-01T00:01:00Z)\n" +
+				"dinghyfile changed\n" +
+				"  ~ spec.email: \"a@example.com\" -> \"b@example.com\"\n" +
+				"pipeline deploy changed\n" +
+				"  ~ stages[0].waitTime: 10 -> 30\n" +
+				"  + stages[1]: {\"type\":\"manualJudgment\"}\n" +
+				"pipeline old removed\n",
+			request: "GET /v1/applications/testapp/renders/abc123/diff/def456",
+		},
+		"missing commit": {
+			args: []string{"renders", "rollback", "testapp"},
+			code: 2,
+		},
+		"unknown command": {
+			args: []string{"nope"},
+			code: 2,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			requests = nil
+			out, errOut := &bytes.Buffer{}, &bytes.Buffer{}
+			code := run(append([]string{"-url", server.URL, "-token", "s3cr3t"}, c.args...), out, errOut)
+			assert.Equal(t, c.code, code, errOut.String())
+			assert.Equal(t, c.expected, out.String())
+			if c.request != "" {
+				assert.Equal(t, []string{c.request}, requests)
+			}
+		})
+	}
+}
+
+func TestRunUnauthorized(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"error":"missing or invalid credentials"}`))
+	}))
+	defer server.Close()
+
+	errOut := &bytes.Buffer{}
+	assert.Equal(t, 1, run([]string{"-url", server.URL, "renders", "list", "testapp"}, &bytes.Buffer{}, errOut))
+	assert.Contains(t, errOut.String(), "401 Unauthorized")
+}
@@ -0,0 +1,122 @@
+/*
+* Copyright 2026 Armory, Inc.
+
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+
+*    http://www.apache.org/licenses/LICENSE-2.0
+
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"time"
+
+	"github.com/armory/dinghy/pkg/web"
+)
+
+// resyncPollInterval is how often resync start -wait polls the job
+var resyncPollInterval = 2 * time.Second
+
+func resync(c *client, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+	switch {
+	case args[0] == "start":
+		return resyncStart(c, args[1:], out)
+	case args[0] == "status" && len(args) == 2:
+		job, err := resyncStatus(c, args[1])
+		if err != nil {
+			return err
+		}
+		printResync(out, job, true)
+		return nil
+	}
+	return errUsage
+}
+
+func resyncStart(c *client, args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("resync start", flag.ContinueOnError)
+	flags.SetOutput(ioutil.Discard)
+	req := web.ResyncRequest{}
+	flags.StringVar(&req.Org, "org", "", "only reprocess dinghyfiles of this org")
+	flags.StringVar(&req.Repo, "repo", "", "only reprocess dinghyfiles of this repo")
+	flags.StringVar(&req.Application, "application", "", "only reprocess the dinghyfile managing this application")
+	flags.BoolVar(&req.ValidateOnly, "validate", false, "render and validate without writing to Spinnaker")
+	flags.IntVar(&req.Concurrency, "concurrency", 0, "dinghyfiles reprocessed at once")
+	wait := flags.Bool("wait", false, "wait for the resync to finish")
+	if err := flags.Parse(args); err != nil || flags.NArg() > 0 {
+		return errUsage
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	job := &web.ResyncJob{}
+	if err := c.do("POST", "/v1/resync", bytes.NewReader(body), job); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "resync %s started, %d dinghyfiles\n", job.ID, job.Total)
+	if !*wait {
+		return nil
+	}
+
+	done := -1
+	for !job.Finished {
+		time.Sleep(resyncPollInterval)
+		if job, err = resyncStatus(c, job.ID); err != nil {
+			return err
+		}
+		if job.Done != done && !job.Finished {
+			done = job.Done
+			fmt.Fprintf(out, "%d/%d done, %d skipped, %d failed\n", job.Done, job.Total, job.Skipped, job.Failed)
+		}
+	}
+	printResync(out, job, false)
+	if job.Failed > 0 {
+		return fmt.Errorf("%d dinghyfiles failed to reprocess", job.Failed)
+	}
+	return nil
+}
+
+func resyncStatus(c *client, id string) (*web.ResyncJob, error) {
+	job := &web.ResyncJob{}
+	if err := c.do("GET", "/v1/resync/"+url.PathEscape(id), nil, job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// printResync summarises job, listing every failure and, with all, every
+// other dinghyfile reprocessed so far
+func printResync(out io.Writer, job *web.ResyncJob, all bool) {
+	state := "running"
+	if job.Finished {
+		state = "finished"
+	}
+	fmt.Fprintf(out, "resync %s %s: %d/%d done, %d skipped, %d failed\n", job.ID, state, job.Done, job.Total, job.Skipped, job.Failed)
+	for _, r := range job.Results {
+		switch {
+		case r.Status == web.ResyncError:
+			fmt.Fprintf(out, "  error    %s: %s\n", r.URL, r.Error)
+		case all && r.Status == web.ResyncSuccess:
+			fmt.Fprintf(out, "  success  %s\n", r.URL)
+		}
+	}
+}
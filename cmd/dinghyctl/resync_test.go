@@ -0,0 +1,79 @@
+/*
+* Copyright 2026 Armory, Inc.
+
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+
+*    http://www.apache.org/licenses/LICENSE-2.0
+
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResync(t *testing.T) {
+	resyncPollInterval = time.Millisecond
+	var bodies []string
+	polls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "POST /v1/resync":
+			body, _ := ioutil.ReadAll(r.Body)
+			bodies = append(bodies, string(body))
+			w.WriteHeader(http.StatusAccepted)
+			w.Write([]byte(`{"id":"resync-1","total":2,"done":0,"results":[]}`))
+		case "GET /v1/resync/resync-1":
+			polls++
+			if polls == 1 {
+				w.Write([]byte(`{"id":"resync-1","total":2,"done":1,"results":[{"url":"a","status":"success"}]}`))
+				return
+			}
+			w.Write([]byte(`{"id":"resync-1","total":2,"done":2,"failed":1,"finished":true,"results":[{"url":"a","status":"success"},{"url":"b","status":"error","error":"boom"}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	out, errOut := &bytes.Buffer{}, &bytes.Buffer{}
+	code := run([]string{"-url", server.URL, "resync", "start", "-org", "org", "-validate"}, out, errOut)
+	assert.Equal(t, 0, code, errOut.String())
+	assert.Equal(t, "resync resync-1 started, 2 dinghyfiles\n", out.String())
+	assert.Equal(t, []string{`{"org":"org","validateOnly":true}`}, bodies)
+	assert.Equal(t, 0, polls)
+
+	out.Reset()
+	code = run([]string{"-url", server.URL, "resync", "start", "-wait"}, out, errOut)
+	assert.Equal(t, 1, code)
+	assert.Equal(t, "resync resync-1 started, 2 dinghyfiles\n"+
+		"1/2 done, 0 skipped, 0 failed\n"+
+		"resync resync-1 finished: 2/2 done, 0 skipped, 1 failed\n"+
+		"  error    b: boom\n", out.String())
+	assert.Contains(t, errOut.String(), "1 dinghyfiles failed to reprocess")
+
+	out.Reset()
+	code = run([]string{"-url", server.URL, "resync", "status", "resync-1"}, out, errOut)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "resync resync-1 finished: 2/2 done, 0 skipped, 1 failed\n"+
+		"  success  a\n"+
+		"  error    b: boom\n", out.String())
+
+	assert.Equal(t, 2, run([]string{"-url", server.URL, "resync", "start", "extra"}, out, errOut))
+	assert.Equal(t, 2, run([]string{"-url", server.URL, "resync", "status"}, out, errOut))
+}
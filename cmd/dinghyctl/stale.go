@@ -0,0 +1,46 @@
+/*
+* Copyright 2026 Armory, Inc.
+
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+
+*    http://www.apache.org/licenses/LICENSE-2.0
+
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"text/tabwriter"
+
+	"github.com/armory/dinghy/pkg/managed"
+)
+
+// stale lists the pipelines the last dry run of each dinghyfile would have
+// deleted from an application
+func stale(c *client, args []string, out io.Writer) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	var list []managed.StalePipelines
+	if err := c.do("GET", "/v1/applications/"+url.PathEscape(args[0])+"/stale", nil, &list); err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "PIPELINE\tCOMMIT\tDINGHYFILE")
+	for _, s := range list {
+		for _, p := range s.Pipelines {
+			fmt.Fprintf(w, "%s\t%s\t%s/%s/%s\n", p, s.Commit, s.Org, s.Repo, s.Path)
+		}
+	}
+	return w.Flush()
+}
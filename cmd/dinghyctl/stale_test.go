@@ -0,0 +1,39 @@
+/*
+* Copyright 2026 Armory, Inc.
+
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+
+*    http://www.apache.org/licenses/LICENSE-2.0
+
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStale(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/applications/testapp/stale", r.URL.Path)
+		w.Write([]byte(`[{"url":"https://github.com/org/repo/dinghyfile","org":"org","repo":"repo","path":"dinghyfile","commit":"abc123","pipelines":["old","older"]}]`))
+	}))
+	defer server.Close()
+
+	out := &bytes.Buffer{}
+	assert.Equal(t, 0, run([]string{"-url", server.URL, "stale", "testapp"}, out, &bytes.Buffer{}))
+	assert.Equal(t, "PIPELINE  COMMIT  DINGHYFILE\nold       abc123  org/repo/dinghyfile\nolder     abc123  org/repo/dinghyfile\n", out.String())
+	assert.Equal(t, 2, run([]string{"-url", server.URL, "stale"}, out, &bytes.Buffer{}))
+}
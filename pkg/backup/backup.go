@@ -0,0 +1,411 @@
+/*
+* Copyright 2026 Armory, Inc.
+
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+
+*    http://www.apache.org/licenses/LICENSE-2.0
+
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+// Package backup exports the state of Dinghy, the dependency graph, the raw
+// push data of the dinghyfiles, the owners of the applications, the pipelines
+// the dinghyfiles manage, the render history and the log events, to an archive
+// of JSON lines and imports it into any backend.
+package backup
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/armory/dinghy/pkg/depgraph"
+	"github.com/armory/dinghy/pkg/history"
+	"github.com/armory/dinghy/pkg/logevents"
+	"github.com/armory/dinghy/pkg/managed"
+	"github.com/armory/dinghy/pkg/ownership"
+)
+
+// Version of the archive format, 2 added the owners, the managed pipelines
+// and the renders
+const Version = 2
+
+// Kinds of record
+const (
+	KindHeader   = "header"
+	KindNode     = "node"
+	KindOwner    = "owner"
+	KindManaged  = "managed"
+	KindRender   = "render"
+	KindLogEvent = "logEvent"
+)
+
+var (
+	ErrNotABackup         = errors.New("not a dinghy backup, the header is missing")
+	ErrRestoreUnsupported = errors.New("the log events backend can't restore log events")
+	ErrStoreUnsupported   = errors.New("the persistence backend can't store owners, managed pipelines nor renders")
+)
+
+// Graph is implemented by the dependency managers a backup can be taken from
+// and restored to
+type Graph interface {
+	depgraph.Reader
+	GetRawData(url string) (string, error)
+	SetRawData(url string, rawData string) error
+	SetDeps(parent string, deps []string)
+}
+
+// Record is a line of the archive. The first one is the header, then come
+// the nodes of the graph with the pipelines their dinghyfile manages, the
+// owners, the renders and the log events.
+type Record struct {
+	Kind string `json:"kind"`
+	// Version and Created, in milliseconds, describe the archive in the header
+	Version int   `json:"version,omitempty"`
+	Created int64 `json:"created,omitempty"`
+	// URL, Dependencies and RawData describe a node
+	URL          string   `json:"url,omitempty"`
+	Dependencies []string `json:"dependencies,omitempty"`
+	RawData      string   `json:"rawData,omitempty"`
+	// Managed is what the dinghyfile of URL applied last
+	Managed *managed.Dinghyfile `json:"managed,omitempty"`
+	// Application and Owner describe the owner of an application
+	Application string           `json:"application,omitempty"`
+	Owner       *ownership.Owner `json:"owner,omitempty"`
+	// Render is a render of the history
+	Render *history.Render `json:"render,omitempty"`
+	// LogEvent is a log event
+	LogEvent *logevents.LogEvent `json:"logEvent,omitempty"`
+}
+
+// Report counts what was exported or imported. After an import, Mismatches
+// lists what the backend doesn't return as archived and LogEventsPresent the
+// log events it returns, the ones past their TTL aren't restored.
+type Report struct {
+	Nodes             int      `json:"nodes"`
+	Edges             int      `json:"edges"`
+	RawData           int      `json:"rawData"`
+	Managed           int      `json:"managed"`
+	Owners            int      `json:"owners"`
+	Renders           int      `json:"renders"`
+	LogEvents         int      `json:"logEvents"`
+	LogEventsRestored int      `json:"logEventsRestored"`
+	LogEventsPresent  int      `json:"logEventsPresent"`
+	Mismatches        []string `json:"mismatches"`
+}
+
+// Export writes the graph and the log events, events can be nil. The owners,
+// managed pipelines and renders are written when the graph keeps them.
+func Export(w io.Writer, g Graph, events logevents.LogEventsClient) (*Report, error) {
+	enc := json.NewEncoder(w)
+	if err := enc.Encode(Record{Kind: KindHeader, Version: Version, Created: time.Now().UnixNano() / int64(time.Millisecond)}); err != nil {
+		return nil, err
+	}
+
+	report := &Report{Mismatches: []string{}}
+	nodes, err := g.ListNodes()
+	if err != nil {
+		return nil, err
+	}
+	for _, url := range nodes {
+		node := Record{Kind: KindNode, URL: url}
+		if node.Dependencies, err = g.Dependencies(url); err != nil {
+			return nil, err
+		}
+		dependents, err := g.Dependents(url)
+		if err != nil {
+			return nil, err
+		}
+		// only the dinghyfiles have raw data
+		if len(dependents) == 0 {
+			if rawData, err := g.GetRawData(url); err == nil && rawData != "" {
+				node.RawData = rawData
+				report.RawData++
+			}
+		}
+		if err := enc.Encode(node); err != nil {
+			return nil, err
+		}
+		report.Nodes++
+		report.Edges += len(node.Dependencies)
+
+		if store, ok := g.(managed.Store); ok {
+			d, err := store.GetManaged(url)
+			if err != nil {
+				return nil, err
+			}
+			if d != nil {
+				if err := enc.Encode(Record{Kind: KindManaged, URL: url, Managed: d}); err != nil {
+					return nil, err
+				}
+				report.Managed++
+			}
+		}
+	}
+	if err := exportOwners(enc, g, report); err != nil {
+		return nil, err
+	}
+	if err := exportRenders(enc, g, report); err != nil {
+		return nil, err
+	}
+
+	if events == nil {
+		return report, nil
+	}
+	found, err := events.GetLogEvents()
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].Date < found[j].Date })
+	for i := range found {
+		if err := enc.Encode(Record{Kind: KindLogEvent, LogEvent: &found[i]}); err != nil {
+			return nil, err
+		}
+		report.LogEvents++
+	}
+	return report, nil
+}
+
+func exportOwners(enc *json.Encoder, g Graph, report *Report) error {
+	lister, ok := g.(ownership.Lister)
+	if !ok {
+		return nil
+	}
+	owners, err := lister.ListOwners()
+	if err != nil {
+		return err
+	}
+	applications := make([]string, 0, len(owners))
+	for application := range owners {
+		applications = append(applications, application)
+	}
+	sort.Strings(applications)
+	for _, application := range applications {
+		owner := owners[application]
+		if err := enc.Encode(Record{Kind: KindOwner, Application: application, Owner: &owner}); err != nil {
+			return err
+		}
+		report.Owners++
+	}
+	return nil
+}
+
+func exportRenders(enc *json.Encoder, g Graph, report *Report) error {
+	store, ok := g.(history.Store)
+	lister, canList := g.(history.Lister)
+	if !ok || !canList {
+		return nil
+	}
+	applications, err := lister.ListApplications()
+	if err != nil {
+		return err
+	}
+	for _, application := range applications {
+		renders, err := store.ListRenders(application)
+		if err != nil {
+			return err
+		}
+		// oldest first, as they were recorded
+		for i := len(renders) - 1; i >= 0; i-- {
+			if err := enc.Encode(Record{Kind: KindRender, Render: &renders[i]}); err != nil {
+				return err
+			}
+			report.Renders++
+		}
+	}
+	return nil
+}
+
+// Import reads an archive into the graph and the log events, then verifies
+// the backends return what was archived. Nodes, owners and managed pipelines
+// already there are replaced, renders and log events already there are kept,
+// so importing twice changes nothing.
+func Import(r io.Reader, g Graph, events logevents.LogEventsClient) (*Report, error) {
+	dec := json.NewDecoder(r)
+	var header Record
+	if err := dec.Decode(&header); err != nil || header.Kind != KindHeader {
+		return nil, ErrNotABackup
+	}
+	if header.Version > Version {
+		return nil, fmt.Errorf("unsupported backup version %d, up to %d is supported", header.Version, Version)
+	}
+
+	report := &Report{Mismatches: []string{}}
+	nodes := []Record{}
+	state := []Record{}
+	archived := []logevents.LogEvent{}
+	for line := 2; ; line++ {
+		var record Record
+		if err := dec.Decode(&record); err == io.EOF {
+			break
+		} else if err != nil {
+			return report, fmt.Errorf("line %d: %v", line, err)
+		}
+		switch {
+		case record.Kind == KindNode && record.URL != "":
+			g.SetDeps(record.URL, record.Dependencies)
+			if record.RawData != "" {
+				if err := g.SetRawData(record.URL, record.RawData); err != nil {
+					return report, fmt.Errorf("line %d: %v", line, err)
+				}
+				report.RawData++
+			}
+			nodes = append(nodes, record)
+			report.Nodes++
+			report.Edges += len(record.Dependencies)
+		case record.Kind == KindManaged && record.URL != "" && record.Managed != nil:
+			store, ok := g.(managed.Store)
+			if !ok {
+				return report, ErrStoreUnsupported
+			}
+			if err := store.SetManaged(record.URL, *record.Managed); err != nil {
+				return report, fmt.Errorf("line %d: %v", line, err)
+			}
+			state = append(state, record)
+			report.Managed++
+		case record.Kind == KindOwner && record.Application != "" && record.Owner != nil:
+			registry, ok := g.(ownership.Registry)
+			if !ok {
+				return report, ErrStoreUnsupported
+			}
+			if err := registry.SetOwner(record.Application, *record.Owner); err != nil {
+				return report, fmt.Errorf("line %d: %v", line, err)
+			}
+			state = append(state, record)
+			report.Owners++
+		case record.Kind == KindRender && record.Render != nil:
+			store, ok := g.(history.Store)
+			if !ok {
+				return report, ErrStoreUnsupported
+			}
+			existing, err := store.GetRender(record.Render.Application, record.Render.ID)
+			if err == nil && existing == nil {
+				err = store.SaveRender(*record.Render)
+			}
+			if err != nil {
+				return report, fmt.Errorf("line %d: %v", line, err)
+			}
+			state = append(state, record)
+			report.Renders++
+		case record.Kind == KindLogEvent && record.LogEvent != nil:
+			restorer, ok := events.(logevents.Restorer)
+			if !ok {
+				return report, ErrRestoreUnsupported
+			}
+			restored, err := restorer.RestoreLogEvent(*record.LogEvent)
+			if err != nil {
+				return report, fmt.Errorf("line %d: %v", line, err)
+			}
+			if restored {
+				report.LogEventsRestored++
+			}
+			archived = append(archived, *record.LogEvent)
+			report.LogEvents++
+		default:
+			return report, fmt.Errorf("line %d: invalid %q record", line, record.Kind)
+		}
+	}
+
+	return report, verify(report, g, nodes, state, events, archived)
+}
+
+func verify(report *Report, g Graph, nodes, state []Record, events logevents.LogEventsClient, archived []logevents.LogEvent) error {
+	for _, node := range nodes {
+		dependencies, err := g.Dependencies(node.URL)
+		if err != nil {
+			return err
+		}
+		if !sameURLs(dependencies, node.Dependencies) {
+			report.Mismatches = append(report.Mismatches, fmt.Sprintf("%s depends on %v instead of %v", node.URL, dependencies, node.Dependencies))
+		}
+		if node.RawData == "" {
+			continue
+		}
+		if rawData, err := g.GetRawData(node.URL); err != nil || rawData != node.RawData {
+			report.Mismatches = append(report.Mismatches, fmt.Sprintf("%s has different raw data", node.URL))
+		}
+	}
+	for _, record := range state {
+		if mismatch, err := verifyState(g, record); err != nil {
+			return err
+		} else if mismatch != "" {
+			report.Mismatches = append(report.Mismatches, mismatch)
+		}
+	}
+
+	if len(archived) == 0 {
+		return nil
+	}
+	found, err := events.GetLogEvents()
+	if err != nil {
+		return err
+	}
+	present := map[string]bool{}
+	for _, e := range found {
+		present[logEventKey(e)] = true
+	}
+	for _, e := range archived {
+		if present[logEventKey(e)] {
+			report.LogEventsPresent++
+		}
+	}
+	return nil
+}
+
+// verifyState describes how the backend differs from a managed, owner or
+// render record, it was imported into a backend keeping them
+func verifyState(g Graph, record Record) (string, error) {
+	switch record.Kind {
+	case KindManaged:
+		d, err := g.(managed.Store).GetManaged(record.URL)
+		if err != nil {
+			return "", err
+		}
+		if d == nil || !reflect.DeepEqual(*d, *record.Managed) {
+			return fmt.Sprintf("%s has different managed pipelines", record.URL), nil
+		}
+	case KindOwner:
+		owner, err := g.(ownership.Registry).GetOwner(record.Application)
+		if err != nil {
+			return "", err
+		}
+		if owner == nil || *owner != *record.Owner {
+			return fmt.Sprintf("application %s has a different owner", record.Application), nil
+		}
+	case KindRender:
+		r, err := g.(history.Store).GetRender(record.Render.Application, record.Render.ID)
+		if err != nil {
+			return "", err
+		}
+		if r == nil {
+			return fmt.Sprintf("render %s of %s is missing", record.Render.ID, record.Render.Application), nil
+		}
+	}
+	return "", nil
+}
+
+// logEventKey identifies a log event across backends, a push to a repository
+func logEventKey(e logevents.LogEvent) string {
+	return strconv.FormatInt(e.Date, 10) + "\x00" + e.Org + "\x00" + e.Repo
+}
+
+func sameURLs(a, b []string) bool {
+	if len(a) == 0 && len(b) == 0 {
+		return true
+	}
+	a, b = append([]string{}, a...), append([]string{}, b...)
+	sort.Strings(a)
+	sort.Strings(b)
+	return reflect.DeepEqual(a, b)
+}
@@ -0,0 +1,355 @@
+/*
+* Copyright 2026 Armory, Inc.
+
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+
+*    http://www.apache.org/licenses/LICENSE-2.0
+
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package backup
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/armory/dinghy/pkg/cache"
+	"github.com/armory/dinghy/pkg/database"
+	"github.com/armory/dinghy/pkg/history"
+	"github.com/armory/dinghy/pkg/logevents"
+	"github.com/armory/dinghy/pkg/managed"
+	"github.com/armory/dinghy/pkg/ownership"
+	"github.com/armory/dinghy/pkg/util"
+	"github.com/go-redis/redis"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+// memoryEvents keeps log events by date, like Redis
+type memoryEvents map[int64]logevents.LogEvent
+
+func (m memoryEvents) GetLogEvents() ([]logevents.LogEvent, error) {
+	found := []logevents.LogEvent{}
+	for _, e := range m {
+		found = append(found, e)
+	}
+	return found, nil
+}
+
+func (m memoryEvents) SaveLogEvent(logEvent logevents.LogEvent) error {
+	m[logEvent.Date] = logEvent
+	return nil
+}
+
+func (m memoryEvents) RestoreLogEvent(logEvent logevents.LogEvent) (bool, error) {
+	if _, exists := m[logEvent.Date]; exists {
+		return false, nil
+	}
+	m[logEvent.Date] = logEvent
+	return true, nil
+}
+
+func testState() (cache.MemoryCache, memoryEvents) {
+	graph := cache.NewMemoryCache()
+	graph.SetDeps("app1/dinghyfile", []string{"stage.module", "wait.module"})
+	graph.SetDeps("stage.module", []string{"wait.module"})
+	graph.SetDeps("old.module", nil)
+	graph.SetRawData("app1/dinghyfile", `{"ref":"refs/heads/master"}`)
+	events := memoryEvents{
+		2: {Org: "org", Repo: "app1", Date: 2, Status: "success"},
+		1: {Org: "org", Repo: "app1", Date: 1, Status: "failure"},
+	}
+	return graph, events
+}
+
+func TestRoundTrip(t *testing.T) {
+	graph, events := testState()
+	var archive bytes.Buffer
+	report, err := Export(&archive, graph, events)
+	assert.Nil(t, err)
+	assert.Equal(t, &Report{Nodes: 4, Edges: 3, RawData: 1, LogEvents: 2, Mismatches: []string{}}, report)
+	lines := strings.Split(strings.TrimSpace(archive.String()), "\n")
+	if assert.Len(t, lines, 7) {
+		assert.Contains(t, lines[0], `{"kind":"header","version":2,"created":`)
+		assert.Equal(t, `{"kind":"node","url":"app1/dinghyfile","dependencies":["stage.module","wait.module"],"rawData":"{\"ref\":\"refs/heads/master\"}"}`, lines[1])
+		assert.Equal(t, `{"kind":"node","url":"old.module"}`, lines[2])
+		assert.Contains(t, lines[5], `"date":1,`)
+	}
+
+	restored, restoredEvents := cache.NewMemoryCache(), memoryEvents{}
+	report, err = Import(bytes.NewReader(archive.Bytes()), restored, restoredEvents)
+	assert.Nil(t, err)
+	assert.Equal(t, &Report{Nodes: 4, Edges: 3, RawData: 1, LogEvents: 2, LogEventsRestored: 2, LogEventsPresent: 2, Mismatches: []string{}}, report)
+	assert.Equal(t, events, restoredEvents)
+	assert.Equal(t, []string{"app1/dinghyfile"}, restored.GetRoots("wait.module"))
+	nodes, _ := restored.ListNodes()
+	assert.Equal(t, []string{"app1/dinghyfile", "old.module", "stage.module", "wait.module"}, nodes)
+	rawData, _ := restored.GetRawData("app1/dinghyfile")
+	assert.Equal(t, `{"ref":"refs/heads/master"}`, rawData)
+
+	// idempotent
+	report, err = Import(bytes.NewReader(archive.Bytes()), restored, restoredEvents)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, report.LogEventsRestored)
+	assert.Equal(t, 2, report.LogEventsPresent)
+	assert.Empty(t, report.Mismatches)
+	assert.Len(t, restoredEvents, 2)
+}
+
+func TestImportInvalid(t *testing.T) {
+	graph := cache.NewMemoryCache()
+	cases := map[string]struct {
+		archive  string
+		expected string
+	}{
+		"empty":       {archive: "", expected: ErrNotABackup.Error()},
+		"no header":   {archive: `{"kind":"node","url":"a"}`, expected: ErrNotABackup.Error()},
+		"too new":     {archive: `{"kind":"header","version":3}`, expected: "unsupported backup version 3, up to 2 is supported"},
+		"bad json":    {archive: "{\"kind\":\"header\",\"version\":1}\n{\"kind\":", expected: "line 2: unexpected EOF"},
+		"bad kind":    {archive: "{\"kind\":\"header\",\"version\":1}\n{\"kind\":\"pipeline\"}", expected: `line 2: invalid "pipeline" record`},
+		"no restorer": {archive: "{\"kind\":\"header\",\"version\":1}\n{\"kind\":\"logEvent\",\"logEvent\":{\"date\":1}}", expected: ErrRestoreUnsupported.Error()},
+		"no store":    {archive: "{\"kind\":\"header\",\"version\":2}\n{\"kind\":\"owner\",\"application\":\"app1\",\"owner\":{\"org\":\"org\"}}", expected: ErrStoreUnsupported.Error()},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := Import(strings.NewReader(c.archive), graph, nil)
+			assert.EqualError(t, err, c.expected)
+		})
+	}
+}
+
+// stateGraph also keeps the owners, the managed pipelines and the renders
+type stateGraph struct {
+	cache.MemoryCache
+	owners  map[string]ownership.Owner
+	managed map[string]managed.Dinghyfile
+	renders map[string][]history.Render
+}
+
+func newStateGraph() *stateGraph {
+	return &stateGraph{
+		MemoryCache: cache.NewMemoryCache(),
+		owners:      map[string]ownership.Owner{},
+		managed:     map[string]managed.Dinghyfile{},
+		renders:     map[string][]history.Render{},
+	}
+}
+
+func (g *stateGraph) GetOwner(application string) (*ownership.Owner, error) {
+	if owner, ok := g.owners[application]; ok {
+		return &owner, nil
+	}
+	return nil, nil
+}
+
+func (g *stateGraph) ClaimOwner(application string, owner ownership.Owner) (*ownership.Owner, error) {
+	if _, ok := g.owners[application]; !ok {
+		g.owners[application] = owner
+	}
+	return g.GetOwner(application)
+}
+
+func (g *stateGraph) SetOwner(application string, owner ownership.Owner) error {
+	g.owners[application] = owner
+	return nil
+}
+
+func (g *stateGraph) ListOwners() (map[string]ownership.Owner, error) {
+	return g.owners, nil
+}
+
+func (g *stateGraph) GetManaged(url string) (*managed.Dinghyfile, error) {
+	if d, ok := g.managed[url]; ok {
+		return &d, nil
+	}
+	return nil, nil
+}
+
+func (g *stateGraph) SetManaged(url string, d managed.Dinghyfile) error {
+	g.managed[url] = d
+	return nil
+}
+
+func (g *stateGraph) DeleteManaged(url string) error {
+	delete(g.managed, url)
+	return nil
+}
+
+func (g *stateGraph) SaveRender(r history.Render) error {
+	g.renders[r.Application] = append([]history.Render{r}, g.renders[r.Application]...)
+	return nil
+}
+
+func (g *stateGraph) ListRenders(application string) ([]history.Render, error) {
+	return g.renders[application], nil
+}
+
+func (g *stateGraph) GetRender(application, id string) (*history.Render, error) {
+	for _, r := range g.renders[application] {
+		if r.ID == id {
+			return &r, nil
+		}
+	}
+	return nil, nil
+}
+
+func (g *stateGraph) PruneRenders(application string, keep int) error {
+	return nil
+}
+
+func (g *stateGraph) ListApplications() ([]string, error) {
+	applications := []string{}
+	for application := range g.renders {
+		applications = append(applications, application)
+	}
+	return applications, nil
+}
+
+func TestRoundTripState(t *testing.T) {
+	graph := newStateGraph()
+	graph.SetDeps("app1/dinghyfile", []string{"wait.module"})
+	graph.SetOwner("app1", ownership.Owner{Org: "org", Repo: "app1", Path: "dinghyfile"})
+	graph.SetManaged("app1/dinghyfile", managed.Dinghyfile{
+		Org:         "org",
+		Repo:        "app1",
+		Path:        "dinghyfile",
+		Application: "app1",
+		Pipelines:   []managed.Pipeline{{Name: "deploy"}},
+	})
+	graph.SaveRender(history.Render{ID: "1-old", Application: "app1", Commit: "old", Date: 1})
+	graph.SaveRender(history.Render{ID: "2-new", Application: "app1", Commit: "new", Date: 2, RollbackOf: "1-old"})
+
+	var archive bytes.Buffer
+	report, err := Export(&archive, graph, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, &Report{Nodes: 2, Edges: 1, Managed: 1, Owners: 1, Renders: 2, Mismatches: []string{}}, report)
+	lines := strings.Split(strings.TrimSpace(archive.String()), "\n")
+	if assert.Len(t, lines, 7) {
+		assert.Equal(t, `{"kind":"managed","url":"app1/dinghyfile","managed":{"org":"org","repo":"app1","path":"dinghyfile","application":"app1","pipelines":[{"name":"deploy"}]}}`, lines[2])
+		assert.Equal(t, `{"kind":"owner","application":"app1","owner":{"org":"org","repo":"app1","path":"dinghyfile"}}`, lines[4])
+		assert.Contains(t, lines[5], `"id":"1-old"`)
+	}
+
+	restored := newStateGraph()
+	for i := 0; i < 2; i++ {
+		report, err = Import(bytes.NewReader(archive.Bytes()), restored, nil)
+		assert.Nil(t, err)
+		assert.Equal(t, &Report{Nodes: 2, Edges: 1, Managed: 1, Owners: 1, Renders: 2, Mismatches: []string{}}, report)
+	}
+	assert.Equal(t, graph.owners, restored.owners)
+	assert.Equal(t, graph.managed, restored.managed)
+	assert.Equal(t, graph.renders, restored.renders)
+}
+
+// mismatchedGraph drops the edges it's given
+type mismatchedGraph struct {
+	cache.MemoryCache
+}
+
+func (m mismatchedGraph) SetDeps(parent string, deps []string) {
+	m.MemoryCache.SetDeps(parent, nil)
+}
+
+func TestImportMismatches(t *testing.T) {
+	graph, _ := testState()
+	var archive bytes.Buffer
+	_, err := Export(&archive, graph, nil)
+	assert.Nil(t, err)
+
+	report, err := Import(&archive, mismatchedGraph{cache.NewMemoryCache()}, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"app1/dinghyfile depends on [] instead of [stage.module wait.module]",
+		"stage.module depends on [] instead of [wait.module]",
+	}, report.Mismatches)
+}
+
+func TestRedisToSQLite(t *testing.T) {
+	redisCache := cache.NewRedisCache(&redis.Options{
+		Addr:     fmt.Sprintf("%s:%s", util.GetenvOrDefault("REDIS_HOST", "redis"), util.GetenvOrDefault("REDIS_PORT", "6379")),
+		Password: util.GetenvOrDefault("REDIS_PASSWORD", ""),
+	}, logrus.New(), context.Background(), make(chan os.Signal, 1), false)
+	if err := redisCache.Ping(context.Background()); err != nil {
+		t.Skip("Could not connect to Redis; skipping test")
+	}
+	// other packages' tests share the Redis, the export reads all of it
+	clear := func() {
+		redisCache.Clear()
+		for _, kind := range []string{"ownership", "managed", "renders", "render"} {
+			redisCache.ScanKeys(cache.CompileKey(kind, "*"), func(keys []string) error {
+				for _, key := range keys {
+					redisCache.Client.Del(key)
+				}
+				return nil
+			})
+		}
+	}
+	clear()
+	defer clear()
+	redisCache.SetDeps("org/repo/dinghyfile", []string{"stage.module"})
+	redisCache.SetDeps("stage.module", []string{"wait.module"})
+	redisCache.SetRawData("org/repo/dinghyfile", `{"ref":"refs/heads/master"}`)
+	owner := ownership.Owner{Org: "org", Repo: "repo", Path: "dinghyfile"}
+	state := managed.Dinghyfile{Org: "org", Repo: "repo", Path: "dinghyfile", Application: "app", Pipelines: []managed.Pipeline{{Name: "deploy"}}}
+	render := history.Render{ID: "1-abc", Application: "app", Commit: "abc", URL: "org/repo/dinghyfile", Date: 1, Dinghyfile: `{"application":"app"}`}
+	assert.Nil(t, redisCache.SetOwner("app", owner))
+	assert.Nil(t, redisCache.SetManaged("org/repo/dinghyfile", state))
+	assert.Nil(t, redisCache.SaveRender(render))
+	redisEvents := logevents.LogEventRedisClient{RedisClient: redisCache, MinutesTTL: 60}
+	found, _ := redisEvents.ListLogEvents()
+	redisEvents.DeleteLogEvents(found)
+	defer func() {
+		found, _ := redisEvents.ListLogEvents()
+		redisEvents.DeleteLogEvents(found)
+	}()
+	date := time.Now().Add(-time.Hour).UnixNano() / int64(time.Millisecond)
+	restored, err := redisEvents.RestoreLogEvent(logevents.LogEvent{Org: "org", Repo: "repo", Date: date - 1})
+	assert.Nil(t, err)
+	assert.False(t, restored, "past its TTL")
+	assert.Nil(t, redisEvents.SaveLogEvent(logevents.LogEvent{Org: "org", Repo: "repo", Files: []string{"dinghyfile"}, Status: "success"}))
+
+	var archive bytes.Buffer
+	report, err := Export(&archive, redisCache, redisEvents)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, report.Nodes)
+	assert.Equal(t, 1, report.Owners)
+	assert.Equal(t, 1, report.Managed)
+	assert.Equal(t, 1, report.Renders)
+	assert.Equal(t, 1, report.LogEvents)
+
+	sqlClient, err := database.NewSQLiteClient(&database.SQLConfig{DbName: filepath.Join(t.TempDir(), "dinghy.db")}, logrus.New(), context.Background(), make(chan os.Signal, 1))
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	assert.Nil(t, sqlClient.Client.AutoMigrate(&logevents.LogEventSQL{}))
+	sqlEvents := logevents.LogEventSQLClient{SQLClient: sqlClient, MinutesTTL: 60}
+	for i := 0; i < 2; i++ {
+		report, err = Import(bytes.NewReader(archive.Bytes()), sqlClient, sqlEvents)
+		assert.Nil(t, err)
+		assert.Empty(t, report.Mismatches)
+		assert.Equal(t, 1, report.LogEventsPresent)
+		assert.Equal(t, 1-i, report.LogEventsRestored)
+	}
+	assert.Equal(t, []string{"org/repo/dinghyfile"}, sqlClient.GetRoots("wait.module"))
+	rawData, _ := sqlClient.GetRawData("org/repo/dinghyfile")
+	assert.Equal(t, `{"ref":"refs/heads/master"}`, rawData)
+	owners, _ := sqlClient.ListOwners()
+	assert.Equal(t, map[string]ownership.Owner{"app": owner}, owners)
+	restoredState, _ := sqlClient.GetManaged("org/repo/dinghyfile")
+	assert.Equal(t, &state, restoredState)
+	renders, _ := sqlClient.ListRenders("app")
+	assert.Equal(t, []history.Render{render}, renders)
+}
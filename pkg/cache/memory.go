@@ -17,6 +17,8 @@
 package cache
 
 import (
+	"sort"
+
 	log "github.com/sirupsen/logrus"
 )
 
@@ -27,6 +29,8 @@ type Node struct {
 	URL      string
 	Children []*Node
 	Parents  []*Node
+	// RawData is the push that last processed the dinghyfile
+	RawData string
 }
 
 func (n *Node) String() string {
@@ -59,12 +63,19 @@ func findInSlice(n *Node, slice []*Node) int {
 	return -1
 }
 
-// Set RawData
+// SetRawData records the push of a url, ignored when the url isn't cached
 func (c MemoryCache) SetRawData(url string, rawData string) error {
+	if node, exists := c[url]; exists {
+		node.RawData = rawData
+	}
 	return nil
 }
 
+// GetRawData returns the push of a url, empty when there is none
 func (c MemoryCache) GetRawData(url string) (string, error) {
+	if node, exists := c[url]; exists {
+		return node.RawData, nil
+	}
 	return "", nil
 }
 
@@ -115,6 +126,73 @@ func (c MemoryCache) SetDeps(parent string, deps []string) {
 	}
 }
 
+// DeleteNode removes a node and all the edges to and from it
+func (c MemoryCache) DeleteNode(url string) error {
+	node, exists := c[url]
+	if !exists {
+		return nil
+	}
+	for _, child := range node.Children {
+		if i := findInSlice(node, child.Parents); i != -1 {
+			child.Parents = append(child.Parents[:i], child.Parents[i+1:]...)
+		}
+	}
+	for _, parent := range node.Parents {
+		if i := findInSlice(node, parent.Children); i != -1 {
+			parent.Children = append(parent.Children[:i], parent.Children[i+1:]...)
+		}
+	}
+	delete(c, url)
+	return nil
+}
+
+// ListRoots returns the nodes without parents, sorted
+func (c MemoryCache) ListRoots() ([]string, error) {
+	roots := make([]string, 0)
+	for url, node := range c {
+		if len(node.Parents) == 0 {
+			roots = append(roots, url)
+		}
+	}
+	sort.Strings(roots)
+	return roots, nil
+}
+
+// Dependents returns the urls using url directly, sorted
+func (c MemoryCache) Dependents(url string) ([]string, error) {
+	if node, exists := c[url]; exists {
+		return nodeURLs(node.Parents), nil
+	}
+	return []string{}, nil
+}
+
+// Dependencies returns the urls url uses directly, sorted
+func (c MemoryCache) Dependencies(url string) ([]string, error) {
+	if node, exists := c[url]; exists {
+		return nodeURLs(node.Children), nil
+	}
+	return []string{}, nil
+}
+
+// ListNodes returns every url of the cache, sorted
+func (c MemoryCache) ListNodes() ([]string, error) {
+	urls := make([]string, 0, len(c))
+	for url := range c {
+		urls = append(urls, url)
+	}
+	sort.Strings(urls)
+	return urls, nil
+}
+
+func nodeURLs(nodes []*Node) []string {
+	urls := make([]string, 0, len(nodes))
+	for _, n := range nodes {
+		urls = append(urls, n.URL)
+	}
+	sort.Strings(urls)
+	return urls
+}
+
 // UpstreamURLs returns two arrays:
 // 1) Array of all upstream URLs from a URL
 // 2) Array of only the root URLs (dinghyfiles) for a given URL
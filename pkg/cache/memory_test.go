@@ -19,6 +19,7 @@ package cache
 import (
 	"testing"
 
+	"github.com/armory/dinghy/pkg/depgraph/depgraphtest"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -114,3 +115,66 @@ func createCache() MemoryCache {
 
 	return c
 }
+
+func TestDeleteNode(t *testing.T) {
+	c := NewMemoryCache()
+
+	c.SetDeps("df1", []string{"mod1", "mod2"})
+	c.SetDeps("df2", []string{"mod2"})
+	c.SetDeps("mod2", []string{"mod3"})
+
+	assert.Nil(t, c.DeleteNode("df1"))
+	_, exists := c["df1"]
+	assert.False(t, exists, "df1 should have been removed")
+	assert.ElementsMatchf(t, c["mod1"].Parents, []*Node{}, "mod1 should not have any parents")
+	assert.ElementsMatchf(t, c["mod2"].Parents, []*Node{c["df2"]}, "mod2 should only have df2 as parent")
+
+	assert.Nil(t, c.DeleteNode("mod2"))
+	assert.ElementsMatchf(t, c["df2"].Children, []*Node{}, "df2 should not have any children")
+	assert.ElementsMatchf(t, c["mod3"].Parents, []*Node{}, "mod3 should not have any parents")
+
+	// deleting an unknown node is a no-op
+	assert.Nil(t, c.DeleteNode("unknown"))
+}
+
+func TestListRoots(t *testing.T) {
+	c := NewMemoryCache()
+
+	c.SetDeps("df2", []string{"mod1", "mod2"})
+	c.SetDeps("df1", []string{"mod2"})
+	c.SetDeps("mod2", []string{"mod3"})
+	c.SetDeps("df3", []string{})
+
+	roots, err := c.ListRoots()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"df1", "df2", "df3"}, roots)
+}
+
+func TestGraphReader(t *testing.T) {
+	c := NewMemoryCache()
+
+	c.SetDeps("df2", []string{"mod2", "mod1"})
+	c.SetDeps("df1", []string{"mod2"})
+
+	dependents, err := c.Dependents("mod2")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"df1", "df2"}, dependents)
+
+	dependencies, err := c.Dependencies("df2")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"mod1", "mod2"}, dependencies)
+
+	dependencies, err = c.Dependencies("unknown")
+	assert.Nil(t, err)
+	assert.Empty(t, dependencies)
+
+	nodes, err := c.ListNodes()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"df1", "df2", "mod1", "mod2"}, nodes)
+}
+
+func TestMemoryCacheConformance(t *testing.T) {
+	depgraphtest.Run(t, func(t *testing.T) depgraphtest.DependencyManager {
+		return NewMemoryCache()
+	})
+}
@@ -18,19 +18,25 @@ package cache
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
+	"sort"
 	"strings"
-	"syscall"
+	"sync"
 	"time"
 
+	"github.com/armory/dinghy/pkg/history"
+	"github.com/armory/dinghy/pkg/managed"
+	"github.com/armory/dinghy/pkg/ownership"
 	"github.com/go-redis/redis"
 	log "github.com/sirupsen/logrus"
 )
 
-// RedisCache maintains a dependency graph inside Redis
+// RedisCache maintains a dependency graph inside Redis, a single node, a
+// Sentinel master or a Cluster
 type RedisCache struct {
-	Client *redis.Client
+	Client redis.UniversalClient
 	Logger *log.Entry
 	ctx    context.Context
 	stop   chan os.Signal
@@ -42,8 +48,14 @@ func CompileKey(keys ...string) string {
 
 // NewRedisCache initializes a new cache
 func NewRedisCache(redisOptions *redis.Options, logger *log.Logger, ctx context.Context, stop chan os.Signal, startMonitor bool) *RedisCache {
+	return NewUniversalRedisCache(redis.NewClient(redisOptions), logger, ctx, stop, startMonitor)
+}
+
+// NewUniversalRedisCache initializes a new cache on top of any client, for
+// Sentinel and Cluster
+func NewUniversalRedisCache(client redis.UniversalClient, logger *log.Logger, ctx context.Context, stop chan os.Signal, startMonitor bool) *RedisCache {
 	rc := &RedisCache{
-		Client: redis.NewClient(redisOptions),
+		Client: client,
 		Logger: logger.WithFields(log.Fields{"cache": "redis"}),
 		ctx:    ctx,
 		stop:   stop,
@@ -54,22 +66,71 @@ func NewRedisCache(redisOptions *redis.Options, logger *log.Logger, ctx context.
 	return rc
 }
 
+// Ping checks the connection to Redis
+func (c *RedisCache) Ping(ctx context.Context) error {
+	switch client := c.Client.(type) {
+	case *redis.Client:
+		return client.WithContext(ctx).Ping().Err()
+	case *redis.ClusterClient:
+		return client.WithContext(ctx).Ping().Err()
+	}
+	return c.Client.Ping().Err()
+}
+
+// ScanKeys calls fn with the keys matching pattern, a batch at a time. On a
+// Cluster every master is scanned, fn is never called concurrently.
+func (c *RedisCache) ScanKeys(pattern string, fn func(keys []string) error) error {
+	return scanKeys(c.Client, pattern, fn)
+}
+
+func scanKeys(c redis.UniversalClient, pattern string, fn func(keys []string) error) error {
+	cluster, ok := c.(*redis.ClusterClient)
+	if !ok {
+		return scanNode(c, pattern, fn)
+	}
+	var mutex sync.Mutex
+	return cluster.ForEachMaster(func(master *redis.Client) error {
+		return scanNode(master, pattern, func(keys []string) error {
+			mutex.Lock()
+			defer mutex.Unlock()
+			return fn(keys)
+		})
+	})
+}
+
+func scanNode(c redis.Cmdable, pattern string, fn func(keys []string) error) error {
+	var cursor uint64
+	for {
+		keys, next, err := c.Scan(cursor, pattern, 1000).Result()
+		if err != nil {
+			return err
+		}
+		if err := fn(keys); err != nil {
+			return err
+		}
+		if cursor = next; cursor == 0 {
+			return nil
+		}
+	}
+}
+
+// monitorWorker logs the failing pings of Redis, the readiness check takes
+// dinghy out of the rotation while Redis can't be reached
 func (c *RedisCache) monitorWorker() {
 	timer := time.NewTicker(10 * time.Second)
+	defer timer.Stop()
 	count := 0
 	for {
 		select {
 		case <-timer.C:
 			if _, err := c.Client.Ping().Result(); err != nil {
 				count++
-				c.Logger.Errorf("Redis monitor failed %d times (5 max)", count)
-				if count >= 5 {
-					c.Logger.Error("Stopping dinghy because communication with redis failed")
-					timer.Stop()
-					c.stop <- syscall.SIGINT
-				}
+				c.Logger.Errorf("Redis monitor failed %d times in a row: %s", count, err.Error())
 				continue
 			}
+			if count > 0 {
+				c.Logger.Info("Communication with redis restored")
+			}
 			count = 0
 		case <-c.ctx.Done():
 			return
@@ -140,12 +201,43 @@ func (c *RedisCache) SetDeps(parent string, deps []string) {
 	}
 }
 
+// DeleteNode removes a node, the edges to and from it and its raw data
+func (c *RedisCache) DeleteNode(url string) error {
+	children, err := c.Client.SMembers(CompileKey("children", url)).Result()
+	if err != nil {
+		return err
+	}
+	for _, child := range children {
+		if err := c.Client.SRem(CompileKey("parents", child), url).Err(); err != nil {
+			return err
+		}
+	}
+
+	parents, err := c.Client.SMembers(CompileKey("parents", url)).Result()
+	if err != nil {
+		return err
+	}
+	for _, parent := range parents {
+		if err := c.Client.SRem(CompileKey("children", parent), url).Err(); err != nil {
+			return err
+		}
+	}
+
+	// one key at a time, they don't share a Cluster slot
+	for _, key := range []string{CompileKey("children", url), CompileKey("parents", url), CompileKey("rawdata", url)} {
+		if err := c.Client.Del(key).Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // GetRoots grabs roots
 func (c *RedisCache) GetRoots(url string) []string {
 	return returnRoots(c.Client, url)
 }
 
-func returnRoots(c *redis.Client, url string) []string {
+func returnRoots(c redis.UniversalClient, url string) []string {
 	roots := make([]string, 0)
 	visited := map[string]bool{}
 	loge := log.WithFields(log.Fields{"func": "GetRoots"})
@@ -178,6 +270,90 @@ func returnRoots(c *redis.Client, url string) []string {
 	return roots
 }
 
+// ListRoots returns the dinghyfiles with modules or a managed record that
+// have no parents, sorted. Dinghyfiles without modules only have a node in
+// Redis once they are managed.
+func (c *RedisCache) ListRoots() ([]string, error) {
+	return returnListRoots(c.Client)
+}
+
+func returnListRoots(c redis.UniversalClient) ([]string, error) {
+	candidates := map[string]bool{}
+	for _, kind := range []string{"children", "managed"} {
+		prefix := CompileKey(kind, "")
+		err := scanKeys(c, prefix+"*", func(keys []string) error {
+			for _, key := range keys {
+				candidates[strings.TrimPrefix(key, prefix)] = true
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	roots := make([]string, 0, len(candidates))
+	for url := range candidates {
+		parents, err := c.SCard(CompileKey("parents", url)).Result()
+		if err != nil {
+			return nil, err
+		}
+		if parents == 0 {
+			roots = append(roots, url)
+		}
+	}
+	sort.Strings(roots)
+	return roots, nil
+}
+
+// Dependents returns the urls using url directly, sorted
+func (c *RedisCache) Dependents(url string) ([]string, error) {
+	return returnMembers(c.Client, CompileKey("parents", url))
+}
+
+// Dependencies returns the urls url uses directly, sorted
+func (c *RedisCache) Dependencies(url string) ([]string, error) {
+	return returnMembers(c.Client, CompileKey("children", url))
+}
+
+func returnMembers(c redis.UniversalClient, key string) ([]string, error) {
+	members, err := c.SMembers(key).Result()
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(members)
+	return members, nil
+}
+
+// ListNodes returns every url with an edge or raw data, sorted. Redis drops
+// the sets of a url once they are empty, so the dinghyfiles without modules
+// are only found through their raw data.
+func (c *RedisCache) ListNodes() ([]string, error) {
+	return returnListNodes(c.Client)
+}
+
+func returnListNodes(c redis.UniversalClient) ([]string, error) {
+	nodes := map[string]bool{}
+	for _, kind := range []string{"children", "parents", "rawdata"} {
+		prefix := CompileKey(kind, "")
+		err := scanKeys(c, prefix+"*", func(keys []string) error {
+			for _, key := range keys {
+				nodes[strings.TrimPrefix(key, prefix)] = true
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	urls := make([]string, 0, len(nodes))
+	for url := range nodes {
+		urls = append(urls, url)
+	}
+	sort.Strings(urls)
+	return urls, nil
+}
+
 // Set RawData
 func (c *RedisCache) SetRawData(url string, rawData string) error {
 	loge := log.WithFields(log.Fields{"func": "SetRawData"})
@@ -195,7 +371,7 @@ func (c *RedisCache) GetRawData(url string) (string, error) {
 	return returnRawData(c.Client, url)
 }
 
-func returnRawData(c *redis.Client, url string) (string, error) {
+func returnRawData(c redis.UniversalClient, url string) (string, error) {
 	loge := log.WithFields(log.Fields{"func": "GetRawData"})
 	key := CompileKey("rawdata", url)
 
@@ -207,36 +383,226 @@ func returnRawData(c *redis.Client, url string) (string, error) {
 	return stringCmd.Result()
 }
 
+// GetOwner returns the owner of an application, nil when it has none
+func (c *RedisCache) GetOwner(application string) (*ownership.Owner, error) {
+	return returnOwner(c.Client, application)
+}
+
+// ClaimOwner records owner for an application unless it already has one
+func (c *RedisCache) ClaimOwner(application string, owner ownership.Owner) (*ownership.Owner, error) {
+	value, err := json.Marshal(owner)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.Client.SetNX(CompileKey("ownership", application), value, 0).Err(); err != nil {
+		return nil, err
+	}
+	return returnOwner(c.Client, application)
+}
+
+// SetOwner overwrites the owner of an application
+func (c *RedisCache) SetOwner(application string, owner ownership.Owner) error {
+	value, err := json.Marshal(owner)
+	if err != nil {
+		return err
+	}
+	return c.Client.Set(CompileKey("ownership", application), value, 0).Err()
+}
+
+// ListOwners returns the owner of every application
+func (c *RedisCache) ListOwners() (map[string]ownership.Owner, error) {
+	owners := map[string]ownership.Owner{}
+	prefix := CompileKey("ownership", "")
+	err := c.ScanKeys(prefix+"*", func(keys []string) error {
+		for _, key := range keys {
+			application := strings.TrimPrefix(key, prefix)
+			owner, err := returnOwner(c.Client, application)
+			if err != nil {
+				return err
+			}
+			if owner != nil {
+				owners[application] = *owner
+			}
+		}
+		return nil
+	})
+	return owners, err
+}
+
+func returnOwner(c redis.UniversalClient, application string) (*ownership.Owner, error) {
+	value, err := c.Get(CompileKey("ownership", application)).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var owner ownership.Owner
+	if err := json.Unmarshal(value, &owner); err != nil {
+		return nil, err
+	}
+	return &owner, nil
+}
+
+// GetManaged returns what a dinghyfile last applied, nil when nothing is recorded
+func (c *RedisCache) GetManaged(url string) (*managed.Dinghyfile, error) {
+	return returnManaged(c.Client, url)
+}
+
+// SetManaged records what a dinghyfile applied
+func (c *RedisCache) SetManaged(url string, d managed.Dinghyfile) error {
+	value, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+	return c.Client.Set(CompileKey("managed", url), value, 0).Err()
+}
+
+// DeleteManaged forgets what a dinghyfile applied
+func (c *RedisCache) DeleteManaged(url string) error {
+	return c.Client.Del(CompileKey("managed", url)).Err()
+}
+
+func returnManaged(c redis.UniversalClient, url string) (*managed.Dinghyfile, error) {
+	value, err := c.Get(CompileKey("managed", url)).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var d managed.Dinghyfile
+	if err := json.Unmarshal(value, &d); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// SaveRender records the rendered dinghyfile of an apply
+func (c *RedisCache) SaveRender(r history.Render) error {
+	value, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	if err := c.Client.Set(CompileKey("render", r.Application, r.ID), value, 0).Err(); err != nil {
+		return err
+	}
+	return c.Client.ZAdd(CompileKey("renders", r.Application), redis.Z{Score: float64(r.Date), Member: r.ID}).Err()
+}
+
+// ListRenders returns the renders of an application, newest first
+func (c *RedisCache) ListRenders(application string) ([]history.Render, error) {
+	return returnRenders(c.Client, application)
+}
+
+// GetRender returns the render with the id, nil when there is none
+func (c *RedisCache) GetRender(application, id string) (*history.Render, error) {
+	return returnRender(c.Client, application, id)
+}
+
+// ListApplications returns the applications with renders
+func (c *RedisCache) ListApplications() ([]string, error) {
+	applications := []string{}
+	prefix := CompileKey("renders", "")
+	err := c.ScanKeys(prefix+"*", func(keys []string) error {
+		for _, key := range keys {
+			applications = append(applications, strings.TrimPrefix(key, prefix))
+		}
+		return nil
+	})
+	sort.Strings(applications)
+	return applications, err
+}
+
+// PruneRenders deletes all but the newest keep renders of an application
+func (c *RedisCache) PruneRenders(application string, keep int) error {
+	key := CompileKey("renders", application)
+	ids, err := c.Client.ZRevRange(key, int64(keep), -1).Result()
+	if err != nil || len(ids) == 0 {
+		return err
+	}
+	members := make([]interface{}, 0, len(ids))
+	for _, id := range ids {
+		if err := c.Client.Del(CompileKey("render", application, id)).Err(); err != nil {
+			return err
+		}
+		members = append(members, id)
+	}
+	return c.Client.ZRem(key, members...).Err()
+}
+
+func returnRenders(c redis.UniversalClient, application string) ([]history.Render, error) {
+	ids, err := c.ZRevRange(CompileKey("renders", application), 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+	renders := make([]history.Render, 0, len(ids))
+	for _, id := range ids {
+		r, err := returnRender(c, application, id)
+		if err != nil {
+			return nil, err
+		}
+		if r != nil {
+			renders = append(renders, *r)
+		}
+	}
+	return renders, nil
+}
+
+// returnRender identifies the renders recorded before they had an id by the
+// commit they were keyed with
+func returnRender(c redis.UniversalClient, application, id string) (*history.Render, error) {
+	value, err := c.Get(CompileKey("render", application, id)).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var r history.Render
+	if err := json.Unmarshal(value, &r); err != nil {
+		return nil, err
+	}
+	if r.ID == "" {
+		r.ID = id
+	}
+	return &r, nil
+}
+
+// TryLock takes the lock name for ttl, reporting whether no other replica
+// held it. The lock is only released by expiring.
+func (c *RedisCache) TryLock(name string, ttl time.Duration) (bool, error) {
+	host, _ := os.Hostname()
+	return c.Client.SetNX(CompileKey("lock", name), host, ttl).Result()
+}
+
 // Clear clears everything
 func (c *RedisCache) Clear() {
-	keys, _ := c.Client.Keys(CompileKey("children", "*")).Result()
-	c.Client.Del(keys...)
-
-	keys, _ = c.Client.Keys(CompileKey("parents", "*")).Result()
-	c.Client.Del(keys...)
+	for _, kind := range []string{"children", "parents"} {
+		c.ScanKeys(CompileKey(kind, "*"), func(keys []string) error {
+			for _, key := range keys {
+				c.Client.Del(key)
+			}
+			return nil
+		})
+	}
 }
 
 // Get all Dinghyfiles
 func (c *RedisCache) GetAllDinghyfiles() []string {
 	loge := log.WithFields(log.Fields{"func": "GetAllDinghyfiles"})
 	key := CompileKey("parents", "*")
-	var cursor uint64
 	result := []string{}
 	childrens := map[string]bool{}
-	for {
-		keys, nextcursor, err := c.Client.Scan(cursor, key, 1000).Result()
-		cursor = nextcursor
-		if err != nil {
-			loge.WithFields(log.Fields{"operation": "scan key", "key": CompileKey("parents", "*")}).Error(err)
-			return result
-		}
+	err := c.ScanKeys(key, func(keys []string) error {
 		for _, key := range keys {
 			childrens[key] = true
 		}
-
-		if cursor == 0 {
-			break
-		}
+		return nil
+	})
+	if err != nil {
+		loge.WithFields(log.Fields{"operation": "scan key", "key": key}).Error(err)
+		return result
 	}
 
 	for currentChildren, _ := range childrens {
@@ -18,6 +18,9 @@ package cache
 
 import (
 	"context"
+	"github.com/armory/dinghy/pkg/history"
+	"github.com/armory/dinghy/pkg/managed"
+	"github.com/armory/dinghy/pkg/ownership"
 	"github.com/go-redis/redis"
 	log "github.com/sirupsen/logrus"
 	"os"
@@ -25,7 +28,7 @@ import (
 
 // RedisCacheReadOnly maintains a dependency graph inside Redis
 type RedisCacheReadOnly struct {
-	Client *redis.Client
+	Client redis.UniversalClient
 	Logger *log.Entry
 	ctx    context.Context
 	stop   chan os.Signal
@@ -36,11 +39,32 @@ func (c *RedisCacheReadOnly) SetDeps(parent string, deps []string) {
 
 }
 
+// DeleteNode removes a node and all the edges to and from it
+func (c *RedisCacheReadOnly) DeleteNode(url string) error {
+	return nil
+}
+
 // GetRoots grabs roots
 func (c *RedisCacheReadOnly) GetRoots(url string) []string {
 	return returnRoots(c.Client, url)
 }
 
+func (c *RedisCacheReadOnly) ListRoots() ([]string, error) {
+	return returnListRoots(c.Client)
+}
+
+func (c *RedisCacheReadOnly) Dependents(url string) ([]string, error) {
+	return returnMembers(c.Client, CompileKey("parents", url))
+}
+
+func (c *RedisCacheReadOnly) Dependencies(url string) ([]string, error) {
+	return returnMembers(c.Client, CompileKey("children", url))
+}
+
+func (c *RedisCacheReadOnly) ListNodes() ([]string, error) {
+	return returnListNodes(c.Client)
+}
+
 // Set RawData
 func (c *RedisCacheReadOnly) SetRawData(url string, rawData string) error {
 	return nil
@@ -50,6 +74,60 @@ func (c *RedisCacheReadOnly) GetRawData(url string) (string, error) {
 	return returnRawData(c.Client, url)
 }
 
+// GetOwner returns the owner of an application, nil when it has none
+func (c *RedisCacheReadOnly) GetOwner(application string) (*ownership.Owner, error) {
+	return returnOwner(c.Client, application)
+}
+
+// ClaimOwner returns the owner on record, or owner without storing it
+func (c *RedisCacheReadOnly) ClaimOwner(application string, owner ownership.Owner) (*ownership.Owner, error) {
+	current, err := returnOwner(c.Client, application)
+	if err != nil || current != nil {
+		return current, err
+	}
+	return &owner, nil
+}
+
+// SetOwner overwrites the owner of an application
+func (c *RedisCacheReadOnly) SetOwner(application string, owner ownership.Owner) error {
+	return nil
+}
+
+// GetManaged returns what a dinghyfile last applied, nil when nothing is recorded
+func (c *RedisCacheReadOnly) GetManaged(url string) (*managed.Dinghyfile, error) {
+	return returnManaged(c.Client, url)
+}
+
+// SetManaged records what a dinghyfile applied
+func (c *RedisCacheReadOnly) SetManaged(url string, d managed.Dinghyfile) error {
+	return nil
+}
+
+// DeleteManaged forgets what a dinghyfile applied
+func (c *RedisCacheReadOnly) DeleteManaged(url string) error {
+	return nil
+}
+
+// SaveRender records the rendered dinghyfile of an apply
+func (c *RedisCacheReadOnly) SaveRender(r history.Render) error {
+	return nil
+}
+
+// ListRenders returns the renders of an application, newest first
+func (c *RedisCacheReadOnly) ListRenders(application string) ([]history.Render, error) {
+	return returnRenders(c.Client, application)
+}
+
+// GetRender returns the render with the id, nil when there is none
+func (c *RedisCacheReadOnly) GetRender(application, id string) (*history.Render, error) {
+	return returnRender(c.Client, application, id)
+}
+
+// PruneRenders deletes all but the newest keep renders of an application
+func (c *RedisCacheReadOnly) PruneRenders(application string, keep int) error {
+	return nil
+}
+
 // Clear clears everything
 func (c *RedisCacheReadOnly) Clear() {
 }
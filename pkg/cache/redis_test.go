@@ -21,9 +21,14 @@ import (
 	"github.com/sirupsen/logrus"
 	"os"
 	"testing"
+	"time"
 
 	"fmt"
 
+	"github.com/armory/dinghy/pkg/depgraph/depgraphtest"
+	"github.com/armory/dinghy/pkg/history"
+	"github.com/armory/dinghy/pkg/managed"
+	"github.com/armory/dinghy/pkg/ownership"
 	"github.com/armory/dinghy/pkg/util"
 	"github.com/go-redis/redis"
 	"github.com/stretchr/testify/assert"
@@ -58,3 +63,167 @@ func TestRedisCache(t *testing.T) {
 	c.SetDeps("mod1", []string{"mod3"})
 	assert.EqualValuesf(t, []string{}, c.GetRoots("mod4"), "mod4 should have no roots")
 }
+
+func TestRedisCacheConformance(t *testing.T) {
+	if _, err := connectToRedis().Client.Ping().Result(); err != nil {
+		t.Skip("Could not connect to Redis; skipping test")
+	}
+	depgraphtest.Run(t, func(t *testing.T) depgraphtest.DependencyManager {
+		return connectToRedis()
+	})
+}
+
+func TestRedisCacheScanKeys(t *testing.T) {
+	c := connectToRedis()
+
+	if err := c.Ping(context.Background()); err != nil {
+		t.Skip("Could not connect to Redis; skipping test")
+	}
+
+	c.SetDeps("df1", []string{"mod1", "mod2"})
+	found := []string{}
+	err := c.ScanKeys(CompileKey("parents", "*"), func(keys []string) error {
+		found = append(found, keys...)
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []string{CompileKey("parents", "mod1"), CompileKey("parents", "mod2")}, found)
+
+	c.Clear()
+	found = []string{}
+	c.ScanKeys(CompileKey("*"), func(keys []string) error {
+		found = append(found, keys...)
+		return nil
+	})
+	assert.NotContains(t, found, CompileKey("children", "df1"))
+}
+
+func TestRedisCacheOwnership(t *testing.T) {
+	c := connectToRedis()
+
+	_, err := c.Client.Ping().Result()
+	if err != nil {
+		t.Skip("Could not connect to Redis; skipping test")
+	}
+	c.Client.Del(CompileKey("ownership", "biff"))
+	defer c.Client.Del(CompileKey("ownership", "biff"))
+
+	owner, err := c.GetOwner("biff")
+	assert.Nil(t, err)
+	assert.Nil(t, owner)
+
+	first := ownership.Owner{Org: "armory", Repo: "team-a", Path: "dinghyfile"}
+	second := ownership.Owner{Org: "armory", Repo: "team-b", Path: "dinghyfile"}
+	owner, err = c.ClaimOwner("biff", first)
+	assert.Nil(t, err)
+	assert.Equal(t, first, *owner)
+	owner, err = c.ClaimOwner("biff", second)
+	assert.Nil(t, err)
+	assert.Equal(t, first, *owner)
+
+	assert.Nil(t, c.SetOwner("biff", second))
+	owner, err = c.GetOwner("biff")
+	assert.Nil(t, err)
+	assert.Equal(t, second, *owner)
+}
+
+func TestRedisCacheListRoots(t *testing.T) {
+	c := connectToRedis()
+
+	_, err := c.Client.Ping().Result()
+	if err != nil {
+		t.Skip("Could not connect to Redis; skipping test")
+	}
+
+	c.SetDeps("df1", []string{"mod1"})
+	c.SetDeps("mod1", []string{"mod2"})
+	assert.Nil(t, c.SetManaged("df2", managed.Dinghyfile{Application: "app2"}))
+	defer c.DeleteManaged("df2")
+
+	roots, err := c.ListRoots()
+	assert.Nil(t, err)
+	assert.Contains(t, roots, "df1")
+	assert.Contains(t, roots, "df2")
+	assert.NotContains(t, roots, "mod1")
+	assert.NotContains(t, roots, "mod2")
+}
+
+func TestRedisCacheRenders(t *testing.T) {
+	c := connectToRedis()
+
+	_, err := c.Client.Ping().Result()
+	if err != nil {
+		t.Skip("Could not connect to Redis; skipping test")
+	}
+	c.Client.Del(CompileKey("renders", "biff"), CompileKey("render", "biff", "1-abc"), CompileKey("render", "biff", "2-abc"))
+	defer c.Client.Del(CompileKey("renders", "biff"), CompileKey("render", "biff", "1-abc"), CompileKey("render", "biff", "2-abc"))
+
+	assert.Nil(t, c.SaveRender(history.Render{ID: "1-abc", Application: "biff", Commit: "abc", Date: 1}))
+	// the same commit applied again doesn't overwrite the first render
+	assert.Nil(t, c.SaveRender(history.Render{ID: "2-abc", Application: "biff", Commit: "abc", Date: 2}))
+	renders, err := c.ListRenders("biff")
+	assert.Nil(t, err)
+	if assert.Len(t, renders, 2) {
+		assert.Equal(t, "2-abc", renders[0].ID)
+		assert.Equal(t, "1-abc", renders[1].ID)
+	}
+	r, err := c.GetRender("biff", "1-abc")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), r.Date)
+
+	assert.Nil(t, c.PruneRenders("biff", 1))
+	renders, err = c.ListRenders("biff")
+	assert.Nil(t, err)
+	if assert.Len(t, renders, 1) {
+		assert.Equal(t, "2-abc", renders[0].ID)
+	}
+	r, err = c.GetRender("biff", "1-abc")
+	assert.Nil(t, err)
+	assert.Nil(t, r)
+}
+
+func TestRedisCacheGraphReader(t *testing.T) {
+	c := connectToRedis()
+
+	_, err := c.Client.Ping().Result()
+	if err != nil {
+		t.Skip("Could not connect to Redis; skipping test")
+	}
+
+	c.SetDeps("df1", []string{"mod2", "mod1"})
+	c.SetDeps("df2", []string{"mod2"})
+	defer c.DeleteNode("df1")
+	defer c.DeleteNode("df2")
+	// a dinghyfile without modules only has raw data
+	c.SetRawData("df3", "{}")
+	defer c.Client.Del(CompileKey("rawdata", "df3"))
+
+	dependents, err := c.Dependents("mod2")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"df1", "df2"}, dependents)
+
+	dependencies, err := c.Dependencies("df1")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"mod1", "mod2"}, dependencies)
+
+	nodes, err := c.ListNodes()
+	assert.Nil(t, err)
+	assert.Subset(t, nodes, []string{"df1", "df2", "df3", "mod1", "mod2"})
+}
+
+func TestRedisCacheTryLock(t *testing.T) {
+	c := connectToRedis()
+
+	_, err := c.Client.Ping().Result()
+	if err != nil {
+		t.Skip("Could not connect to Redis; skipping test")
+	}
+	defer c.Client.Del(CompileKey("lock", "test"))
+
+	locked, err := c.TryLock("test", time.Minute)
+	assert.Nil(t, err)
+	assert.True(t, locked)
+	locked, err = c.TryLock("test", time.Minute)
+	assert.Nil(t, err)
+	assert.False(t, locked)
+}
@@ -0,0 +1,113 @@
+/*
+* Copyright 2020 Armory, Inc.
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*    http://www.apache.org/licenses/LICENSE-2.0
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package database
+
+import (
+	"context"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	DialectMySQL    = "mysql"
+	DialectPostgres = "postgres"
+	DialectSQLite   = "sqlite"
+)
+
+// NewSQLClient initializes a Client for the dialect of sqlOptions, MySQL when it is empty
+func NewSQLClient(sqlOptions *SQLConfig, logger *log.Logger, ctx context.Context, stop chan os.Signal) (*SQLClient, error) {
+	switch strings.ToLower(sqlOptions.Dialect) {
+	case "", DialectMySQL:
+		return NewMySQLClient(sqlOptions, logger, ctx, stop)
+	case DialectPostgres, "postgresql":
+		return NewPostgresClient(sqlOptions, logger, ctx, stop)
+	case DialectSQLite, "sqlite3":
+		return NewSQLiteClient(sqlOptions, logger, ctx, stop)
+	default:
+		return nil, fmt.Errorf("unsupported sql dialect %q, use %s, %s or %s", sqlOptions.Dialect, DialectMySQL, DialectPostgres, DialectSQLite)
+	}
+}
+
+func openSQLClient(dialector gorm.Dialector, ctx context.Context, stop chan os.Signal) (*SQLClient, error) {
+	db, err := gorm.Open(dialector, &gorm.Config{})
+
+	if err != nil {
+		return nil, err
+	}
+
+	//TODO: Add logger so queries can be seen, create a parameter later for this
+	sqlclient := &SQLClient{
+		Client: db,
+		Logger: nil,
+		ctx:    ctx,
+		stop:   stop,
+	}
+
+	go sqlclient.monitorWorker()
+	return sqlclient, nil
+}
+
+// Ping checks the connection to the database
+func (c *SQLClient) Ping(ctx context.Context) error {
+	sqlDB, err := c.Client.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+// monitorWorker logs the failing pings of the database, the readiness check
+// takes dinghy out of the rotation while the database can't be reached
+func (c *SQLClient) monitorWorker() {
+	logger := log.WithFields(log.Fields{"persistence": "sql"})
+	timer := time.NewTicker(10 * time.Second)
+	defer timer.Stop()
+	count := 0
+	for {
+		select {
+		case <-timer.C:
+			sqlDB, err := c.Client.DB()
+			if err == nil {
+				err = sqlDB.Ping()
+			}
+			if err != nil {
+				count++
+				logger.Errorf("SQL monitor failed %d times in a row: %s", count, err.Error())
+				continue
+			}
+			if count > 0 {
+				logger.Infof("Communication with the %s database restored", c.Client.Dialector.Name())
+			}
+			count = 0
+		case <-c.ctx.Done():
+			return
+		}
+	}
+}
+
+type SQLConfig struct {
+	// Dialect is mysql, postgres or sqlite
+	Dialect  string
+	DbUrl    string
+	User     string
+	Password string
+	// DbName is the path of the database file for sqlite
+	DbName string
+	// SSLMode is the postgres sslmode
+	SSLMode string
+}
@@ -0,0 +1,46 @@
+/*
+* Copyright 2026 Armory, Inc.
+
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+
+*    http://www.apache.org/licenses/LICENSE-2.0
+
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package database
+
+import (
+	"context"
+	"net/url"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+	"gorm.io/driver/postgres"
+)
+
+// NewPostgresClient initializes a PostgreSQL Client
+func NewPostgresClient(sqlOptions *SQLConfig, logger *log.Logger, ctx context.Context, stop chan os.Signal) (*SQLClient, error) {
+	return openSQLClient(postgres.Open(PostgresDSN(sqlOptions)), ctx, stop)
+}
+
+// PostgresDSN builds the connection url for sqlOptions, the ssl mode is left
+// to the driver default unless SSLMode is set
+func PostgresDSN(sqlOptions *SQLConfig) string {
+	dsn := url.URL{
+		Scheme: "postgres",
+		User:   url.UserPassword(sqlOptions.User, sqlOptions.Password),
+		Host:   sqlOptions.DbUrl,
+		Path:   "/" + sqlOptions.DbName,
+	}
+	if sqlOptions.SSLMode != "" {
+		dsn.RawQuery = url.Values{"sslmode": {sqlOptions.SSLMode}}.Encode()
+	}
+	return dsn.String()
+}
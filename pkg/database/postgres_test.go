@@ -0,0 +1,178 @@
+/*
+* Copyright 2026 Armory, Inc.
+
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+
+*    http://www.apache.org/licenses/LICENSE-2.0
+
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package database_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"testing"
+
+	"github.com/armory/dinghy/pkg/database"
+	"github.com/armory/dinghy/pkg/depgraph/depgraphtest"
+	"github.com/armory/dinghy/pkg/execution"
+	"github.com/armory/dinghy/pkg/history"
+	"github.com/armory/dinghy/pkg/logevents"
+	"github.com/armory/dinghy/pkg/managed"
+	"github.com/armory/dinghy/pkg/ownership"
+	embeddedpostgres "github.com/fergusstrange/embedded-postgres"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+// postgresClient starts an embedded PostgreSQL server with the dinghy schema,
+// the test is skipped when it can't be started (no network to download the
+// binaries, running as root...)
+func postgresClient(t *testing.T) *database.SQLClient {
+	if testing.Short() {
+		t.Skip("skipping PostgreSQL integration test in short mode")
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("no free port for PostgreSQL: %v", err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+
+	dir := t.TempDir()
+	server := embeddedpostgres.NewDatabase(embeddedpostgres.DefaultConfig().
+		Port(uint32(port)).
+		Database("dinghy").
+		Username("dinghy").
+		Password("dinghy").
+		RuntimePath(dir).
+		Logger(nil))
+	if err := server.Start(); err != nil {
+		t.Skipf("PostgreSQL could not be started: %v", err)
+	}
+	t.Cleanup(func() { server.Stop() })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	client, err := database.NewSQLClient(&database.SQLConfig{
+		Dialect:  database.DialectPostgres,
+		DbUrl:    fmt.Sprintf("127.0.0.1:%d", port),
+		User:     "dinghy",
+		Password: "dinghy",
+		DbName:   "dinghy",
+		SSLMode:  "disable",
+	}, logrus.New(), ctx, make(chan os.Signal, 1))
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	err = client.Client.AutoMigrate(append([]interface{}{&logevents.LogEventSQL{}}, database.Models...)...)
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	return client
+}
+
+func TestPostgresDependencies(t *testing.T) {
+	client := postgresClient(t)
+	readOnly := &database.SQLReadOnly{Client: client}
+
+	client.SetDeps("app1/dinghyfile", []string{"stage.module", "wait.module"})
+	client.SetDeps("app2/dinghyfile", []string{"stage.module"})
+	client.SetDeps("stage.module", []string{"wait.module"})
+	// SetDeps is idempotent
+	client.SetDeps("app2/dinghyfile", []string{"stage.module"})
+	readOnly.SetDeps("readonly/dinghyfile", []string{"stage.module"})
+
+	assert.ElementsMatch(t, []string{"app1/dinghyfile", "app2/dinghyfile"}, client.GetRoots("stage.module"))
+	assert.ElementsMatch(t, client.GetRoots("stage.module"), readOnly.GetRoots("stage.module"))
+
+	roots, err := readOnly.ListRoots()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"app1/dinghyfile", "app2/dinghyfile"}, roots)
+
+	dependents, err := readOnly.Dependents("wait.module")
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []string{"app1/dinghyfile", "stage.module"}, dependents)
+
+	dependencies, err := client.Dependencies("app1/dinghyfile")
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []string{"stage.module", "wait.module"}, dependencies)
+
+	assert.Nil(t, client.SetRawData("app1/dinghyfile", `{"stages":[]}`))
+	rawData, err := readOnly.GetRawData("app1/dinghyfile")
+	assert.Nil(t, err)
+	assert.Equal(t, `{"stages":[]}`, rawData)
+
+	assert.Nil(t, client.DeleteNode("app2/dinghyfile"))
+	nodes, err := readOnly.ListNodes()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"app1/dinghyfile", "stage.module", "wait.module"}, nodes)
+}
+
+func TestPostgresConformance(t *testing.T) {
+	client := postgresClient(t)
+	depgraphtest.Run(t, func(t *testing.T) depgraphtest.DependencyManager {
+		assert.Nil(t, client.Client.Exec("DELETE FROM fileurl_childs").Error)
+		assert.Nil(t, client.Client.Exec("DELETE FROM fileurls").Error)
+		return client
+	})
+}
+
+func TestPostgresStores(t *testing.T) {
+	client := postgresClient(t)
+	readOnly := &database.SQLReadOnly{Client: client}
+
+	owner, err := client.ClaimOwner("app", ownership.Owner{Org: "org", Repo: "repo", Path: "dinghyfile"})
+	assert.Nil(t, err)
+	owner, err = client.ClaimOwner("app", ownership.Owner{Org: "other", Repo: "repo", Path: "dinghyfile"})
+	assert.Nil(t, err)
+	assert.Equal(t, &ownership.Owner{Org: "org", Repo: "repo", Path: "dinghyfile"}, owner)
+	assert.Nil(t, client.SetOwner("app", ownership.Owner{Org: "other", Repo: "repo", Path: "dinghyfile"}))
+	owner, err = readOnly.GetOwner("app")
+	assert.Nil(t, err)
+	assert.Equal(t, &ownership.Owner{Org: "other", Repo: "repo", Path: "dinghyfile"}, owner)
+
+	d := managed.Dinghyfile{Org: "org", Repo: "repo", Path: "dinghyfile", Application: "app", Pipelines: []managed.Pipeline{}}
+	assert.Nil(t, client.SetManaged("org/repo/dinghyfile", d))
+	assert.Nil(t, client.SetManaged("org/repo/dinghyfile", d))
+	found, err := readOnly.GetManaged("org/repo/dinghyfile")
+	assert.Nil(t, err)
+	assert.Equal(t, &d, found)
+
+	assert.Nil(t, client.SaveRender(history.Render{Application: "app", Commit: "a", Date: 1, Dinghyfile: "{}"}))
+	assert.Nil(t, client.SaveRender(history.Render{Application: "app", Commit: "b", Date: 2, Dinghyfile: "{}"}))
+	renders, err := readOnly.ListRenders("app")
+	assert.Nil(t, err)
+	if assert.Len(t, renders, 2) {
+		assert.Equal(t, "b", renders[0].Commit)
+	}
+}
+
+func TestPostgresLogEventsAndExecutions(t *testing.T) {
+	client := postgresClient(t)
+
+	events := logevents.LogEventSQLClient{SQLClient: client, MinutesTTL: 60}
+	assert.Nil(t, events.SaveLogEvent(logevents.LogEvent{Org: "org", Repo: "repo", Files: []string{"dinghyfile"}, Commits: []string{"a"}, Status: "success"}))
+	found, err := events.GetLogEvents()
+	assert.Nil(t, err)
+	if assert.Len(t, found, 1) {
+		assert.Equal(t, "org", found[0].Org)
+	}
+
+	migration := &execution.RedisToSQLMigration{SQLClient: client}
+	assert.Nil(t, migration.CreateExecution(client, migration.ExecutionName()))
+	assert.Nil(t, migration.UpdateExecution(client, migration.ExecutionName(), "done", true))
+	row := database.ExecutionSQL{}
+	assert.Nil(t, client.Client.Where(&database.ExecutionSQL{Execution: migration.ExecutionName()}).Find(&row).Error)
+	assert.Equal(t, "true", row.Success)
+}
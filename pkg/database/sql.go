@@ -15,8 +15,13 @@ package database
 
 import (
 	"context"
+	"encoding/json"
+	"github.com/armory/dinghy/pkg/history"
+	"github.com/armory/dinghy/pkg/managed"
+	"github.com/armory/dinghy/pkg/ownership"
 	log "github.com/sirupsen/logrus"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"os"
 )
 
@@ -29,13 +34,13 @@ type SQLClient struct {
 
 type Fileurl struct {
 	Id      int    `gorm:"primaryKey;column:id"`
-	Url     string `gorm:"column:url"`
+	Url     string `gorm:"column:url;index:idx_fileurls_url"`
 	Rawdata string `gorm:"column:rawdata"`
 }
 
 type FileurlChilds struct {
-	FileurlID      int `gorm:"column:fileurl_id"`
-	ChildfileurlId int `gorm:"column:childfileurl_id"`
+	FileurlID      int `gorm:"column:fileurl_id;index:idx_fileurl_childs_parent"`
+	ChildfileurlId int `gorm:"column:childfileurl_id;index:idx_fileurl_childs_child"`
 }
 
 type ExecutionSQL struct {
@@ -49,44 +54,163 @@ func (ExecutionSQL) TableName() string {
 	return "executions"
 }
 
-// SetDeps sets dependencies for a parent
+type OwnershipSQL struct {
+	Application string `gorm:"primaryKey;column:application"`
+	Org         string `gorm:"column:org"`
+	Repo        string `gorm:"column:repo"`
+	Path        string `gorm:"column:path"`
+}
+
+func (OwnershipSQL) TableName() string {
+	return "ownership"
+}
+
+type ManagedSQL struct {
+	Id   int    `gorm:"primaryKey;column:id"`
+	Url  string `gorm:"column:url"`
+	Data string `gorm:"column:data"`
+}
+
+func (ManagedSQL) TableName() string {
+	return "managed_dinghyfiles"
+}
+
+type RenderSQL struct {
+	Id          int    `gorm:"primaryKey;column:id"`
+	RenderID    string `gorm:"column:renderid"`
+	Application string `gorm:"column:application"`
+	Commit      string `gorm:"column:commitid"`
+	Url         string `gorm:"column:url"`
+	Org         string `gorm:"column:org"`
+	Repo        string `gorm:"column:repo"`
+	Path        string `gorm:"column:path"`
+	Branch      string `gorm:"column:branch"`
+	Pusher      string `gorm:"column:pusher"`
+	Date        int64  `gorm:"column:renderdate"`
+	Dinghyfile  string `gorm:"column:dinghyfile"`
+	Pipelines   string `gorm:"column:pipelines"`
+	RollbackOf  string `gorm:"column:rollbackof"`
+}
+
+func (RenderSQL) TableName() string {
+	return "renders"
+}
+
+// sqlBatchSize bounds the rows of an insert and the values of an IN clause
+const sqlBatchSize = 500
+
+// SetDeps replaces the dependencies of a parent in a single transaction
 func (c *SQLClient) SetDeps(parent string, deps []string) {
+	err := c.Client.Transaction(func(tx *gorm.DB) error {
+		return setDeps(tx, parent, deps)
+	})
+	if err != nil {
+		log.WithFields(log.Fields{"func": "SetDeps", "parent": parent}).Error(err)
+	}
+}
 
-	currParent := Fileurl{}
-	c.Client.Where(&Fileurl{Url: parent}).Find(&currParent)
-	if currParent.Url == "" {
-		currParent.Url = parent
-		c.Client.Create(&currParent)
-	}
-	children := []FileurlChilds{}
-	c.Client.Where(&FileurlChilds{FileurlID: currParent.Id}).Find(&children)
-	for _, currDep := range deps {
-		foundDep := Fileurl{}
-		c.Client.Where(&Fileurl{Url: currDep}).Find(&foundDep)
-		if foundDep.Url == "" {
-			foundDep.Url = currDep
-			c.Client.Create(&foundDep)
+func setDeps(tx *gorm.DB, parent string, deps []string) error {
+	ids, err := nodeIDs(tx, append([]string{parent}, deps...))
+	if err != nil {
+		return err
+	}
+	parentID := ids[parent]
+
+	current := []int{}
+	if err := tx.Model(&FileurlChilds{}).Where("fileurl_id = ?", parentID).Pluck("childfileurl_id", &current).Error; err != nil {
+		return err
+	}
+	existing := make(map[int]bool, len(current))
+	for _, id := range current {
+		existing[id] = true
+	}
+	wanted := make(map[int]bool, len(deps))
+	toAdd := []FileurlChilds{}
+	for _, dep := range deps {
+		id := ids[dep]
+		if !wanted[id] && !existing[id] {
+			toAdd = append(toAdd, FileurlChilds{FileurlID: parentID, ChildfileurlId: id})
 		}
-		if !containsChildId(children, foundDep.Id) {
-			c.Client.Create(&FileurlChilds{
-				FileurlID:      currParent.Id,
-				ChildfileurlId: foundDep.Id,
-			})
+		wanted[id] = true
+	}
+	toDelete := []int{}
+	for id := range existing {
+		if !wanted[id] {
+			toDelete = append(toDelete, id)
 		}
 	}
 
+	for start := 0; start < len(toDelete); start += sqlBatchSize {
+		batch := toDelete[start:min(start+sqlBatchSize, len(toDelete))]
+		if err := tx.Where("fileurl_id = ? AND childfileurl_id IN ?", parentID, batch).Delete(&FileurlChilds{}).Error; err != nil {
+			return err
+		}
+	}
+	if len(toAdd) > 0 {
+		return tx.CreateInBatches(toAdd, sqlBatchSize).Error
+	}
+	return nil
 }
 
-func containsChildId(slice []FileurlChilds, searchChildId int) bool {
-	if slice == nil {
-		return false
+// nodeIDs returns the id of every url, creating the nodes that don't exist
+func nodeIDs(tx *gorm.DB, urls []string) (map[string]int, error) {
+	ids := make(map[string]int, len(urls))
+	unique := make([]string, 0, len(urls))
+	for _, url := range urls {
+		if _, ok := ids[url]; !ok {
+			ids[url] = 0
+			unique = append(unique, url)
+		}
 	}
-	for _, val := range slice {
-		if val.ChildfileurlId == searchChildId {
-			return true
+
+	for start := 0; start < len(unique); start += sqlBatchSize {
+		found := []Fileurl{}
+		batch := unique[start:min(start+sqlBatchSize, len(unique))]
+		if err := tx.Select("id", "url").Where("url IN ?", batch).Order("id").Find(&found).Error; err != nil {
+			return nil, err
+		}
+		for _, node := range found {
+			if ids[node.Url] == 0 {
+				ids[node.Url] = node.Id
+			}
+		}
+	}
+
+	missing := []Fileurl{}
+	for _, url := range unique {
+		if ids[url] == 0 {
+			missing = append(missing, Fileurl{Url: url})
 		}
 	}
-	return false
+	if len(missing) == 0 {
+		return ids, nil
+	}
+	if err := tx.CreateInBatches(&missing, sqlBatchSize).Error; err != nil {
+		return nil, err
+	}
+	for _, node := range missing {
+		ids[node.Url] = node.Id
+	}
+	return ids, nil
+}
+
+// DeleteNode removes a node and all the edges to and from it
+func (c *SQLClient) DeleteNode(url string) error {
+	nodes := []Fileurl{}
+	if err := c.Client.Where(&Fileurl{Url: url}).Find(&nodes).Error; err != nil {
+		return err
+	}
+	return c.Client.Transaction(func(tx *gorm.DB) error {
+		for _, node := range nodes {
+			if err := tx.Where("fileurl_id = ? OR childfileurl_id = ?", node.Id, node.Id).Delete(&FileurlChilds{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Delete(&Fileurl{}, node.Id).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
 // GetRoots grabs roots
@@ -94,36 +218,82 @@ func (c *SQLClient) GetRoots(url string) []string {
 	return returnRoots(c, url)
 }
 
+// rootsQuery walks up from a url to the urls nothing depends on, other than
+// the url itself. UNION drops the nodes already reached, so cycles and
+// diamonds end the walk
+const rootsQuery = `WITH RECURSIVE ancestors (id) AS (
+	SELECT id FROM fileurls WHERE url = ?
+	UNION
+	SELECT fileurl_childs.fileurl_id FROM fileurl_childs
+	JOIN ancestors ON fileurl_childs.childfileurl_id = ancestors.id
+)
+SELECT DISTINCT fileurls.url FROM fileurls
+JOIN ancestors ON fileurls.id = ancestors.id
+WHERE fileurls.url <> ?
+AND NOT EXISTS (SELECT 1 FROM fileurl_childs WHERE fileurl_childs.childfileurl_id = fileurls.id)
+ORDER BY fileurls.url`
+
 func returnRoots(c *SQLClient, url string) []string {
-	results := []string{}
-	currUrl := Fileurl{}
-	c.Client.Where(&Fileurl{Url: url}).Find(&currUrl)
-	if currUrl.Url != "" {
-		parents := []FileurlChilds{{FileurlID: currUrl.Id}}
-		tempParent := []FileurlChilds{}
-		for {
-			for _, currParent := range parents {
-				records := []FileurlChilds{}
-				c.Client.Where(&FileurlChilds{ChildfileurlId: currParent.FileurlID}).Find(&records)
-				if len(records) == 0 {
-					resultUrl := Fileurl{}
-					c.Client.Where(&Fileurl{Id: currParent.FileurlID}).Find(&resultUrl)
-					results = append(results, resultUrl.Url)
-				} else {
-					for _, currRecord := range records {
-						tempParent = append(tempParent, currRecord)
-					}
-				}
-			}
-			if len(tempParent) == 0 {
-				break
-			} else {
-				parents = tempParent
-				tempParent = []FileurlChilds{}
-			}
-		}
+	roots := make([]string, 0)
+	if err := c.Client.Raw(rootsQuery, url, url).Scan(&roots).Error; err != nil {
+		log.WithFields(log.Fields{"func": "GetRoots", "url": url}).Error(err)
 	}
-	return results
+	return roots
+}
+
+// ListRoots returns the urls no other url depends on, sorted
+func (c *SQLClient) ListRoots() ([]string, error) {
+	return returnListRoots(c)
+}
+
+func returnListRoots(c *SQLClient) ([]string, error) {
+	roots := make([]string, 0)
+	err := c.Client.Model(&Fileurl{}).
+		Where("id NOT IN (?)", c.Client.Model(&FileurlChilds{}).Select("childfileurl_id")).
+		Order("url").
+		Pluck("url", &roots).Error
+	return roots, err
+}
+
+// Dependents returns the urls using url directly, sorted
+func (c *SQLClient) Dependents(url string) ([]string, error) {
+	return returnDependents(c, url)
+}
+
+func returnDependents(c *SQLClient, url string) ([]string, error) {
+	urls := make([]string, 0)
+	err := c.Client.Model(&Fileurl{}).
+		Where("id IN (?)", c.Client.Model(&FileurlChilds{}).Select("fileurl_id").
+			Where("childfileurl_id IN (?)", c.Client.Model(&Fileurl{}).Select("id").Where("url = ?", url))).
+		Order("url").
+		Pluck("url", &urls).Error
+	return urls, err
+}
+
+// Dependencies returns the urls url uses directly, sorted
+func (c *SQLClient) Dependencies(url string) ([]string, error) {
+	return returnDependencies(c, url)
+}
+
+func returnDependencies(c *SQLClient, url string) ([]string, error) {
+	urls := make([]string, 0)
+	err := c.Client.Model(&Fileurl{}).
+		Where("id IN (?)", c.Client.Model(&FileurlChilds{}).Select("childfileurl_id").
+			Where("fileurl_id IN (?)", c.Client.Model(&Fileurl{}).Select("id").Where("url = ?", url))).
+		Order("url").
+		Pluck("url", &urls).Error
+	return urls, err
+}
+
+// ListNodes returns every url, sorted
+func (c *SQLClient) ListNodes() ([]string, error) {
+	return returnListNodes(c)
+}
+
+func returnListNodes(c *SQLClient) ([]string, error) {
+	urls := make([]string, 0)
+	err := c.Client.Model(&Fileurl{}).Distinct("url").Order("url").Pluck("url", &urls).Error
+	return urls, err
 }
 
 // Set RawData
@@ -141,3 +311,197 @@ func returnRawData(c *SQLClient, url string) (string, error) {
 	result := c.Client.Where(&Fileurl{Url: url}).Find(&find)
 	return find.Rawdata, result.Error
 }
+
+// GetOwner returns the owner of an application, nil when it has none
+func (c *SQLClient) GetOwner(application string) (*ownership.Owner, error) {
+	return returnOwner(c, application)
+}
+
+// ClaimOwner records owner for an application unless it already has one
+func (c *SQLClient) ClaimOwner(application string, owner ownership.Owner) (*ownership.Owner, error) {
+	row := OwnershipSQL{Application: application, Org: owner.Org, Repo: owner.Repo, Path: owner.Path}
+	if err := c.Client.Clauses(clause.OnConflict{DoNothing: true}).Create(&row).Error; err != nil {
+		return nil, err
+	}
+	return returnOwner(c, application)
+}
+
+// SetOwner overwrites the owner of an application
+func (c *SQLClient) SetOwner(application string, owner ownership.Owner) error {
+	row := OwnershipSQL{Application: application, Org: owner.Org, Repo: owner.Repo, Path: owner.Path}
+	return c.Client.Clauses(clause.OnConflict{UpdateAll: true}).Create(&row).Error
+}
+
+// ListOwners returns the owner of every application
+func (c *SQLClient) ListOwners() (map[string]ownership.Owner, error) {
+	rows := []OwnershipSQL{}
+	if err := c.Client.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	owners := make(map[string]ownership.Owner, len(rows))
+	for _, row := range rows {
+		owners[row.Application] = ownership.Owner{Org: row.Org, Repo: row.Repo, Path: row.Path}
+	}
+	return owners, nil
+}
+
+func returnOwner(c *SQLClient, application string) (*ownership.Owner, error) {
+	rows := []OwnershipSQL{}
+	if err := c.Client.Where(&OwnershipSQL{Application: application}).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	return &ownership.Owner{Org: rows[0].Org, Repo: rows[0].Repo, Path: rows[0].Path}, nil
+}
+
+// GetManaged returns what a dinghyfile last applied, nil when nothing is recorded
+func (c *SQLClient) GetManaged(url string) (*managed.Dinghyfile, error) {
+	return returnManaged(c, url)
+}
+
+// SetManaged records what a dinghyfile applied
+func (c *SQLClient) SetManaged(url string, d managed.Dinghyfile) error {
+	data, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+	row := ManagedSQL{}
+	if err := c.Client.Where(&ManagedSQL{Url: url}).Find(&row).Error; err != nil {
+		return err
+	}
+	if row.Url == "" {
+		return c.Client.Create(&ManagedSQL{Url: url, Data: string(data)}).Error
+	}
+	return c.Client.Model(&row).Update("data", string(data)).Error
+}
+
+// DeleteManaged forgets what a dinghyfile applied
+func (c *SQLClient) DeleteManaged(url string) error {
+	return c.Client.Where(&ManagedSQL{Url: url}).Delete(&ManagedSQL{}).Error
+}
+
+func returnManaged(c *SQLClient, url string) (*managed.Dinghyfile, error) {
+	rows := []ManagedSQL{}
+	if err := c.Client.Where(&ManagedSQL{Url: url}).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	var d managed.Dinghyfile
+	if err := json.Unmarshal([]byte(rows[0].Data), &d); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// SaveRender records the rendered dinghyfile of an apply
+func (c *SQLClient) SaveRender(r history.Render) error {
+	row := RenderSQL{
+		RenderID:    r.ID,
+		Application: r.Application,
+		Commit:      r.Commit,
+		Org:         r.Org,
+		Repo:        r.Repo,
+		Path:        r.Path,
+		Url:         r.URL,
+		Branch:      r.Branch,
+		Pusher:      r.Pusher,
+		Date:        r.Date,
+		Dinghyfile:  r.Dinghyfile,
+		Pipelines:   string(r.Pipelines),
+		RollbackOf:  r.RollbackOf,
+	}
+	return c.Client.Create(&row).Error
+}
+
+// ListRenders returns the renders of an application, newest first
+func (c *SQLClient) ListRenders(application string) ([]history.Render, error) {
+	return returnRenders(c, application)
+}
+
+// GetRender returns the render with the id, nil when there is none
+func (c *SQLClient) GetRender(application, id string) (*history.Render, error) {
+	return returnRender(c, application, id)
+}
+
+// ListApplications returns the applications with renders
+func (c *SQLClient) ListApplications() ([]string, error) {
+	applications := []string{}
+	err := c.Client.Model(&RenderSQL{}).Distinct("application").Order("application").Pluck("application", &applications).Error
+	return applications, err
+}
+
+// PruneRenders deletes all but the newest keep renders of an application
+func (c *SQLClient) PruneRenders(application string, keep int) error {
+	rows := []RenderSQL{}
+	if err := c.Client.Select("id").Where(&RenderSQL{Application: application}).Order("renderdate desc").Find(&rows).Error; err != nil {
+		return err
+	}
+	if len(rows) <= keep {
+		return nil
+	}
+	ids := make([]int, 0, len(rows)-keep)
+	for _, row := range rows[keep:] {
+		ids = append(ids, row.Id)
+	}
+	for start := 0; start < len(ids); start += sqlBatchSize {
+		batch := ids[start:min(start+sqlBatchSize, len(ids))]
+		if err := c.Client.Where("id IN ?", batch).Delete(&RenderSQL{}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func returnRenders(c *SQLClient, application string) ([]history.Render, error) {
+	rows := []RenderSQL{}
+	if err := c.Client.Where(&RenderSQL{Application: application}).Order("renderdate desc").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	renders := make([]history.Render, 0, len(rows))
+	for _, row := range rows {
+		renders = append(renders, row.toRender())
+	}
+	return renders, nil
+}
+
+func returnRender(c *SQLClient, application, id string) (*history.Render, error) {
+	rows := []RenderSQL{}
+	if err := c.Client.Where(&RenderSQL{Application: application, RenderID: id}).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	r := rows[0].toRender()
+	return &r, nil
+}
+
+// toRender identifies the renders recorded before they had an id by their
+// commit
+func (row RenderSQL) toRender() history.Render {
+	r := history.Render{
+		ID:          row.RenderID,
+		Application: row.Application,
+		Commit:      row.Commit,
+		URL:         row.Url,
+		Org:         row.Org,
+		Repo:        row.Repo,
+		Path:        row.Path,
+		Branch:      row.Branch,
+		Pusher:      row.Pusher,
+		Date:        row.Date,
+		Dinghyfile:  row.Dinghyfile,
+		RollbackOf:  row.RollbackOf,
+	}
+	if row.Pipelines != "" {
+		r.Pipelines = json.RawMessage(row.Pipelines)
+	}
+	if r.ID == "" {
+		r.ID = row.Commit
+	}
+	return r
+}
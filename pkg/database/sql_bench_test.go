@@ -0,0 +1,163 @@
+/*
+* Copyright 2026 Armory, Inc.
+
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+
+*    http://www.apache.org/licenses/LICENSE-2.0
+
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package database
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+// The synthetic graph: every dinghyfile uses benchStagesPerFile of the stage
+// modules, and every stage module uses two of the base modules. SQLite runs in
+// process, every round trip saved costs more against a database server.
+const (
+	benchDinghyfiles   = 500
+	benchStages        = 50
+	benchBases         = 10
+	benchStagesPerFile = 5
+)
+
+func benchClient(b *testing.B) *SQLClient {
+	ctx, cancel := context.WithCancel(context.Background())
+	b.Cleanup(cancel)
+	c, err := NewSQLiteClient(&SQLConfig{DbName: filepath.Join(b.TempDir(), "bench.db")}, logrus.New(), ctx, make(chan os.Signal, 1))
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() {
+		if sqlDB, err := c.Client.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return c
+}
+
+func benchDeps(file int) []string {
+	deps := make([]string, 0, benchStagesPerFile)
+	for i := 0; i < benchStagesPerFile; i++ {
+		deps = append(deps, fmt.Sprintf("stage%d.module", (file+i*7)%benchStages))
+	}
+	return deps
+}
+
+func benchGraph(b *testing.B, setDeps func(c *SQLClient, parent string, deps []string)) *SQLClient {
+	c := benchClient(b)
+	for i := 0; i < benchStages; i++ {
+		setDeps(c, fmt.Sprintf("stage%d.module", i), []string{
+			fmt.Sprintf("base%d.module", i%benchBases),
+			fmt.Sprintf("base%d.module", (i+1)%benchBases),
+		})
+	}
+	for i := 0; i < benchDinghyfiles; i++ {
+		setDeps(c, fmt.Sprintf("org/app%d/dinghyfile", i), benchDeps(i))
+	}
+	return c
+}
+
+func BenchmarkGetRoots(b *testing.B) {
+	b.Run("level by level", func(b *testing.B) {
+		c := benchGraph(b, (*SQLClient).SetDeps)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			legacyRoots(c, "base0.module")
+		}
+	})
+	b.Run("recursive query", func(b *testing.B) {
+		c := benchGraph(b, (*SQLClient).SetDeps)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			c.GetRoots("base0.module")
+		}
+	})
+}
+
+func BenchmarkSetDeps(b *testing.B) {
+	b.Run("row by row", func(b *testing.B) {
+		c := benchGraph(b, legacySetDeps)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			legacySetDeps(c, fmt.Sprintf("org/app%d/dinghyfile", i%benchDinghyfiles), benchDeps(i))
+		}
+	})
+	b.Run("batched", func(b *testing.B) {
+		c := benchGraph(b, (*SQLClient).SetDeps)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			c.SetDeps(fmt.Sprintf("org/app%d/dinghyfile", i%benchDinghyfiles), benchDeps(i))
+		}
+	})
+}
+
+// legacySetDeps is SetDeps before it was batched, one query per dependency
+// and stale edges never deleted
+func legacySetDeps(c *SQLClient, parent string, deps []string) {
+	currParent := Fileurl{}
+	c.Client.Where(&Fileurl{Url: parent}).Find(&currParent)
+	if currParent.Url == "" {
+		currParent.Url = parent
+		c.Client.Create(&currParent)
+	}
+	children := []FileurlChilds{}
+	c.Client.Where(&FileurlChilds{FileurlID: currParent.Id}).Find(&children)
+	for _, currDep := range deps {
+		foundDep := Fileurl{}
+		c.Client.Where(&Fileurl{Url: currDep}).Find(&foundDep)
+		if foundDep.Url == "" {
+			foundDep.Url = currDep
+			c.Client.Create(&foundDep)
+		}
+		found := false
+		for _, child := range children {
+			found = found || child.ChildfileurlId == foundDep.Id
+		}
+		if !found {
+			c.Client.Create(&FileurlChilds{FileurlID: currParent.Id, ChildfileurlId: foundDep.Id})
+		}
+	}
+}
+
+// legacyRoots is GetRoots before the recursive query, one query per parent
+// and level
+func legacyRoots(c *SQLClient, url string) []string {
+	results := []string{}
+	currUrl := Fileurl{}
+	c.Client.Where(&Fileurl{Url: url}).Find(&currUrl)
+	if currUrl.Url == "" {
+		return results
+	}
+	parents := []FileurlChilds{{FileurlID: currUrl.Id}}
+	for len(parents) > 0 {
+		next := []FileurlChilds{}
+		for _, currParent := range parents {
+			records := []FileurlChilds{}
+			c.Client.Where(&FileurlChilds{ChildfileurlId: currParent.FileurlID}).Find(&records)
+			if len(records) == 0 {
+				resultUrl := Fileurl{}
+				c.Client.Where(&Fileurl{Id: currParent.FileurlID}).Find(&resultUrl)
+				results = append(results, resultUrl.Url)
+			}
+			next = append(next, records...)
+		}
+		parents = next
+	}
+	return results
+}
@@ -18,6 +18,9 @@ package database
 
 import (
 	"context"
+	"github.com/armory/dinghy/pkg/history"
+	"github.com/armory/dinghy/pkg/managed"
+	"github.com/armory/dinghy/pkg/ownership"
 	log "github.com/sirupsen/logrus"
 	"os"
 )
@@ -35,11 +38,32 @@ func (c *SQLReadOnly) SetDeps(parent string, deps []string) {
 
 }
 
+// DeleteNode removes a node and all the edges to and from it
+func (c *SQLReadOnly) DeleteNode(url string) error {
+	return nil
+}
+
 // GetRoots grabs roots
 func (c *SQLReadOnly) GetRoots(url string) []string {
 	return returnRoots(c.Client, url)
 }
 
+func (c *SQLReadOnly) ListRoots() ([]string, error) {
+	return returnListRoots(c.Client)
+}
+
+func (c *SQLReadOnly) Dependents(url string) ([]string, error) {
+	return returnDependents(c.Client, url)
+}
+
+func (c *SQLReadOnly) Dependencies(url string) ([]string, error) {
+	return returnDependencies(c.Client, url)
+}
+
+func (c *SQLReadOnly) ListNodes() ([]string, error) {
+	return returnListNodes(c.Client)
+}
+
 // Set RawData
 func (c *SQLReadOnly) SetRawData(url string, rawData string) error {
 	return nil
@@ -52,3 +76,57 @@ func (c *SQLReadOnly) GetRawData(url string) (string, error) {
 // Clear clears everything
 func (c *SQLReadOnly) Clear() {
 }
+
+// GetOwner returns the owner of an application, nil when it has none
+func (c *SQLReadOnly) GetOwner(application string) (*ownership.Owner, error) {
+	return returnOwner(c.Client, application)
+}
+
+// ClaimOwner returns the owner on record, or owner without storing it
+func (c *SQLReadOnly) ClaimOwner(application string, owner ownership.Owner) (*ownership.Owner, error) {
+	current, err := returnOwner(c.Client, application)
+	if err != nil || current != nil {
+		return current, err
+	}
+	return &owner, nil
+}
+
+// SetOwner overwrites the owner of an application
+func (c *SQLReadOnly) SetOwner(application string, owner ownership.Owner) error {
+	return nil
+}
+
+// GetManaged returns what a dinghyfile last applied, nil when nothing is recorded
+func (c *SQLReadOnly) GetManaged(url string) (*managed.Dinghyfile, error) {
+	return returnManaged(c.Client, url)
+}
+
+// SetManaged records what a dinghyfile applied
+func (c *SQLReadOnly) SetManaged(url string, d managed.Dinghyfile) error {
+	return nil
+}
+
+// DeleteManaged forgets what a dinghyfile applied
+func (c *SQLReadOnly) DeleteManaged(url string) error {
+	return nil
+}
+
+// SaveRender records the rendered dinghyfile of an apply
+func (c *SQLReadOnly) SaveRender(r history.Render) error {
+	return nil
+}
+
+// ListRenders returns the renders of an application, newest first
+func (c *SQLReadOnly) ListRenders(application string) ([]history.Render, error) {
+	return returnRenders(c.Client, application)
+}
+
+// GetRender returns the render with the id, nil when there is none
+func (c *SQLReadOnly) GetRender(application, id string) (*history.Render, error) {
+	return returnRender(c.Client, application, id)
+}
+
+// PruneRenders deletes all but the newest keep renders of an application
+func (c *SQLReadOnly) PruneRenders(application string, keep int) error {
+	return nil
+}
@@ -0,0 +1,48 @@
+/*
+* Copyright 2026 Armory, Inc.
+
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+
+*    http://www.apache.org/licenses/LICENSE-2.0
+
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package database
+
+import (
+	"context"
+	"os"
+
+	"github.com/glebarez/sqlite"
+	log "github.com/sirupsen/logrus"
+)
+
+// Models are the tables of the SQLClient
+var Models = []interface{}{&Fileurl{}, &FileurlChilds{}, &ExecutionSQL{}, &OwnershipSQL{}, &ManagedSQL{}, &RenderSQL{}}
+
+// NewSQLiteClient initializes a SQLite Client on the database file DbName,
+// creating it when it doesn't exist. There is no Liquibase for SQLite, the
+// tables of the Client are created (or upgraded) here.
+func NewSQLiteClient(sqlOptions *SQLConfig, logger *log.Logger, ctx context.Context, stop chan os.Signal) (*SQLClient, error) {
+	client, err := openSQLClient(sqlite.Open(sqlOptions.DbName+"?_pragma=busy_timeout(5000)&_pragma=foreign_keys(1)"), ctx, stop)
+	if err != nil {
+		return nil, err
+	}
+	sqlDB, err := client.Client.DB()
+	if err != nil {
+		return nil, err
+	}
+	// SQLite has a single writer, sharing one connection avoids "database is locked"
+	sqlDB.SetMaxOpenConns(1)
+	if err := client.Client.AutoMigrate(Models...); err != nil {
+		return nil, err
+	}
+	return client, nil
+}
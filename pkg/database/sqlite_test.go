@@ -0,0 +1,217 @@
+/*
+* Copyright 2026 Armory, Inc.
+
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+
+*    http://www.apache.org/licenses/LICENSE-2.0
+
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package database_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/armory/dinghy/pkg/database"
+	"github.com/armory/dinghy/pkg/depgraph/depgraphtest"
+	"github.com/armory/dinghy/pkg/history"
+	"github.com/armory/dinghy/pkg/logevents"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func sqliteClient(t *testing.T, file string) *database.SQLClient {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	client, err := database.NewSQLClient(&database.SQLConfig{
+		Dialect: database.DialectSQLite,
+		DbName:  file,
+	}, logrus.New(), ctx, make(chan os.Signal, 1))
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := client.Client.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return client
+}
+
+func TestSQLite(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "dinghy.db")
+	client := sqliteClient(t, file)
+	readOnly := &database.SQLReadOnly{Client: client}
+
+	client.SetDeps("app1/dinghyfile", []string{"stage.module", "wait.module"})
+	client.SetDeps("app2/dinghyfile", []string{"stage.module"})
+	client.SetDeps("stage.module", []string{"wait.module"})
+	assert.Nil(t, client.SetRawData("app1/dinghyfile", `{"ref":"refs/heads/master"}`))
+	assert.ElementsMatch(t, []string{"app1/dinghyfile", "app2/dinghyfile"}, readOnly.GetRoots("stage.module"))
+
+	dependents, err := client.Dependents("stage.module")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"app1/dinghyfile", "app2/dinghyfile"}, dependents)
+
+	// everything survives a restart
+	sqlDB, _ := client.Client.DB()
+	sqlDB.Close()
+	client = sqliteClient(t, file)
+
+	roots, err := client.ListRoots()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"app1/dinghyfile", "app2/dinghyfile"}, roots)
+	rawData, err := client.GetRawData("app1/dinghyfile")
+	assert.Nil(t, err)
+	assert.Equal(t, `{"ref":"refs/heads/master"}`, rawData)
+
+	assert.Nil(t, client.DeleteNode("app2/dinghyfile"))
+	assert.Equal(t, []string{"app1/dinghyfile"}, client.GetRoots("stage.module"))
+}
+
+func TestSQLiteLogEvents(t *testing.T) {
+	client := sqliteClient(t, filepath.Join(t.TempDir(), "dinghy.db"))
+	assert.Nil(t, client.Client.AutoMigrate(&logevents.LogEventSQL{}))
+
+	events := logevents.LogEventSQLClient{SQLClient: client, MinutesTTL: 60}
+	assert.Nil(t, events.SaveLogEvent(logevents.LogEvent{Org: "org", Repo: "repo", Files: []string{"dinghyfile"}, Commits: []string{"a"}, Status: "success"}))
+	found, err := events.GetLogEvents()
+	assert.Nil(t, err)
+	if assert.Len(t, found, 1) {
+		assert.Equal(t, []string{"dinghyfile"}, found[0].Files)
+	}
+}
+
+func TestSQLiteRestoreLogEvent(t *testing.T) {
+	client := sqliteClient(t, filepath.Join(t.TempDir(), "dinghy.db"))
+	assert.Nil(t, client.Client.AutoMigrate(&logevents.LogEventSQL{}))
+
+	events := logevents.LogEventSQLClient{SQLClient: client, MinutesTTL: 60}
+	date := time.Now().Add(-time.Minute).UnixNano() / int64(time.Millisecond)
+	event := logevents.LogEvent{Org: "org", Repo: "repo", Files: []string{"dinghyfile"}, Commits: []string{"a"}, Date: date}
+	for _, expected := range []bool{true, false} {
+		restored, err := events.RestoreLogEvent(event)
+		assert.Nil(t, err)
+		assert.Equal(t, expected, restored)
+	}
+	event.Date = time.Now().Add(-2*time.Hour).UnixNano() / int64(time.Millisecond)
+	restored, err := events.RestoreLogEvent(event)
+	assert.Nil(t, err)
+	assert.False(t, restored, "past its TTL")
+
+	found, err := events.GetLogEvents()
+	assert.Nil(t, err)
+	if assert.Len(t, found, 1) {
+		assert.Equal(t, date, found[0].Date)
+	}
+}
+
+func TestSQLitePurgeLogEvents(t *testing.T) {
+	client := sqliteClient(t, filepath.Join(t.TempDir(), "dinghy.db"))
+	assert.Nil(t, client.Client.AutoMigrate(&logevents.LogEventSQL{}))
+
+	events := logevents.LogEventSQLClient{SQLClient: client, MinutesTTL: 60}
+	old := logevents.LogEvent{Org: "org", Repo: "repo", Files: []string{"dinghyfile"}, Commits: []string{"a"}, Status: "error", Date: 1}
+	assert.Nil(t, client.Client.Create(&[]logevents.LogEventSQL{old.ToLogEventSQL()}).Error)
+	assert.Nil(t, events.SaveLogEvent(logevents.LogEvent{Org: "org", Repo: "repo", Status: "success"}))
+	visible, _ := events.GetLogEvents()
+	assert.Len(t, visible, 1)
+
+	janitor := &logevents.Janitor{Retention: logevents.Retention{MaxAge: time.Hour}, Client: events, Logger: logrus.New()}
+	purged, err := janitor.Run(time.Now())
+	assert.Nil(t, err)
+	assert.Equal(t, 1, purged)
+	all, err := events.ListLogEvents()
+	assert.Nil(t, err)
+	if assert.Len(t, all, 1) {
+		assert.Equal(t, "success", all[0].Status)
+	}
+}
+
+func TestSQLiteRenderPipelines(t *testing.T) {
+	client := sqliteClient(t, filepath.Join(t.TempDir(), "dinghy.db"))
+
+	// recorded before renders had an id
+	assert.Nil(t, client.SaveRender(history.Render{Application: "app", Commit: "a", Date: 1, Dinghyfile: "{}"}))
+	assert.Nil(t, client.SaveRender(history.Render{ID: "2-b", Application: "app", Commit: "b", Date: 2, Dinghyfile: "{}", Pipelines: json.RawMessage(`[{"name":"deploy","id":"1"}]`)}))
+	// the same commit applied again doesn't overwrite the first render
+	assert.Nil(t, client.SaveRender(history.Render{ID: "3-b", Application: "app", Commit: "b", Date: 3, Dinghyfile: "{}", RollbackOf: "2-b"}))
+	renders, err := client.ListRenders("app")
+	assert.Nil(t, err)
+	if assert.Len(t, renders, 3) {
+		assert.Equal(t, []string{"3-b", "2-b", "a"}, []string{renders[0].ID, renders[1].ID, renders[2].ID})
+		assert.Equal(t, json.RawMessage(`[{"name":"deploy","id":"1"}]`), renders[1].Pipelines)
+		assert.Nil(t, renders[2].Pipelines)
+		assert.Equal(t, "2-b", renders[0].RollbackOf)
+	}
+	r, err := client.GetRender("app", "2-b")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), r.Date)
+	r, err = history.Find(client, "app", "b")
+	assert.Nil(t, err)
+	assert.Equal(t, "3-b", r.ID)
+	assert.Nil(t, client.PruneRenders("app", 2))
+	renders, err = client.ListRenders("app")
+	assert.Nil(t, err)
+	if assert.Len(t, renders, 2) {
+		assert.Equal(t, "2-b", renders[1].ID)
+	}
+	assert.Nil(t, client.PruneRenders("app", 2))
+}
+
+func TestUnsupportedDialect(t *testing.T) {
+	_, err := database.NewSQLClient(&database.SQLConfig{Dialect: "oracle"}, logrus.New(), context.Background(), make(chan os.Signal, 1))
+	assert.EqualError(t, err, `unsupported sql dialect "oracle", use mysql, postgres or sqlite`)
+}
+
+func TestSQLiteSetDepsReplacesEdges(t *testing.T) {
+	client := sqliteClient(t, filepath.Join(t.TempDir(), "dinghy.db"))
+
+	client.SetDeps("app1/dinghyfile", []string{"stage.module", "wait.module", "wait.module"})
+	client.SetDeps("app1/dinghyfile", []string{"stage.module", "notify.module"})
+	dependencies, err := client.Dependencies("app1/dinghyfile")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"notify.module", "stage.module"}, dependencies)
+	assert.Empty(t, client.GetRoots("wait.module"))
+
+	edges := int64(0)
+	assert.Nil(t, client.Client.Model(&database.FileurlChilds{}).Count(&edges).Error)
+	assert.Equal(t, int64(2), edges)
+
+	client.SetDeps("app1/dinghyfile", nil)
+	dependencies, err = client.Dependencies("app1/dinghyfile")
+	assert.Nil(t, err)
+	assert.Empty(t, dependencies)
+}
+
+func TestSQLiteGetRootsWalksDiamondsAndCycles(t *testing.T) {
+	client := sqliteClient(t, filepath.Join(t.TempDir(), "dinghy.db"))
+
+	client.SetDeps("app1/dinghyfile", []string{"stage.module", "wait.module"})
+	client.SetDeps("stage.module", []string{"wait.module"})
+	client.SetDeps("a.module", []string{"b.module"})
+	client.SetDeps("b.module", []string{"a.module", "wait.module"})
+
+	assert.Equal(t, []string{"app1/dinghyfile"}, client.GetRoots("wait.module"))
+	assert.Empty(t, client.GetRoots("app1/dinghyfile"))
+	assert.Empty(t, client.GetRoots("a.module"))
+	assert.Empty(t, client.GetRoots("unknown.module"))
+}
+
+func TestSQLiteConformance(t *testing.T) {
+	depgraphtest.Run(t, func(t *testing.T) depgraphtest.DependencyManager {
+		return sqliteClient(t, filepath.Join(t.TempDir(), "dinghy.db"))
+	})
+}
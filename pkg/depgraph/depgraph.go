@@ -0,0 +1,258 @@
+/*
+* Copyright 2026 Armory, Inc.
+
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+
+*    http://www.apache.org/licenses/LICENSE-2.0
+
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+// Package depgraph answers questions about the dependency graph between
+// dinghyfiles and modules: what a module change impacts, what a dinghyfile
+// uses and which modules nothing uses anymore.
+package depgraph
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Reader is implemented by the dependency managers whose graph can be walked
+// edge by edge
+type Reader interface {
+	// Dependents returns the urls using url directly, sorted
+	Dependents(url string) ([]string, error)
+	// Dependencies returns the urls url uses directly, sorted
+	Dependencies(url string) ([]string, error)
+	// ListNodes returns every url of the graph, sorted
+	ListNodes() ([]string, error)
+}
+
+// Tree is a node of the graph and, transitively, the nodes it reaches in one
+// direction. Cycle marks a node already on the path from the top of the tree,
+// Repeated a node already expanded elsewhere in the tree, neither is expanded
+// again.
+type Tree struct {
+	URL      string  `json:"url"`
+	Cycle    bool    `json:"cycle,omitempty"`
+	Repeated bool    `json:"repeated,omitempty"`
+	Children []*Tree `json:"children,omitempty"`
+}
+
+// Leaves returns the urls of the nodes without children, sorted and unique.
+// For a tree of dependents those are the dinghyfiles impacted.
+func (t *Tree) Leaves() []string {
+	found := map[string]bool{}
+	var walk func(*Tree)
+	walk = func(n *Tree) {
+		if len(n.Children) == 0 && !n.Cycle && !n.Repeated && n != t {
+			found[n.URL] = true
+		}
+		for _, c := range n.Children {
+			walk(c)
+		}
+	}
+	walk(t)
+	leaves := make([]string, 0, len(found))
+	for url := range found {
+		leaves = append(leaves, url)
+	}
+	sort.Strings(leaves)
+	return leaves
+}
+
+// Graph returns the edges of the tree, pointing from the node using another
+// to the node used, whatever the direction the tree was walked in
+func (t *Tree) Graph(dependents bool) *Graph {
+	g := newGraph()
+	var walk func(*Tree)
+	walk = func(n *Tree) {
+		g.addNode(n.URL)
+		for _, c := range n.Children {
+			if dependents {
+				g.addEdge(c.URL, n.URL)
+			} else {
+				g.addEdge(n.URL, c.URL)
+			}
+			walk(c)
+		}
+	}
+	walk(t)
+	return g.sorted()
+}
+
+// Dependents returns the tree of everything using url, transitively
+func Dependents(r Reader, url string) (*Tree, error) {
+	return newWalker(r.Dependents).walk(url)
+}
+
+// Dependencies returns the tree of everything url uses, transitively
+func Dependencies(r Reader, url string) (*Tree, error) {
+	return newWalker(r.Dependencies).walk(url)
+}
+
+// walker expands every node once, so that subgraphs shared by several
+// nodes don't make the tree grow exponentially
+type walker struct {
+	next     func(string) ([]string, error)
+	path     map[string]bool
+	expanded map[string]bool
+}
+
+func newWalker(next func(string) ([]string, error)) *walker {
+	return &walker{next: next, path: map[string]bool{}, expanded: map[string]bool{}}
+}
+
+func (w *walker) walk(url string) (*Tree, error) {
+	t := &Tree{URL: url}
+	if w.path[url] {
+		t.Cycle = true
+		return t, nil
+	}
+	if w.expanded[url] {
+		t.Repeated = true
+		return t, nil
+	}
+	w.path[url] = true
+	defer delete(w.path, url)
+
+	urls, err := w.next(url)
+	if err != nil {
+		return nil, err
+	}
+	for _, u := range urls {
+		child, err := w.walk(u)
+		if err != nil {
+			return nil, err
+		}
+		t.Children = append(t.Children, child)
+	}
+	w.expanded[url] = true
+	return t, nil
+}
+
+// Orphans returns the modules nothing uses, sorted. Nodes named like a
+// dinghyfile are roots by design and never orphans.
+func Orphans(r Reader, dinghyfileName string) ([]string, error) {
+	nodes, err := r.ListNodes()
+	if err != nil {
+		return nil, err
+	}
+	orphans := make([]string, 0)
+	for _, url := range nodes {
+		if dinghyfileName != "" && path.Base(stripQuery(url)) == dinghyfileName {
+			continue
+		}
+		dependents, err := r.Dependents(url)
+		if err != nil {
+			return nil, err
+		}
+		if len(dependents) == 0 {
+			orphans = append(orphans, url)
+		}
+	}
+	return orphans, nil
+}
+
+// stripQuery drops the query of a url, the providers put the branch there
+func stripQuery(url string) string {
+	if i := strings.Index(url, "?"); i != -1 {
+		return url[:i]
+	}
+	return url
+}
+
+// Edge is a dependency, From uses To
+type Edge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Graph is the dependency graph, or a part of it
+type Graph struct {
+	Nodes []string `json:"nodes"`
+	Edges []Edge   `json:"edges"`
+
+	seen map[string]bool
+}
+
+func newGraph() *Graph {
+	return &Graph{Nodes: []string{}, Edges: []Edge{}, seen: map[string]bool{}}
+}
+
+func (g *Graph) addNode(url string) {
+	if !g.seen[url] {
+		g.seen[url] = true
+		g.Nodes = append(g.Nodes, url)
+	}
+}
+
+func (g *Graph) addEdge(from, to string) {
+	key := from + "\x00" + to
+	if !g.seen[key] {
+		g.seen[key] = true
+		g.Edges = append(g.Edges, Edge{From: from, To: to})
+	}
+	g.addNode(from)
+	g.addNode(to)
+}
+
+func (g *Graph) sorted() *Graph {
+	sort.Strings(g.Nodes)
+	sort.Slice(g.Edges, func(i, j int) bool {
+		if g.Edges[i].From != g.Edges[j].From {
+			return g.Edges[i].From < g.Edges[j].From
+		}
+		return g.Edges[i].To < g.Edges[j].To
+	})
+	return g
+}
+
+// Export returns the whole graph
+func Export(r Reader) (*Graph, error) {
+	nodes, err := r.ListNodes()
+	if err != nil {
+		return nil, err
+	}
+	g := newGraph()
+	for _, url := range nodes {
+		g.addNode(url)
+		dependencies, err := r.Dependencies(url)
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range dependencies {
+			g.addEdge(url, d)
+		}
+	}
+	return g.sorted(), nil
+}
+
+// DOT renders the graph in the Graphviz DOT language
+func (g *Graph) DOT() string {
+	var b strings.Builder
+	b.WriteString("digraph dinghy {\n")
+	b.WriteString("  rankdir=LR;\n")
+	linked := map[string]bool{}
+	for _, e := range g.Edges {
+		linked[e.From], linked[e.To] = true, true
+		fmt.Fprintf(&b, "  %s -> %s;\n", strconv.Quote(e.From), strconv.Quote(e.To))
+	}
+	for _, n := range g.Nodes {
+		if !linked[n] {
+			fmt.Fprintf(&b, "  %s;\n", strconv.Quote(n))
+		}
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
@@ -0,0 +1,134 @@
+/*
+* Copyright 2026 Armory, Inc.
+
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+
+*    http://www.apache.org/licenses/LICENSE-2.0
+
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package depgraph
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/armory/dinghy/pkg/cache"
+	"github.com/stretchr/testify/assert"
+)
+
+func testGraph() cache.MemoryCache {
+	c := cache.NewMemoryCache()
+	c.SetDeps("app1/dinghyfile", []string{"stage.module", "wait.module"})
+	c.SetDeps("app2/dinghyfile", []string{"stage.module"})
+	c.SetDeps("stage.module", []string{"wait.module"})
+	// used to be used by app1
+	c.SetDeps("app1/dinghyfile", []string{"stage.module"})
+	return c
+}
+
+func TestDependents(t *testing.T) {
+	tree, err := Dependents(testGraph(), "wait.module")
+	assert.Nil(t, err)
+	assert.Equal(t, &Tree{URL: "wait.module", Children: []*Tree{
+		{URL: "stage.module", Children: []*Tree{{URL: "app1/dinghyfile"}, {URL: "app2/dinghyfile"}}},
+	}}, tree)
+	assert.Equal(t, []string{"app1/dinghyfile", "app2/dinghyfile"}, tree.Leaves())
+	assert.Equal(t, []Edge{
+		{From: "app1/dinghyfile", To: "stage.module"},
+		{From: "app2/dinghyfile", To: "stage.module"},
+		{From: "stage.module", To: "wait.module"},
+	}, tree.Graph(true).Edges)
+}
+
+func TestDependencies(t *testing.T) {
+	tree, err := Dependencies(testGraph(), "app1/dinghyfile")
+	assert.Nil(t, err)
+	assert.Equal(t, &Tree{URL: "app1/dinghyfile", Children: []*Tree{
+		{URL: "stage.module", Children: []*Tree{{URL: "wait.module"}}},
+	}}, tree)
+	assert.Equal(t, []Edge{
+		{From: "app1/dinghyfile", To: "stage.module"},
+		{From: "stage.module", To: "wait.module"},
+	}, tree.Graph(false).Edges)
+
+	tree, err = Dependencies(testGraph(), "unknown")
+	assert.Nil(t, err)
+	assert.Equal(t, &Tree{URL: "unknown"}, tree)
+	assert.Empty(t, tree.Leaves())
+}
+
+func TestCycle(t *testing.T) {
+	c := cache.NewMemoryCache()
+	c.SetDeps("a.module", []string{"b.module"})
+	c.SetDeps("b.module", []string{"a.module"})
+
+	tree, err := Dependencies(c, "a.module")
+	assert.Nil(t, err)
+	assert.Equal(t, &Tree{URL: "a.module", Children: []*Tree{
+		{URL: "b.module", Children: []*Tree{{URL: "a.module", Cycle: true}}},
+	}}, tree)
+}
+
+func TestSharedSubgraph(t *testing.T) {
+	c := cache.NewMemoryCache()
+	c.SetDeps("app/dinghyfile", []string{"deploy.module", "verify.module"})
+	c.SetDeps("deploy.module", []string{"stage.module"})
+	c.SetDeps("verify.module", []string{"stage.module"})
+	c.SetDeps("stage.module", []string{"wait.module"})
+
+	tree, err := Dependencies(c, "app/dinghyfile")
+	assert.Nil(t, err)
+	assert.Equal(t, &Tree{URL: "app/dinghyfile", Children: []*Tree{
+		{URL: "deploy.module", Children: []*Tree{{URL: "stage.module", Children: []*Tree{{URL: "wait.module"}}}}},
+		{URL: "verify.module", Children: []*Tree{{URL: "stage.module", Repeated: true}}},
+	}}, tree)
+	assert.Equal(t, []string{"wait.module"}, tree.Leaves())
+	assert.Len(t, tree.Graph(false).Edges, 5)
+
+	// a chain of diamonds doubles the paths at every level, each node is
+	// still expanded once
+	c = cache.NewMemoryCache()
+	for i := 0; i < 30; i++ {
+		c.SetDeps(fmt.Sprintf("%d.module", i), []string{fmt.Sprintf("%d-left.module", i), fmt.Sprintf("%d-right.module", i)})
+		c.SetDeps(fmt.Sprintf("%d-left.module", i), []string{fmt.Sprintf("%d.module", i+1)})
+		c.SetDeps(fmt.Sprintf("%d-right.module", i), []string{fmt.Sprintf("%d.module", i+1)})
+	}
+	tree, err = Dependencies(c, "0.module")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"30.module"}, tree.Leaves())
+}
+
+func TestOrphans(t *testing.T) {
+	c := testGraph()
+	c.SetDeps("old.module", []string{"wait.module"})
+	c.SetDeps("https://github.com/repos/org/app3/contents/dinghyfile?ref=master", nil)
+
+	orphans, err := Orphans(c, "dinghyfile")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"old.module"}, orphans)
+}
+
+func TestExport(t *testing.T) {
+	c := testGraph()
+	c.SetDeps("lonely.module", nil)
+
+	g, err := Export(c)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"app1/dinghyfile", "app2/dinghyfile", "lonely.module", "stage.module", "wait.module"}, g.Nodes)
+	assert.Equal(t, `digraph dinghy {
+  rankdir=LR;
+  "app1/dinghyfile" -> "stage.module";
+  "app2/dinghyfile" -> "stage.module";
+  "stage.module" -> "wait.module";
+  "lonely.module";
+}
+`, g.DOT())
+}
@@ -0,0 +1,172 @@
+/*
+* Copyright 2026 Armory, Inc.
+
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+
+*    http://www.apache.org/licenses/LICENSE-2.0
+
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+// Package depgraphtest is the conformance suite every dependency graph
+// backend must pass.
+package depgraphtest
+
+import (
+	"testing"
+
+	"github.com/armory/dinghy/pkg/depgraph"
+	"github.com/stretchr/testify/assert"
+)
+
+// DependencyManager is the graph part of dinghyfile.DependencyManager, which
+// can't be imported from the backends
+type DependencyManager interface {
+	GetRawData(url string) (string, error)
+	SetRawData(url string, rawData string) error
+	SetDeps(parent string, deps []string)
+	GetRoots(child string) []string
+	DeleteNode(url string) error
+}
+
+// Run runs the suite, newManager must return an empty graph on every call
+func Run(t *testing.T, newManager func(t *testing.T) DependencyManager) {
+	cases := map[string]func(t *testing.T, m DependencyManager){
+		"roots":           testRoots,
+		"replaces edges":  testReplacesEdges,
+		"duplicate deps":  testDuplicateDeps,
+		"clears edges":    testClearsEdges,
+		"cycles":          testCycles,
+		"deletes nodes":   testDeleteNode,
+		"raw data":        testRawData,
+		"reads the graph": testReader,
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			c(t, newManager(t))
+		})
+	}
+}
+
+// setGraph sets the graph most cases start from
+//
+//	 df1    df2
+//	  /\    /\
+//	 /  \  /  \
+//	mod1  mod2  |
+//	  \    |    |
+//	   `- mod3 -'
+//	       |
+//	      mod4
+func setGraph(m DependencyManager) {
+	m.SetDeps("df1", []string{"mod1", "mod2"})
+	m.SetDeps("df2", []string{"mod2", "mod3"})
+	m.SetDeps("mod1", []string{"mod3"})
+	m.SetDeps("mod2", []string{"mod3"})
+	m.SetDeps("mod3", []string{"mod4"})
+}
+
+func testRoots(t *testing.T, m DependencyManager) {
+	setGraph(m)
+	assert.ElementsMatch(t, []string{"df1"}, m.GetRoots("mod1"))
+	assert.ElementsMatch(t, []string{"df1", "df2"}, m.GetRoots("mod3"))
+	// every root once, however many paths lead to it
+	assert.ElementsMatch(t, []string{"df1", "df2"}, m.GetRoots("mod4"))
+	// a root isn't its own root
+	assert.Empty(t, m.GetRoots("df1"))
+	assert.Empty(t, m.GetRoots("unknown"))
+}
+
+func testReplacesEdges(t *testing.T, m DependencyManager) {
+	setGraph(m)
+	m.SetDeps("df2", []string{"mod3", "mod5"})
+	assert.ElementsMatch(t, []string{"df1"}, m.GetRoots("mod2"))
+	assert.ElementsMatch(t, []string{"df2"}, m.GetRoots("mod5"))
+
+	m.SetDeps("mod3", []string{})
+	assert.Empty(t, m.GetRoots("mod4"))
+}
+
+func testDuplicateDeps(t *testing.T, m DependencyManager) {
+	m.SetDeps("df1", []string{"mod1", "mod1"})
+	m.SetDeps("df1", []string{"mod1", "mod1"})
+	assert.Equal(t, []string{"df1"}, m.GetRoots("mod1"))
+
+	m.SetDeps("df1", []string{"mod2"})
+	assert.Empty(t, m.GetRoots("mod1"))
+}
+
+func testClearsEdges(t *testing.T, m DependencyManager) {
+	m.SetDeps("df1", []string{"mod1", "mod2"})
+	m.SetDeps("df1", nil)
+	assert.Empty(t, m.GetRoots("mod1"))
+	assert.Empty(t, m.GetRoots("mod2"))
+}
+
+func testCycles(t *testing.T, m DependencyManager) {
+	m.SetDeps("df1", []string{"a"})
+	m.SetDeps("a", []string{"b"})
+	m.SetDeps("b", []string{"a", "mod1"})
+	assert.Equal(t, []string{"df1"}, m.GetRoots("mod1"))
+
+	m.SetDeps("c", []string{"d"})
+	m.SetDeps("d", []string{"c"})
+	assert.Empty(t, m.GetRoots("d"))
+}
+
+func testDeleteNode(t *testing.T, m DependencyManager) {
+	setGraph(m)
+	assert.Nil(t, m.DeleteNode("df1"))
+	assert.Empty(t, m.GetRoots("mod1"))
+	// nothing uses mod1 anymore
+	assert.ElementsMatch(t, []string{"df2", "mod1"}, m.GetRoots("mod4"))
+
+	assert.Nil(t, m.DeleteNode("mod3"))
+	assert.Empty(t, m.GetRoots("mod4"))
+	assert.Nil(t, m.DeleteNode("unknown"))
+}
+
+func testRawData(t *testing.T, m DependencyManager) {
+	m.SetDeps("df1", []string{"mod1"})
+	assert.Nil(t, m.SetRawData("df1", `{"ref":"refs/heads/master"}`))
+	rawData, err := m.GetRawData("df1")
+	assert.Nil(t, err)
+	assert.Equal(t, `{"ref":"refs/heads/master"}`, rawData)
+
+	// a new SetDeps keeps the raw data
+	m.SetDeps("df1", []string{"mod2"})
+	rawData, err = m.GetRawData("df1")
+	assert.Nil(t, err)
+	assert.Equal(t, `{"ref":"refs/heads/master"}`, rawData)
+
+	assert.Nil(t, m.DeleteNode("df1"))
+	rawData, _ = m.GetRawData("df1")
+	assert.Empty(t, rawData)
+}
+
+func testReader(t *testing.T, m DependencyManager) {
+	reader, ok := m.(depgraph.Reader)
+	if !ok {
+		t.Skip("not a depgraph.Reader")
+	}
+	setGraph(m)
+	m.SetDeps("df2", []string{"mod3", "mod2", "mod2"})
+
+	dependents, err := reader.Dependents("mod2")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"df1", "df2"}, dependents)
+
+	dependencies, err := reader.Dependencies("df2")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"mod2", "mod3"}, dependencies)
+
+	dependencies, err = reader.Dependencies("unknown")
+	assert.Nil(t, err)
+	assert.Empty(t, dependencies)
+}
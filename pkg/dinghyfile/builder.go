@@ -18,6 +18,7 @@ package dinghyfile
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -28,9 +29,14 @@ import (
 	"time"
 
 	"github.com/armory/dinghy/pkg/events"
+	"github.com/armory/dinghy/pkg/history"
+	"github.com/armory/dinghy/pkg/managed"
 	"github.com/armory/dinghy/pkg/notifiers"
+	"github.com/armory/dinghy/pkg/ownership"
+	"github.com/armory/dinghy/pkg/tracing"
 	"github.com/armory/dinghy/pkg/util"
 	"github.com/armory/plank/v4"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type VarMap map[string]interface{}
@@ -63,6 +69,31 @@ type PipelineBuilder struct {
 	JsonValidationDisabled             bool
 	UserWriteAccessValidation          UserWriteAccessValidation
 	UpsertPipelineUsingOrcaTaskEnabled bool
+	// Ctx carries the trace context of the request being processed
+	Ctx context.Context
+	// OwnershipPolicy, when set, restricts which dinghyfiles can manage an application
+	OwnershipPolicy *ownership.Policy
+	// Managed, when set, records the pipelines every dinghyfile applied
+	Managed managed.Store
+	// RemovedDinghyfilePolicy is applied to the application of a removed dinghyfile
+	RemovedDinghyfilePolicy string
+	// StalePipelinesDryRun only records the stale pipelines that would be deleted
+	StalePipelinesDryRun bool
+	// Commit is the commit being processed, recorded with the managed pipelines
+	Commit string
+	// Pusher is the user who pushed the commit, sent with the notifications
+	Pusher string
+	// RollbackOnFailure snapshots the application before writing to it and
+	// restores the snapshot when a write fails
+	RollbackOnFailure bool
+	// History, when set, keeps the rendered dinghyfile of every successful apply
+	History history.Store
+	// RenderHistoryLimit is the number of renders kept per application, the
+	// oldest are pruned after each apply. history.DefaultLimit when not set.
+	RenderHistoryLimit int
+	// ModuleBranch, when set, renders the modules of the template repo from
+	// this branch instead of the branch of the dinghyfile using them
+	ModuleBranch string
 }
 
 // DependencyManager is an interface for assigning dependencies and looking up root nodes
@@ -71,6 +102,14 @@ type DependencyManager interface {
 	SetRawData(url string, rawData string) error
 	SetDeps(parent string, deps []string)
 	GetRoots(child string) []string
+	DeleteNode(url string) error
+}
+
+// RootLister is implemented by the dependency managers that can enumerate
+// every dinghyfile they know about
+type RootLister interface {
+	// ListRoots returns the URL of every node without parents
+	ListRoots() ([]string, error)
 }
 
 // Downloader is an interface that fetches files from a source
@@ -89,13 +128,17 @@ type Dinghyfile struct {
 	DeleteStalePipelines bool                   `json:"deleteStalePipelines" yaml:"deleteStalePipelines" hcl:"deleteStalePipelines"`
 	Globals              map[string]interface{} `json:"globals" yaml:"globals" hcl:"globals"`
 	Pipelines            []plank.Pipeline       `json:"pipelines" yaml:"pipelines" hcl:"pipelines"`
+	// PipelineKeys maps pipeline names to their optional dinghyId, a key that
+	// survives renames
+	PipelineKeys map[string]string `json:"-" yaml:"-" hcl:"-"`
 }
 
 type UserWriteAccessValidation struct {
-	Logger  log.DinghyLog
-	Client  util.PlankClient
-	Enabled bool
-	Ignore  []string
+	Logger      log.DinghyLog
+	Client      util.PlankClient
+	Enabled     bool
+	Ignore      []string
+	Traceparent string
 }
 
 func (v *UserWriteAccessValidation) Validate(application plank.Application, pusher string) error {
@@ -105,6 +148,9 @@ func (v *UserWriteAccessValidation) Validate(application plank.Application, push
 		return nil
 	}
 	validator := GetWritePermissionsValidator(v.Enabled, v.Client, application)
+	if fiatValidator, ok := validator.(*FiatPermissionsValidator); ok {
+		fiatValidator.traceparent = v.Traceparent
+	}
 	return validator.Validate(pusher)
 }
 
@@ -168,6 +214,12 @@ func (b *PipelineBuilder) UpdateDinghyfile(dinghyfile []byte) (Dinghyfile, error
 	}
 	b.Logger.Infof("Unmarshalled: %v", d)
 
+	keys, err := b.pipelineKeys(dinghyfile)
+	if err != nil {
+		return d, err
+	}
+	d.PipelineKeys = keys
+
 	// If "spec" is not provided, these will be initialized to ""; need to pull them in.
 	if d.ApplicationSpec.Name == "" {
 		d.ApplicationSpec.Name = d.Application
@@ -249,6 +301,51 @@ func (b *PipelineBuilder) DetermineParser(path string) Parser {
 	return NewDinghyfileParser(b)
 }
 
+// checkDinghyfile runs the validations and the ownership check a rendered
+// dinghyfile must pass before it is applied. The application is only claimed
+// once it is applied, see claimOwnership.
+func (b *PipelineBuilder) checkDinghyfile(org, repo, path string, dinghyfile Dinghyfile, rendered []byte) error {
+	endValidateSpan := b.startSpan(tracing.SpanValidate, attribute.String("dinghy.application", dinghyfile.ApplicationSpec.Name))
+	err := b.ValidatePipelines(dinghyfile, rendered)
+	if err != nil {
+		endValidateSpan(err)
+		b.Logger.Errorf("Failed to validate pipelines %s", path)
+		return err
+	}
+	b.Logger.Info("Validations for stage refs were successful")
+
+	err = b.ValidateAppNotifications(dinghyfile, rendered)
+	endValidateSpan(err)
+	if err != nil {
+		b.Logger.Errorf("Failed to validate application notifications %s", dinghyfile.ApplicationSpec.Notifications)
+		return err
+	}
+	b.Logger.Info("Validations for app notifications were successful")
+
+	if b.OwnershipPolicy != nil {
+		source := ownership.Owner{Org: org, Repo: repo, Path: path}
+		if err := b.OwnershipPolicy.Check(dinghyfile.ApplicationSpec.Name, source, false); err != nil {
+			b.Logger.Errorf("Ownership check failed for %s: %s", path, err.Error())
+			return err
+		}
+	}
+	return nil
+}
+
+// claimOwnership makes the dinghyfile the owner of the application it just
+// applied, unless the application already has one
+func (b *PipelineBuilder) claimOwnership(org, repo, path, application string) error {
+	if b.OwnershipPolicy == nil {
+		return nil
+	}
+	source := ownership.Owner{Org: org, Repo: repo, Path: path}
+	if err := b.OwnershipPolicy.Check(application, source, true); err != nil {
+		b.Logger.Errorf("Could not claim application %s for %s: %s", application, path, err.Error())
+		return err
+	}
+	return nil
+}
+
 // ProcessDinghyfile downloads a dinghyfile and uses it to update Spinnaker's pipelines.
 func (b *PipelineBuilder) ProcessDinghyfile(org, repo, path, branch, pusher string) (string, error) {
 	if b.Parser == nil {
@@ -256,7 +353,9 @@ func (b *PipelineBuilder) ProcessDinghyfile(org, repo, path, branch, pusher stri
 		b.Logger.Info("Calling DetermineParser")
 		b.Parser = b.DetermineParser(path)
 	}
+	endRenderSpan := b.startSpan(tracing.SpanRender, attribute.String("dinghy.path", path))
 	buf, err := b.Parser.Parse(org, repo, path, branch, nil)
+	endRenderSpan(err)
 	if err != nil {
 		buf, errDownload := b.Downloader.Download(org, repo, path, branch)
 		b.Logger.Errorf("Failed to parse dinghyfile %s: %s", path, err.Error())
@@ -278,33 +377,36 @@ func (b *PipelineBuilder) ProcessDinghyfile(org, repo, path, branch, pusher stri
 	b.Logger.Infof("Updated: %s", buf.String())
 	b.Logger.Infof("Dinghyfile struct: %v", dinghyfile)
 
-	err = b.ValidatePipelines(dinghyfile, buf.Bytes())
-	if err != nil {
-		b.Logger.Errorf("Failed to validate pipelines %s", path)
-		b.NotifyFailure(org, repo, path, err, buf.String())
-		return buf.String(), err
-	}
-	b.Logger.Info("Validations for stage refs were successful")
-
-	err = b.ValidateAppNotifications(dinghyfile, buf.Bytes())
-	if err != nil {
-		b.Logger.Errorf("Failed to validate application notifications %s", dinghyfile.ApplicationSpec.Notifications)
+	if err := b.checkDinghyfile(org, repo, path, dinghyfile, buf.Bytes()); err != nil {
 		b.NotifyFailure(org, repo, path, err, buf.String())
 		return buf.String(), err
 	}
-	b.Logger.Info("Validations for app notifications were successful")
 
 	if b.Action == pipebuilder.Validate {
 		b.Logger.Info("Validation finished successfully")
 	} else {
-		if err := b.updatePipelines(dinghyfile, pusher); err != nil {
+		url := b.Downloader.EncodeURL(org, repo, path, branch)
+		previous := b.previouslyManaged(url)
+		source := managed.Marker{URL: url, Org: org, Repo: repo, Path: path}
+		applied, stale, err := b.updatePipelines(dinghyfile, source, pusher, previous)
+		if err != nil {
 			b.Logger.Errorf("Failed to update Pipelines for %s: %s", path, err.Error())
 			b.NotifyFailure(org, repo, path, err, buf.String())
 			return buf.String(), err
 		}
+		if err := b.claimOwnership(org, repo, path, dinghyfile.ApplicationSpec.Name); err != nil {
+			b.NotifyFailure(org, repo, path, err, buf.String())
+			return buf.String(), err
+		}
+		if b.Managed != nil {
+			b.recordManaged(url, org, repo, path, dinghyfile, stale)
+		}
+		if b.History != nil {
+			b.saveRender(url, org, repo, path, branch, pusher, dinghyfile, buf.String(), applied)
+		}
 	}
 
-	b.NotifySuccess(org, repo, path, dinghyfile.ApplicationSpec.Notifications)
+	b.NotifySuccess(org, repo, path, dinghyfile.ApplicationSpec.Name, dinghyfile.ApplicationSpec.Notifications)
 	return buf.String(), nil
 }
 
@@ -353,20 +455,7 @@ func (b *PipelineBuilder) RebuildModuleRoots(org, repo, path, branch, pusher str
 	for _, url := range b.Depman.GetRoots(url) {
 		org, repo, path, branch := b.Downloader.DecodeURL(url)
 		if filepath.Base(path) == b.DinghyfileName {
-			if b.RepositoryRawdataProcessing {
-				rawData, errRaw := b.Depman.GetRawData(url)
-				if errRaw == nil && rawData != "" {
-					b.Logger.Infof("found rawdata for %v", url)
-					// deserialze push data to a map.
-					rawPushData := make(map[string]interface{})
-					if err := json.Unmarshal([]byte(rawData), &rawPushData); err != nil {
-						b.Logger.Errorf("unable to deserialize raw data to map while executing RebuildModuleRoots")
-					} else {
-						b.Logger.Infof("using latest rawdata from %v", url)
-						b.PushRaw = rawPushData
-					}
-				}
-			}
+			b.LoadRawData(url)
 
 			if _, err := b.ProcessDinghyfile(org, repo, path, branch, pusher); err != nil {
 				errEncountered = true
@@ -392,56 +481,110 @@ func (b *PipelineBuilder) RebuildModuleRoots(org, repo, path, branch, pusher str
 	return nil
 }
 
-// This is the bit that actually updates the pipeline(s) and application in Spinnaker
-func (b *PipelineBuilder) updatePipelines(dinghyfile Dinghyfile, pusher string) error {
+// LoadRawData makes the push data last stored for url the data of the push
+// being processed, when repository rawdata processing is enabled. Dinghyfiles
+// processed without a push of their own use it.
+func (b *PipelineBuilder) LoadRawData(url string) {
+	if !b.RepositoryRawdataProcessing {
+		return
+	}
+	rawData, errRaw := b.Depman.GetRawData(url)
+	if errRaw == nil && rawData != "" {
+		b.Logger.Infof("found rawdata for %v", url)
+		// deserialze push data to a map.
+		rawPushData := make(map[string]interface{})
+		if err := json.Unmarshal([]byte(rawData), &rawPushData); err != nil {
+			b.Logger.Errorf("unable to deserialize raw data to map while rebuilding module roots")
+		} else {
+			b.Logger.Infof("using latest rawdata from %v", url)
+			b.PushRaw = rawPushData
+		}
+	}
+}
+
+// updatePipelines is the bit that actually updates the pipeline(s) and
+// application in Spinnaker. It returns the pipelines upserted, as sent to
+// Spinnaker and marked with source. Only pipelines carrying the marker of the
+// same source are considered stale, stale lists the ones a dry run kept.
+// previous is what the dinghyfile applied last time, it maps renamed pipelines
+// to the ID of their old name.
+func (b *PipelineBuilder) updatePipelines(dinghyfile Dinghyfile, source managed.Marker, pusher string, previous *managed.Dinghyfile) (applied []plank.Pipeline, stale []string, err error) {
+	endSpan := b.startSpan(tracing.SpanUpsert, attribute.String("dinghy.application", dinghyfile.ApplicationSpec.Name))
+	defer func() { endSpan(err) }()
+
 	app := dinghyfile.ApplicationSpec
 	pipelines := dinghyfile.Pipelines
 	deleteStale := dinghyfile.DeleteStalePipelines
 
 	var newapp = false
-	_, err := b.Client.GetApplication(app.Name, "")
+	current, err := b.Client.GetApplication(app.Name, b.traceparent())
+	var snapshot *applySnapshot
+	if b.RollbackOnFailure {
+		snapshot = &applySnapshot{application: current}
+	}
 	if err != nil {
 		newapp = true
 		failedResponse, ok := err.(*plank.FailedResponse)
 		if !ok {
 			b.Logger.Errorf("Failed to create application (%s)", err.Error())
-			return err
+			return nil, nil, err
 		}
 		if failedResponse.StatusCode == 404 {
 			// Likely just not there...
 			b.Logger.Infof("Creating application '%s'...", app.Name)
-			if err = b.Client.CreateApplication(&app, ""); err != nil {
+			if err = b.Client.CreateApplication(&app, b.traceparent()); err != nil {
 				b.Logger.Errorf("Failed to create application (%s)", failedResponse.Error())
-				return err
+				return nil, nil, err
+			}
+			if snapshot != nil {
+				snapshot.application = nil
+				snapshot.appCreated = true
 			}
 		} else {
 			b.Logger.Errorf("Failed to create application (%s)", failedResponse.Error())
-			return err
+			return nil, nil, err
 		}
 	} else {
 		if b.saveAppOnUpdate() {
 			//UpdateApplication method updates application permissions. It is possible that a user, who pushed changes to repository
 			//doesn't have write access to the application, thus we need to prevent from updating the app.
+			b.UserWriteAccessValidation.Traceparent = b.traceparent()
 			err := b.UserWriteAccessValidation.Validate(app, pusher)
 			if err != nil {
-				return err
+				return nil, nil, err
+			}
+			if snapshot != nil {
+				if snapshot.notifications, err = b.Client.GetApplicationNotifications(app.Name, b.traceparent()); err != nil {
+					b.Logger.Errorf("Failed to snapshot notifications of %s: %s", app.Name, err.Error())
+					return nil, nil, err
+				}
 			}
-			errUpdating := b.Client.UpdateApplication(app, "")
+			errUpdating := b.Client.UpdateApplication(app, b.traceparent())
 			if errUpdating != nil {
 				b.Logger.Errorf("Failed to update application (%s)", errUpdating.Error())
-				return errUpdating
+				return nil, nil, errUpdating
+			}
+			if snapshot != nil {
+				snapshot.appUpdated = true
 			}
 		}
 	}
 
 	if b.saveAppOnUpdate() || newapp {
 		b.Logger.Infof("Updating notifications: %s", app.Notifications)
-		errNotif := b.Client.UpdateApplicationNotifications(app.Notifications, app.Name, "")
+		errNotif := b.Client.UpdateApplicationNotifications(app.Notifications, app.Name, b.traceparent())
 		if errNotif != nil {
 			b.Logger.Errorf("Failed to update notifications: (%s)", errNotif.Error())
+		} else if snapshot != nil {
+			snapshot.notificationsUpdated = true
 		}
 	}
 
+	if snapshot != nil {
+		if err = snapshot.addPipelines(b, app.Name); err != nil {
+			return nil, nil, b.rollback(app.Name, snapshot, err)
+		}
+	}
 	ids, _ := b.PipelineIDs(app.Name)
 	ignoreList := make(map[string]bool)
 	idToName := make(map[string]string)
@@ -458,57 +601,77 @@ func (b *PipelineBuilder) updatePipelines(dinghyfile Dinghyfile, pusher string)
 			ignoreList[p.Name] = true
 			p.ID = id //note: we're working with a copy.  once this loop exits all changes go out of scope!
 			b.Logger.Info("Updating pipeline: " + p.Name)
+		} else if oldName, id, renamed := renamedPipeline(dinghyfile, p.Name, previous, ids); renamed {
+			ignoreList[p.Name] = true
+			ignoreList[oldName] = true
+			p.ID = id
+			b.Logger.Infof("Renaming pipeline %s to %s", oldName, p.Name)
 		} else {
 			b.Logger.Debug("Adding ", p.Name, " to ignored stale pipelines")
 			ignoreList[p.Name] = true
 			b.Logger.Info("Creating pipeline: " + p.Name)
 		}
+		p.Description = managed.Mark(p.Description, source)
 		if b.AutolockPipelines == "true" {
 			b.Logger.Debug("Locking pipeline ", p.Name)
 			p.Lock()
 		}
 
 		if b.UpsertPipelineUsingOrcaTaskEnabled {
-			if err := b.Client.UpsertPipelineUsingOrca(p, p.ID, ""); err != nil {
+			if err := b.Client.UpsertPipelineUsingOrca(p, p.ID, b.traceparent()); err != nil {
 				b.Logger.Errorf("Upsert failed: %s", err.Error())
-				return err
+				return nil, nil, b.rollback(app.Name, snapshot, err)
 			}
 		} else {
-			if err := b.Client.UpsertPipeline(p, p.ID, ""); err != nil {
+			if err := b.Client.UpsertPipeline(p, p.ID, b.traceparent()); err != nil {
 				err = unwrapFront50Error(err)
 				b.Logger.Errorf("Upsert failed: %s", err.Error())
-				return err
+				return nil, nil, b.rollback(app.Name, snapshot, err)
 			}
 		}
 		b.Logger.Info("Upsert succeeded.")
+		applied = append(applied, p)
+		if snapshot != nil {
+			snapshot.written = append(snapshot.written, p)
+		}
 	}
 	if deleteStale {
 		// clear existing pipelines that weren't updated
 		b.Logger.Debug("Pipelines we should ignore because they were just created: ", ignoreList)
-		allPipelines, err := b.Client.GetPipelines(app.Name, "")
+		allPipelines, err := b.Client.GetPipelines(app.Name, b.traceparent())
 		if err != nil {
 			b.Logger.Errorf("Could not retrieve pipelines for %s: %s", app.Name, err.Error())
 		} else {
 			for _, p := range allPipelines {
-				if !ignoreList[p.Name] {
-					b.Logger.Infof("Deleting stale pipeline %s", p.Name)
-					if err := b.Client.DeletePipeline(p, ""); err != nil {
-						// Not worrying about handling errors here because it just means it
-						// didn't get deleted *this time*.
-						b.Logger.Warnf("Could not delete Pipeline %s (Application %s)", p.Name, p.Application)
-					}
+				if ignoreList[p.Name] {
+					continue
+				}
+				if marker, marked := managed.ParseMarker(p.Description); !marked || !marker.SameSource(source) {
+					b.Logger.Infof("Keeping pipeline %s, it wasn't written by this dinghyfile", p.Name)
+					continue
+				}
+				if b.StalePipelinesDryRun {
+					b.Logger.Infof("Dry run, stale pipeline %s would be deleted", p.Name)
+					stale = append(stale, p.Name)
+					continue
+				}
+				b.Logger.Infof("Deleting stale pipeline %s", p.Name)
+				if err := b.Client.DeletePipeline(p, b.traceparent()); err != nil {
+					// Not worrying about handling errors here because it just means it
+					// didn't get deleted *this time*.
+					b.Logger.Warnf("Could not delete Pipeline %s (Application %s)", p.Name, p.Application)
 				}
 			}
 		}
 	}
-	return err
+	return applied, stale, err
 }
 
 // PipelineIDs returns a map of pipeline names -> their UUID.
 func (b *PipelineBuilder) PipelineIDs(app string) (map[string]string, error) {
 	ids := map[string]string{}
 	b.Logger.Info("Looking up existing pipelines")
-	pipelines, err := b.Client.GetPipelines(app, "")
+	pipelines, err := b.Client.GetPipelines(app, b.traceparent())
 	if err != nil {
 		b.Logger.Errorf("Failed to GetPipelines for %s: %s", app, err.Error())
 		return ids, err
@@ -527,7 +690,7 @@ func (b *PipelineBuilder) GetPipelineByID(app, pipelineName string) (string, err
 			Name:  app,
 			Email: DefaultEmail,
 		}
-		_, err := b.Client.GetApplication(app, "")
+		_, err := b.Client.GetApplication(app, b.traceparent())
 		if err != nil {
 			failedResponse, ok := err.(*plank.FailedResponse)
 			if !ok {
@@ -537,7 +700,7 @@ func (b *PipelineBuilder) GetPipelineByID(app, pipelineName string) (string, err
 			if failedResponse.StatusCode == 404 {
 				// Likely just not there...
 				b.Logger.Infof("Creating application '%s'...", application.Name)
-				if err = b.Client.CreateApplication(application, ""); err != nil {
+				if err = b.Client.CreateApplication(application, b.traceparent()); err != nil {
 					b.Logger.Errorf("Failed to create application (%s)", failedResponse.Error())
 					return "", err
 				}
@@ -559,7 +722,7 @@ func (b *PipelineBuilder) GetPipelineByID(app, pipelineName string) (string, err
 	err = b.Client.UpsertPipeline(plank.Pipeline{
 		Application: app,
 		Name:        pipelineName,
-	}, "", "")
+	}, "", b.traceparent())
 	if err != nil {
 		err = unwrapFront50Error(err)
 		b.Logger.Errorf("Failed to UpsertPipeline for %s (%s): %s", pipelineName, app, err.Error())
@@ -572,32 +735,33 @@ func (b *PipelineBuilder) AddUnmarshaller(u Unmarshaller) {
 	b.Ums = append(b.Ums, u)
 }
 
-func (b *PipelineBuilder) NotifySuccess(org, repo, path string, notifications plank.NotificationsType) {
+func (b *PipelineBuilder) NotifySuccess(org, repo, path, application string, notifications plank.NotificationsType) {
 	for _, n := range b.Notifiers {
 		if b.Action == pipebuilder.Validate {
 			if n.SendOnValidation() {
-				n.SendSuccess(org, repo, path, notifications, b.getNotificationContent())
+				n.SendSuccess(org, repo, path, notifications, b.getNotificationContent(application))
 			}
 		} else {
-			n.SendSuccess(org, repo, path, notifications, b.getNotificationContent())
+			n.SendSuccess(org, repo, path, notifications, b.getNotificationContent(application))
 		}
 	}
 }
 
 func (b *PipelineBuilder) NotifyFailure(org, repo, path string, err error, dinghyfile string) {
 	var notifications plank.NotificationsType
-	if appName, err := extractApplicationName(dinghyfile); err == nil {
-		if foundNotifications, errGetApp := b.Client.GetApplicationNotifications(appName, ""); errGetApp == nil {
+	appName, errName := extractApplicationName(dinghyfile)
+	if errName == nil {
+		if foundNotifications, errGetApp := b.Client.GetApplicationNotifications(appName, b.traceparent()); errGetApp == nil {
 			notifications = *foundNotifications
 		}
 	}
 	for _, n := range b.Notifiers {
 		if b.Action == pipebuilder.Validate {
 			if n.SendOnValidation() {
-				n.SendFailure(org, repo, path, err, notifications, b.getNotificationContent())
+				n.SendFailure(org, repo, path, err, notifications, b.getFailureContent(appName, dinghyfile))
 			}
 		} else {
-			n.SendFailure(org, repo, path, err, notifications, b.getNotificationContent())
+			n.SendFailure(org, repo, path, err, notifications, b.getFailureContent(appName, dinghyfile))
 		}
 	}
 }
@@ -627,21 +791,69 @@ func getParams(regEx, url string) (paramsMap map[string]string) {
 	return paramsMap
 }
 
-func (b *PipelineBuilder) getNotificationContent() map[string]interface{} {
+func (b *PipelineBuilder) getNotificationContent(application string) map[string]interface{} {
 	content := map[string]interface{}{}
 
 	logEvent, err := b.Logger.GetBytesBuffByLoggerKey(log.LogEventKey)
 	if err != nil {
 		b.Logger.Error(fmt.Sprintf("there was an error trying to get notification content: %v", err))
 	} else {
-		content["logevent"] = logEvent.String()
+		content[notifiers.LogEventContent] = logEvent.String()
 	}
 	if b.PushRaw != nil {
-		content["rawdata"] = b.PushRaw
+		content[notifiers.RawDataContent] = b.PushRaw
+	}
+	if b.Commit != "" {
+		content[notifiers.CommitContent] = b.Commit
+	}
+	if b.Pusher != "" {
+		content[notifiers.PusherContent] = b.Pusher
+	}
+	if application != "" {
+		content[notifiers.ApplicationContent] = application
+	}
+	if b.Action == pipebuilder.Validate {
+		content[notifiers.ValidationContent] = true
 	}
 	return content
 }
 
+// getFailureContent adds the dinghyfile that failed and the email of its
+// application spec to the notification content
+func (b *PipelineBuilder) getFailureContent(application, dinghyfile string) map[string]interface{} {
+	content := b.getNotificationContent(application)
+	if dinghyfile == "" {
+		return content
+	}
+	content[notifiers.DinghyfileContent] = dinghyfile
+	for _, ums := range b.Ums {
+		d := NewDinghyfile()
+		if err := ums.Unmarshal([]byte(dinghyfile), &d); err == nil && d.ApplicationSpec.Email != "" {
+			content[notifiers.ApplicationEmailContent] = d.ApplicationSpec.Email
+			break
+		}
+	}
+	return content
+}
+
+// traceparent returns the w3c traceparent header for the request being
+// processed, so calls to Spinnaker are correlated with it.
+func (b *PipelineBuilder) traceparent() string {
+	return tracing.Traceparent(b.Ctx)
+}
+
+// startSpan starts a child span of the builder's context and makes it the
+// current one until the returned function is called.
+func (b *PipelineBuilder) startSpan(name string, attrs ...attribute.KeyValue) func(error) {
+	parent := b.Ctx
+	ctx, span := tracing.StartSpan(parent, name, attrs...)
+	b.Ctx = ctx
+	return func(err error) {
+		tracing.EndSpan(span, err)
+		b.Ctx = parent
+	}
+}
+
 func (b *PipelineBuilder) saveAppOnUpdate() bool {
 	val, found := b.GlobalVariablesMap["save_app_on_update"]
 	return found && val == true
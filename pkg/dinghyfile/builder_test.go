@@ -22,6 +22,7 @@ import (
 	"github.com/armory/dinghy/pkg/dinghyfile/pipebuilder"
 	"github.com/armory/dinghy/pkg/events"
 	"github.com/armory/dinghy/pkg/log"
+	"github.com/armory/dinghy/pkg/managed"
 	"github.com/armory/dinghy/pkg/util"
 	"reflect"
 	"testing"
@@ -34,6 +35,7 @@ import (
 
 	"github.com/armory/dinghy/pkg/mock"
 	"github.com/armory/dinghy/pkg/notifiers"
+	"github.com/armory/dinghy/pkg/ownership"
 )
 
 // Test the high-level runthrough of ProcessDinghyfile
@@ -69,10 +71,71 @@ func TestProcessDinghyfile(t *testing.T) {
 	pb.Parser = renderer
 	pb.Client = client
 	pb.Logger = logger
+	registry := ownerRegistry{}
+	pb.OwnershipPolicy = &ownership.Policy{Registry: registry}
 
 	if _, err := pb.ProcessDinghyfile("myorg", "myrepo", "the/full/path", "mybranch", "pusher"); err != nil {
 		t.Fail()
 	}
+	// the application is claimed once applied
+	assert.Equal(t, ownership.Owner{Org: "myorg", Repo: "myrepo", Path: "the/full/path"}, registry["biff"])
+}
+
+// ownerRegistry keeps the owners of applications in memory
+type ownerRegistry map[string]ownership.Owner
+
+func (r ownerRegistry) GetOwner(application string) (*ownership.Owner, error) {
+	if owner, ok := r[application]; ok {
+		return &owner, nil
+	}
+	return nil, nil
+}
+
+func (r ownerRegistry) ClaimOwner(application string, owner ownership.Owner) (*ownership.Owner, error) {
+	if _, ok := r[application]; !ok {
+		r[application] = owner
+	}
+	return r.GetOwner(application)
+}
+
+func (r ownerRegistry) SetOwner(application string, owner ownership.Owner) error {
+	r[application] = owner
+	return nil
+}
+
+func TestProcessDinghyfileNotOwner(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	rendered := `{"application":"biff"}`
+
+	renderer := NewMockParser(ctrl)
+	renderer.EXPECT().Parse(gomock.Eq("myorg"), gomock.Eq("myrepo"), gomock.Eq("the/full/path"), gomock.Eq("mybranch"), gomock.Any()).Return(bytes.NewBuffer([]byte(rendered)), nil).Times(1)
+
+	client := NewMockPlankClient(ctrl)
+	client.EXPECT().GetApplicationNotifications(gomock.Eq("biff"), "").Return(nil, errors.New("not found")).Times(1)
+
+	logger := mock.NewMockDinghyLog(ctrl)
+	logger.EXPECT().Infof(gomock.Eq("Unmarshalled: %v"), gomock.Any()).Times(1)
+	logger.EXPECT().Infof(gomock.Eq("Dinghyfile struct: %v"), gomock.Any()).Times(1)
+	logger.EXPECT().Infof(gomock.Eq("Updated: %s"), gomock.Any()).Times(1)
+	logger.EXPECT().Infof(gomock.Eq("Compiled: %s"), gomock.Any()).Times(1)
+	logger.EXPECT().Info(gomock.Eq("Validations for stage refs were successful")).Times(1)
+	logger.EXPECT().Info(gomock.Eq("Validations for app notifications were successful")).Times(1)
+	logger.EXPECT().Errorf(gomock.Eq("Ownership check failed for %s: %s"), gomock.Any()).Times(1)
+
+	// Never gets to Spinnaker because another repo owns the application
+	client.EXPECT().GetApplication(gomock.Any(), gomock.Any()).Times(0)
+	pb := testPipelineBuilder()
+	pb.Parser = renderer
+	pb.Client = client
+	pb.Logger = logger
+	pb.OwnershipPolicy = &ownership.Policy{
+		Allowed: map[string][]string{"biff": {"myorg/otherrepo"}},
+	}
+
+	_, err := pb.ProcessDinghyfile("myorg", "myrepo", "the/full/path", "mybranch", "pusher")
+	assert.IsType(t, &ownership.NotOwnerError{}, err)
 }
 
 func TestProcessDinghyfileValidate(t *testing.T) {
@@ -115,7 +178,7 @@ func TestUpdateApplication(t *testing.T) {
 	defer ctrl.Finish()
 
 	existingPipeline := plank.Pipeline{Name: "ExistingPipeline", ID: "ExistingID", Application: "testapp", Locked: &plank.PipelineLockType{true, true}}
-	deletedPipeline := plank.Pipeline{Name: "DeletedPipeline", ID: "DeletedID", Application: "testapp"}
+	deletedPipeline := marked(plank.Pipeline{Name: "DeletedPipeline", ID: "DeletedID", Application: "testapp"}, testSource)
 	newPipeline := plank.Pipeline{Name: "NewPipeline", ID: "NewID", Locked: &plank.PipelineLockType{true, true}}
 
 	existing := []plank.Pipeline{existingPipeline, deletedPipeline}
@@ -126,8 +189,8 @@ func TestUpdateApplication(t *testing.T) {
 	client := NewMockPlankClient(ctrl)
 	client.EXPECT().GetApplication("testapp", "").Return(nil, nil).Times(1)
 	client.EXPECT().GetPipelines(gomock.Eq("testapp"), "").Return(existing, nil).Times(1)
-	client.EXPECT().UpsertPipeline(gomock.Eq(existingPipeline), gomock.Eq(existingPipeline.ID), "").Return(nil).Times(1)
-	client.EXPECT().UpsertPipeline(gomock.Eq(newPipeline), gomock.Eq(newPipeline.ID), "").Return(errors.New("upsert fail test")).Times(1)
+	client.EXPECT().UpsertPipeline(gomock.Eq(marked(existingPipeline, testSource)), gomock.Eq(existingPipeline.ID), "").Return(nil).Times(1)
+	client.EXPECT().UpsertPipeline(gomock.Eq(marked(newPipeline, testSource)), gomock.Eq(newPipeline.ID), "").Return(errors.New("upsert fail test")).Times(1)
 	// Should not get called at all, because of earlier error
 	client.EXPECT().DeletePipeline(gomock.Eq(deletedPipeline), "").Times(0)
 	client.EXPECT().UpdateApplication(gomock.Eq(*testapp), "").Return(nil).Times(1)
@@ -146,7 +209,7 @@ func TestUpdateApplication(t *testing.T) {
 		DeleteStalePipelines: true,
 	}
 
-	err := b.updatePipelines(dinghyfile, "pusher")
+	_, _, err := b.updatePipelines(dinghyfile, testSource, "pusher", nil)
 	assert.NotNil(t, err)
 	assert.Equal(t, "upsert fail test", err.Error())
 }
@@ -217,10 +280,14 @@ func TestProcessDinghyfileFailedUpdate(t *testing.T) {
 	pb.Logger = logger
 	pb.Parser = renderer
 	pb.Client = client
+	registry := ownerRegistry{}
+	pb.OwnershipPolicy = &ownership.Policy{Registry: registry}
 	dinghyfileParsed, res := pb.ProcessDinghyfile("myorg", "myrepo", "the/full/path", "mybranch", "pusher")
 	assert.Equal(t, rendered, dinghyfileParsed)
 	assert.NotNil(t, res)
 	assert.Equal(t, "boom", res.Error())
+	// nothing was applied, the application stays unclaimed
+	assert.Empty(t, registry)
 }
 
 func TestProcessDinghyfileFailedValidation(t *testing.T) {
@@ -848,6 +915,64 @@ func TestUpdateDinghyfileMalformed(t *testing.T) {
 	assert.NotNil(t, df)
 }
 
+func TestUpdateDinghyfilePipelineKeys(t *testing.T) {
+	b := testPipelineBuilder()
+
+	df, err := b.UpdateDinghyfile([]byte(`{
+		"application": "testapp",
+		"pipelines": [
+			{"name": "deploy", "dinghyId": "deploy-key"},
+			{"name": "manual"}
+		]
+	}`))
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"deploy": "deploy-key"}, df.PipelineKeys)
+
+	_, err = b.UpdateDinghyfile([]byte(`{
+		"application": "testapp",
+		"pipelines": [
+			{"name": "deploy", "dinghyId": "deploy-key"},
+			{"name": "deploy-copy", "dinghyId": "deploy-key"}
+		]
+	}`))
+	assert.EqualError(t, err, "dinghyId deploy-key is used by pipelines deploy and deploy-copy")
+}
+
+func TestUpdatePipelinesRenameWithDinghyID(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	oldPipeline := plank.Pipeline{Name: "OldName", ID: "StableID", Application: "testapp"}
+	newPipeline := plank.Pipeline{Name: "NewName", Application: "testapp"}
+	renamedPipeline := plank.Pipeline{Name: "NewName", ID: "StableID", Application: "testapp"}
+	testapp := &plank.Application{Name: "testapp"}
+
+	client := NewMockPlankClient(ctrl)
+	client.EXPECT().GetApplication("testapp", "").Return(nil, nil).Times(1)
+	client.EXPECT().GetPipelines(gomock.Eq("testapp"), "").Return([]plank.Pipeline{oldPipeline}, nil).Times(1)
+	client.EXPECT().GetPipelines(gomock.Eq("testapp"), "").Return([]plank.Pipeline{renamedPipeline}, nil).Times(1)
+	client.EXPECT().UpsertPipeline(gomock.Eq(marked(renamedPipeline, testSource)), gomock.Eq("StableID"), "").Return(nil).Times(1)
+	client.EXPECT().DeletePipeline(gomock.Any(), gomock.Any()).Times(0)
+
+	b := testPipelineBuilder()
+	b.Client = client
+	b.Managed = memoryStore{}
+
+	dinghyfile := Dinghyfile{
+		ApplicationSpec:      *testapp,
+		Pipelines:            []plank.Pipeline{newPipeline},
+		PipelineKeys:         map[string]string{"NewName": "deploy-key"},
+		DeleteStalePipelines: true,
+	}
+	previous := &managed.Dinghyfile{
+		Application: "testapp",
+		Pipelines:   []managed.Pipeline{{Name: "OldName", DinghyID: "deploy-key"}},
+	}
+
+	_, _, err := b.updatePipelines(dinghyfile, testSource, "pusher", previous)
+	assert.Nil(t, err)
+}
+
 func TestDetermineRenderer(t *testing.T) {
 	// TODO:  Currently this will ALWAYS return a DinghyfileParser; when we
 	//        support additional types, we'll need to add those tests here.
@@ -929,7 +1054,7 @@ func TestUpdatePipelinesDeleteStaleWithExisting(t *testing.T) {
 	defer ctrl.Finish()
 
 	existingPipeline := plank.Pipeline{Name: "ExistingPipeline", ID: "ExistingID", Application: "testapp", Locked: &plank.PipelineLockType{true, true}}
-	deletedPipeline := plank.Pipeline{Name: "DeletedPipeline", ID: "DeletedID", Application: "testapp"}
+	deletedPipeline := marked(plank.Pipeline{Name: "DeletedPipeline", ID: "DeletedID", Application: "testapp"}, testSource)
 	newPipeline := plank.Pipeline{Name: "NewPipeline", ID: "NewID", Locked: &plank.PipelineLockType{true, true}}
 
 	existing := []plank.Pipeline{existingPipeline, deletedPipeline}
@@ -942,8 +1067,8 @@ func TestUpdatePipelinesDeleteStaleWithExisting(t *testing.T) {
 	client.EXPECT().GetApplication("testapp", "").Return(nil, nil).Times(1)
 	client.EXPECT().GetPipelines(gomock.Eq("testapp"), "").Return(existing, nil).Times(1)
 	client.EXPECT().GetPipelines(gomock.Eq("testapp"), "").Return(combined, nil).Times(1)
-	client.EXPECT().UpsertPipeline(gomock.Eq(existingPipeline), gomock.Eq(existingPipeline.ID), "").Return(nil).Times(1)
-	client.EXPECT().UpsertPipeline(gomock.Eq(newPipeline), gomock.Eq(newPipeline.ID), "").Return(nil).Times(1)
+	client.EXPECT().UpsertPipeline(gomock.Eq(marked(existingPipeline, testSource)), gomock.Eq(existingPipeline.ID), "").Return(nil).Times(1)
+	client.EXPECT().UpsertPipeline(gomock.Eq(marked(newPipeline, testSource)), gomock.Eq(newPipeline.ID), "").Return(nil).Times(1)
 	client.EXPECT().DeletePipeline(gomock.Eq(deletedPipeline), "").Return(errors.New("fake delete failure")).Times(1)
 
 	b := testPipelineBuilder()
@@ -956,8 +1081,83 @@ func TestUpdatePipelinesDeleteStaleWithExisting(t *testing.T) {
 		DeleteStalePipelines: true,
 	}
 
-	err := b.updatePipelines(dinghyfile, "pusher")
+	applied, _, err := b.updatePipelines(dinghyfile, testSource, "pusher", nil)
 	assert.Nil(t, err)
+	assert.Equal(t, []plank.Pipeline{marked(existingPipeline, testSource), marked(newPipeline, testSource)}, applied)
+}
+
+func TestUpdatePipelinesDeleteStaleOnlyMarked(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	existingPipeline := plank.Pipeline{Name: "ExistingPipeline", ID: "ExistingID", Application: "testapp"}
+	stalePipeline := marked(plank.Pipeline{Name: "StalePipeline", ID: "StaleID", Application: "testapp"}, testSource)
+	manualPipeline := plank.Pipeline{Name: "ManualPipeline", ID: "ManualID", Application: "testapp", Description: "created by hand"}
+	otherSource := managed.Marker{Org: "org", Repo: "repo", Path: "other/dinghyfile"}
+	otherPipeline := marked(plank.Pipeline{Name: "OtherPipeline", ID: "OtherID", Application: "testapp"}, otherSource)
+
+	existing := []plank.Pipeline{marked(existingPipeline, testSource), stalePipeline, manualPipeline, otherPipeline}
+	testapp := &plank.Application{Name: "testapp"}
+
+	client := NewMockPlankClient(ctrl)
+	client.EXPECT().GetApplication("testapp", "").Return(nil, nil).Times(1)
+	client.EXPECT().GetPipelines(gomock.Eq("testapp"), "").Return(existing, nil).Times(2)
+	client.EXPECT().UpsertPipeline(gomock.Eq(marked(existingPipeline, testSource)), gomock.Eq(existingPipeline.ID), "").Return(nil).Times(1)
+	// ManualPipeline carries no marker and OtherPipeline belongs to another
+	// dinghyfile, so both are kept even without a managed store
+	client.EXPECT().DeletePipeline(gomock.Eq(stalePipeline), "").Return(nil).Times(1)
+
+	b := testPipelineBuilder()
+	b.Client = client
+
+	dinghyfile := Dinghyfile{
+		ApplicationSpec:      *testapp,
+		Pipelines:            []plank.Pipeline{existingPipeline},
+		DeleteStalePipelines: true,
+	}
+
+	_, _, err := b.updatePipelines(dinghyfile, testSource, "pusher", nil)
+	assert.Nil(t, err)
+}
+
+func TestUpdatePipelinesDeleteStaleDryRun(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	existingPipeline := plank.Pipeline{Name: "ExistingPipeline", ID: "ExistingID", Application: "testapp"}
+	stalePipeline := marked(plank.Pipeline{Name: "StalePipeline", ID: "StaleID", Application: "testapp"}, testSource)
+	testapp := &plank.Application{Name: "testapp"}
+
+	client := NewMockPlankClient(ctrl)
+	client.EXPECT().GetApplication("testapp", "").Return(nil, nil).Times(1)
+	client.EXPECT().GetPipelines(gomock.Eq("testapp"), "").Return([]plank.Pipeline{existingPipeline, stalePipeline}, nil).Times(2)
+	client.EXPECT().UpsertPipeline(gomock.Any(), gomock.Eq(existingPipeline.ID), "").Return(nil).Times(1)
+	client.EXPECT().DeletePipeline(gomock.Any(), gomock.Any()).Times(0)
+
+	store := memoryStore{}
+	b := testPipelineBuilder()
+	b.Client = client
+	b.Managed = store
+	b.StalePipelinesDryRun = true
+	b.Commit = "abc123"
+
+	dinghyfile := Dinghyfile{
+		ApplicationSpec:      *testapp,
+		Pipelines:            []plank.Pipeline{existingPipeline},
+		DeleteStalePipelines: true,
+	}
+
+	_, stale, err := b.updatePipelines(dinghyfile, testSource, "pusher", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"StalePipeline"}, stale)
+
+	// the pipeline kept by the dry run is recorded apart from the managed ones
+	url := b.Downloader.EncodeURL("org", "repo", "dinghyfile", "master")
+	b.recordManaged(url, "org", "repo", "dinghyfile", dinghyfile, stale)
+	recorded := store[url]
+	assert.Equal(t, "abc123", recorded.Commit)
+	assert.Equal(t, []managed.Pipeline{{Name: "ExistingPipeline"}}, recorded.Pipelines)
+	assert.Equal(t, []managed.Pipeline{{Name: "StalePipeline"}}, recorded.Stale)
 }
 
 func TestUpdatePipelinesNoDeleteStaleWithExisting(t *testing.T) {
@@ -965,7 +1165,7 @@ func TestUpdatePipelinesNoDeleteStaleWithExisting(t *testing.T) {
 	defer ctrl.Finish()
 
 	existingPipeline := plank.Pipeline{Name: "ExistingPipeline", ID: "ExistingID", Application: "testapp", Locked: &plank.PipelineLockType{true, true}}
-	deletedPipeline := plank.Pipeline{Name: "DeletedPipeline", ID: "DeletedID", Application: "testapp"}
+	deletedPipeline := marked(plank.Pipeline{Name: "DeletedPipeline", ID: "DeletedID", Application: "testapp"}, testSource)
 	newPipeline := plank.Pipeline{Name: "NewPipeline", ID: "NewID", Locked: &plank.PipelineLockType{true, true}}
 
 	existing := []plank.Pipeline{existingPipeline, deletedPipeline}
@@ -976,8 +1176,8 @@ func TestUpdatePipelinesNoDeleteStaleWithExisting(t *testing.T) {
 	client := NewMockPlankClient(ctrl)
 	client.EXPECT().GetApplication("testapp", "").Return(nil, nil).Times(1)
 	client.EXPECT().GetPipelines(gomock.Eq("testapp"), "").Return(existing, nil).Times(1)
-	client.EXPECT().UpsertPipeline(gomock.Eq(existingPipeline), gomock.Eq(existingPipeline.ID), "").Return(nil).Times(1)
-	client.EXPECT().UpsertPipeline(gomock.Eq(newPipeline), gomock.Eq(newPipeline.ID), "").Return(nil).Times(1)
+	client.EXPECT().UpsertPipeline(gomock.Eq(marked(existingPipeline, testSource)), gomock.Eq(existingPipeline.ID), "").Return(nil).Times(1)
+	client.EXPECT().UpsertPipeline(gomock.Eq(marked(newPipeline, testSource)), gomock.Eq(newPipeline.ID), "").Return(nil).Times(1)
 	client.EXPECT().DeletePipeline(gomock.Eq(deletedPipeline), "").Return(errors.New("fake delete failure")).Times(0)
 
 	b := testPipelineBuilder()
@@ -990,7 +1190,7 @@ func TestUpdatePipelinesNoDeleteStaleWithExisting(t *testing.T) {
 		DeleteStalePipelines: false,
 	}
 
-	err := b.updatePipelines(dinghyfile, "pusher")
+	_, _, err := b.updatePipelines(dinghyfile, testSource, "pusher", nil)
 	assert.Nil(t, err)
 }
 
@@ -999,7 +1199,7 @@ func TestUpdatePipelinesDeleteStaleWithFailure(t *testing.T) {
 	defer ctrl.Finish()
 
 	existingPipeline := plank.Pipeline{Name: "ExistingPipeline", ID: "ExistingID", Application: "testapp", Locked: &plank.PipelineLockType{true, true}}
-	deletedPipeline := plank.Pipeline{Name: "DeletedPipeline", ID: "DeletedID", Application: "testapp"}
+	deletedPipeline := marked(plank.Pipeline{Name: "DeletedPipeline", ID: "DeletedID", Application: "testapp"}, testSource)
 	newPipeline := plank.Pipeline{Name: "NewPipeline", ID: "NewID", Locked: &plank.PipelineLockType{true, true}}
 
 	existing := []plank.Pipeline{existingPipeline, deletedPipeline}
@@ -1011,8 +1211,8 @@ func TestUpdatePipelinesDeleteStaleWithFailure(t *testing.T) {
 	client.EXPECT().GetApplication("testapp", "").Return(nil, nil).Times(1)
 	client.EXPECT().GetPipelines(gomock.Eq("testapp"), "").Return(existing, nil).Times(1)
 	client.EXPECT().GetPipelines(gomock.Eq("testapp"), "").Return(nil, errors.New("failure to retrieve")).Times(1)
-	client.EXPECT().UpsertPipeline(gomock.Eq(existingPipeline), gomock.Eq(existingPipeline.ID), "").Return(nil).Times(1)
-	client.EXPECT().UpsertPipeline(gomock.Eq(newPipeline), gomock.Eq(newPipeline.ID), "").Return(nil).Times(1)
+	client.EXPECT().UpsertPipeline(gomock.Eq(marked(existingPipeline, testSource)), gomock.Eq(existingPipeline.ID), "").Return(nil).Times(1)
+	client.EXPECT().UpsertPipeline(gomock.Eq(marked(newPipeline, testSource)), gomock.Eq(newPipeline.ID), "").Return(nil).Times(1)
 	// Should not be called because we couldn't re-retrieve the combined list.
 	client.EXPECT().DeletePipeline(gomock.Eq(deletedPipeline), "").Return(errors.New("fake delete failure")).Times(0)
 
@@ -1026,7 +1226,7 @@ func TestUpdatePipelinesDeleteStaleWithFailure(t *testing.T) {
 		DeleteStalePipelines: true,
 	}
 
-	err := b.updatePipelines(dinghyfile, "true")
+	_, _, err := b.updatePipelines(dinghyfile, testSource, "true", nil)
 	assert.Nil(t, err)
 }
 
@@ -1035,7 +1235,7 @@ func TestUpdatePipelinesUpsertFail(t *testing.T) {
 	defer ctrl.Finish()
 
 	existingPipeline := plank.Pipeline{Name: "ExistingPipeline", ID: "ExistingID", Application: "testapp", Locked: &plank.PipelineLockType{true, true}}
-	deletedPipeline := plank.Pipeline{Name: "DeletedPipeline", ID: "DeletedID", Application: "testapp"}
+	deletedPipeline := marked(plank.Pipeline{Name: "DeletedPipeline", ID: "DeletedID", Application: "testapp"}, testSource)
 	newPipeline := plank.Pipeline{Name: "NewPipeline", ID: "NewID", Locked: &plank.PipelineLockType{true, true}}
 
 	existing := []plank.Pipeline{existingPipeline, deletedPipeline}
@@ -1046,8 +1246,8 @@ func TestUpdatePipelinesUpsertFail(t *testing.T) {
 	client := NewMockPlankClient(ctrl)
 	client.EXPECT().GetApplication("testapp", "").Return(nil, nil).Times(1)
 	client.EXPECT().GetPipelines(gomock.Eq("testapp"), "").Return(existing, nil).Times(1)
-	client.EXPECT().UpsertPipeline(gomock.Eq(existingPipeline), gomock.Eq(existingPipeline.ID), "").Return(nil).Times(1)
-	client.EXPECT().UpsertPipeline(gomock.Eq(newPipeline), gomock.Eq(newPipeline.ID), "").Return(errors.New("upsert fail test")).Times(1)
+	client.EXPECT().UpsertPipeline(gomock.Eq(marked(existingPipeline, testSource)), gomock.Eq(existingPipeline.ID), "").Return(nil).Times(1)
+	client.EXPECT().UpsertPipeline(gomock.Eq(marked(newPipeline, testSource)), gomock.Eq(newPipeline.ID), "").Return(errors.New("upsert fail test")).Times(1)
 	// Should not get called at all, because of earlier error
 	client.EXPECT().DeletePipeline(gomock.Eq(deletedPipeline), "").Times(0)
 
@@ -1060,7 +1260,7 @@ func TestUpdatePipelinesUpsertFail(t *testing.T) {
 		Pipelines:            newPipelines,
 		DeleteStalePipelines: true,
 	}
-	err := b.updatePipelines(dinghyfile, "true")
+	_, _, err := b.updatePipelines(dinghyfile, testSource, "true", nil)
 	assert.NotNil(t, err)
 	assert.Equal(t, "upsert fail test", err.Error())
 }
@@ -1081,7 +1281,7 @@ func TestUpdatePipelinesRespectsAutoLockOn(t *testing.T) {
 	client := NewMockPlankClient(ctrl)
 	client.EXPECT().GetApplication("testapp", "").Return(nil, nil).Times(1)
 	client.EXPECT().GetPipelines(gomock.Eq("testapp"), "").Return([]plank.Pipeline{}, nil).Times(1)
-	client.EXPECT().UpsertPipeline(gomock.Eq(expectedPipeline), gomock.Eq(newPipeline.ID), "").Return(nil).Times(1)
+	client.EXPECT().UpsertPipeline(gomock.Eq(marked(expectedPipeline, testSource)), gomock.Eq(newPipeline.ID), "").Return(nil).Times(1)
 
 	b := testPipelineBuilder()
 	b.Client = client
@@ -1092,7 +1292,7 @@ func TestUpdatePipelinesRespectsAutoLockOn(t *testing.T) {
 		Pipelines:            []plank.Pipeline{newPipeline},
 		DeleteStalePipelines: false,
 	}
-	err := b.updatePipelines(dinghyfile, "true")
+	_, _, err := b.updatePipelines(dinghyfile, testSource, "true", nil)
 	assert.Nil(t, err)
 }
 
@@ -1112,7 +1312,7 @@ func TestUpdatePipelinesRespectsAutoLockOff(t *testing.T) {
 	client := NewMockPlankClient(ctrl)
 	client.EXPECT().GetApplication("testapp", "").Return(nil, nil).Times(1)
 	client.EXPECT().GetPipelines(gomock.Eq("testapp"), "").Return([]plank.Pipeline{}, nil).Times(1)
-	client.EXPECT().UpsertPipeline(gomock.Eq(expectedPipeline), gomock.Eq(newPipeline.ID), "").Return(nil).Times(1)
+	client.EXPECT().UpsertPipeline(gomock.Eq(marked(expectedPipeline, testSource)), gomock.Eq(newPipeline.ID), "").Return(nil).Times(1)
 
 	b := testPipelineBuilder()
 	b.Client = client
@@ -1122,7 +1322,7 @@ func TestUpdatePipelinesRespectsAutoLockOff(t *testing.T) {
 		Pipelines:            []plank.Pipeline{newPipeline},
 		DeleteStalePipelines: false,
 	}
-	err := b.updatePipelines(dinghyfile, "")
+	_, _, err := b.updatePipelines(dinghyfile, testSource, "", nil)
 	assert.Nil(t, err)
 }
 
@@ -1242,6 +1442,7 @@ type mockNotifier struct {
 	SuccessCalls int
 	FailureCalls int
 	LastError    error
+	LastContent  map[string]interface{}
 }
 
 func (m *mockNotifier) SendSuccess(org, repo, path string, notificationsType plank.NotificationsType, content map[string]interface{}) {
@@ -1250,6 +1451,7 @@ func (m *mockNotifier) SendSuccess(org, repo, path string, notificationsType pla
 func (m *mockNotifier) SendFailure(org, repo, path string, err error, notificationsType plank.NotificationsType, content map[string]interface{}) {
 	m.FailureCalls = m.FailureCalls + 1
 	m.LastError = err
+	m.LastContent = content
 }
 
 func (m *mockNotifier) SendOnValidation() bool {
@@ -1260,7 +1462,7 @@ func TestSuccessNotifier(t *testing.T) {
 	b := testPipelineBuilder()
 	n := mockNotifier{}
 	b.Notifiers = []notifiers.Notifier{&n}
-	b.NotifySuccess("foo", "bar", "biff", nil)
+	b.NotifySuccess("foo", "bar", "biff", "app", nil)
 	assert.Equal(t, n.SuccessCalls, 1)
 	assert.Equal(t, n.FailureCalls, 0)
 }
@@ -1275,6 +1477,30 @@ func TestFailureNotifier(t *testing.T) {
 	assert.Equal(t, n.LastError.Error(), "foo")
 }
 
+func TestFailureNotifierContent(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	client := NewMockPlankClient(ctrl)
+	client.EXPECT().GetApplicationNotifications("foo", "").Return(nil, errors.New("not found")).Times(2)
+
+	b := testPipelineBuilder()
+	b.Client = client
+	b.Ums = []Unmarshaller{&DinghyJsonUnmarshaller{}}
+	n := mockNotifier{}
+	b.Notifiers = []notifiers.Notifier{&n}
+
+	dinghyfile := `{"application": "foo", "spec": {"email": "owners@example.com"}, "pipelines": []}`
+	b.NotifyFailure("org", "repo", "dinghyfile", errors.New("foo"), dinghyfile)
+	assert.Equal(t, "foo", n.LastContent[notifiers.ApplicationContent])
+	assert.Equal(t, dinghyfile, n.LastContent[notifiers.DinghyfileContent])
+	assert.Equal(t, "owners@example.com", n.LastContent[notifiers.ApplicationEmailContent])
+
+	b.NotifyFailure("org", "repo", "dinghyfile", errors.New("foo"), `{"application": "foo", `)
+	assert.Equal(t, `{"application": "foo", `, n.LastContent[notifiers.DinghyfileContent])
+	assert.NotContains(t, n.LastContent, notifiers.ApplicationEmailContent)
+}
+
 func Test_extractApplicationName(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -1356,11 +1582,14 @@ func TestPipelineBuilder_getContent(t *testing.T) {
 		RepositoryRawdataProcessing bool
 		RebuildingModules           bool
 		Action                      pipebuilder.BuilderAction
+		Commit                      string
+		Pusher                      string
 	}
 	tests := []struct {
-		name   string
-		fields fields
-		want   map[string]interface{}
+		name        string
+		fields      fields
+		application string
+		want        map[string]interface{}
 	}{
 		{
 			name: "Content should return a map populated with 'raw' property if no Log Events are available",
@@ -1405,6 +1634,30 @@ func TestPipelineBuilder_getContent(t *testing.T) {
 				"logevent": "test",
 			},
 		},
+		{
+			name: "Content should return a map populated with 'commit', 'pusher' and 'application' properties, since all are known.",
+			fields: fields{
+				Logger: NewDinghylog(),
+				Commit: "a5fc63bd5a8bdb342d1e83933a5b5c99010e61e4",
+				Pusher: "octocat",
+			},
+			application: "testapp",
+			want: map[string]interface{}{
+				"commit":      "a5fc63bd5a8bdb342d1e83933a5b5c99010e61e4",
+				"pusher":      "octocat",
+				"application": "testapp",
+			},
+		},
+		{
+			name: "Content should return a map populated with 'validation' property, since a pull request is validated.",
+			fields: fields{
+				Logger: NewDinghylog(),
+				Action: pipebuilder.Validate,
+			},
+			want: map[string]interface{}{
+				"validation": true,
+			},
+		},
 		{
 			name: "Content should return a empty map, since no properties are populated.",
 			fields: fields{
@@ -1434,8 +1687,10 @@ func TestPipelineBuilder_getContent(t *testing.T) {
 				RepositoryRawdataProcessing: tt.fields.RepositoryRawdataProcessing,
 				RebuildingModules:           tt.fields.RebuildingModules,
 				Action:                      tt.fields.Action,
+				Commit:                      tt.fields.Commit,
+				Pusher:                      tt.fields.Pusher,
 			}
-			if got := b.getNotificationContent(); !reflect.DeepEqual(got, tt.want) {
+			if got := b.getNotificationContent(tt.application); !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("getNotificationContent() = %v, want %v", got, tt.want)
 			}
 		})
@@ -0,0 +1,126 @@
+/*
+* Copyright 2026 Armory, Inc.
+
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+
+*    http://www.apache.org/licenses/LICENSE-2.0
+
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package dinghyfile
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/armory/dinghy/pkg/history"
+	"github.com/armory/dinghy/pkg/managed"
+	"github.com/armory/plank/v4"
+)
+
+// saveRender records a successfully applied render and the pipelines it
+// upserted under a new id, with the commit being processed. Renders without
+// a commit, like manual updates, are skipped.
+func (b *PipelineBuilder) saveRender(url, org, repo, path, branch, pusher string, d Dinghyfile, rendered string, applied []plank.Pipeline) {
+	if b.Commit == "" {
+		b.Logger.Infof("No commit to record the render of %s with, skipping history", path)
+		return
+	}
+	date := time.Now().UnixNano() / int64(time.Millisecond)
+	b.recordRender(history.Render{
+		ID:          history.NewID(date, b.Commit, url),
+		Application: strings.ToLower(d.ApplicationSpec.Name),
+		Commit:      b.Commit,
+		URL:         url,
+		Org:         org,
+		Repo:        repo,
+		Path:        path,
+		Branch:      branch,
+		Pusher:      pusher,
+		Date:        date,
+		Dinghyfile:  rendered,
+	}, applied)
+}
+
+// saveRollback records a render applied again as the newest render of its
+// application, so what is live is the latest render
+func (b *PipelineBuilder) saveRollback(r history.Render, pusher string, applied []plank.Pipeline) {
+	date := time.Now().UnixNano() / int64(time.Millisecond)
+	r.RollbackOf = r.ID
+	r.ID = history.NewID(date, r.Commit, r.URL)
+	r.Pusher = pusher
+	r.Date = date
+	r.Pipelines = nil
+	b.recordRender(r, applied)
+}
+
+// recordRender saves r with the pipelines applied and prunes the older
+// renders of its application
+func (b *PipelineBuilder) recordRender(r history.Render, applied []plank.Pipeline) {
+	if pipelines, err := json.Marshal(applied); err == nil && len(applied) > 0 {
+		r.Pipelines = pipelines
+	}
+	if err := b.History.SaveRender(r); err != nil {
+		b.Logger.Warnf("Could not record the render of %s: %s", r.Path, err.Error())
+		return
+	}
+	limit := b.RenderHistoryLimit
+	if limit <= 0 {
+		limit = history.DefaultLimit
+	}
+	if err := b.History.PruneRenders(r.Application, limit); err != nil {
+		b.Logger.Warnf("Could not prune the renders of %s: %s", r.Application, err.Error())
+	}
+}
+
+// ApplyRender applies a previously recorded render again, without downloading
+// or rendering anything. It goes through the same checks as a push, and is
+// recorded as a new render when the builder keeps history.
+func (b *PipelineBuilder) ApplyRender(r history.Render, pusher string) error {
+	d, err := b.UpdateDinghyfile([]byte(r.Dinghyfile))
+	if err != nil {
+		b.Logger.Errorf("Failed to parse the render of %s at %s: %s", r.Application, r.Commit, err.Error())
+		b.NotifyFailure(r.Org, r.Repo, r.Path, err, r.Dinghyfile)
+		return err
+	}
+	if err := b.checkDinghyfile(r.Org, r.Repo, r.Path, d, []byte(r.Dinghyfile)); err != nil {
+		b.NotifyFailure(r.Org, r.Repo, r.Path, err, r.Dinghyfile)
+		return err
+	}
+
+	// renders recorded without their url can't update the managed pipelines
+	url := r.URL
+	if url == "" && b.Managed != nil {
+		b.Logger.Warnf("The render of %s at %s has no dinghyfile url, managed pipelines won't be updated", r.Application, r.Commit)
+	}
+	previous := b.previouslyManaged(url)
+	source := managed.Marker{URL: url, Org: r.Org, Repo: r.Repo, Path: r.Path}
+	applied, stale, err := b.updatePipelines(d, source, pusher, previous)
+	if err != nil {
+		b.Logger.Errorf("Failed to apply the render of %s at %s: %s", r.Application, r.Commit, err.Error())
+		b.NotifyFailure(r.Org, r.Repo, r.Path, err, r.Dinghyfile)
+		return err
+	}
+	if err := b.claimOwnership(r.Org, r.Repo, r.Path, d.ApplicationSpec.Name); err != nil {
+		b.NotifyFailure(r.Org, r.Repo, r.Path, err, r.Dinghyfile)
+		return err
+	}
+	if b.Managed != nil && url != "" {
+		b.recordManaged(url, r.Org, r.Repo, r.Path, d, stale)
+	}
+	if b.History != nil {
+		b.saveRollback(r, pusher, applied)
+	}
+
+	b.Logger.Infof("Applied the render of %s at %s", r.Application, r.Commit)
+	b.NotifySuccess(r.Org, r.Repo, r.Path, d.ApplicationSpec.Name, d.ApplicationSpec.Notifications)
+	return nil
+}
@@ -0,0 +1,184 @@
+/*
+* Copyright 2026 Armory, Inc.
+
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+
+*    http://www.apache.org/licenses/LICENSE-2.0
+
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package dinghyfile
+
+import (
+	"testing"
+	"time"
+
+	"github.com/armory/dinghy/pkg/history"
+	"github.com/armory/dinghy/pkg/managed"
+	"github.com/armory/dinghy/pkg/ownership"
+	"github.com/armory/plank/v4"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+type memoryHistory []history.Render
+
+func (m *memoryHistory) SaveRender(r history.Render) error {
+	*m = append(*m, r)
+	return nil
+}
+
+func (m *memoryHistory) ListRenders(application string) ([]history.Render, error) {
+	return *m, nil
+}
+
+func (m *memoryHistory) GetRender(application, id string) (*history.Render, error) {
+	for _, r := range *m {
+		if r.Application == application && r.ID == id {
+			return &r, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *memoryHistory) PruneRenders(application string, keep int) error {
+	if len(*m) > keep {
+		*m = (*m)[len(*m)-keep:]
+	}
+	return nil
+}
+
+func TestSaveRender(t *testing.T) {
+	store := &memoryHistory{}
+	b := testPipelineBuilder()
+	b.History = store
+	d := Dinghyfile{ApplicationSpec: plank.Application{Name: "TestApp"}}
+
+	// nothing to key the render with
+	b.saveRender("url", "org", "repo", "dinghyfile", "master", "pusher", d, "{}", nil)
+	assert.Empty(t, *store)
+
+	b.Commit = "abc123"
+	b.saveRender("url", "org", "repo", "dinghyfile", "master", "pusher", d, "{}", []plank.Pipeline{{Name: "first", ID: "firstID"}})
+	assert.Len(t, *store, 1)
+	r := (*store)[0]
+	assert.Equal(t, "testapp", r.Application)
+	assert.Equal(t, "abc123", r.Commit)
+	assert.Equal(t, "url", r.URL)
+	assert.Equal(t, "dinghyfile", r.Path)
+	assert.Equal(t, "pusher", r.Pusher)
+	assert.Equal(t, "{}", r.Dinghyfile)
+	assert.Contains(t, string(r.Pipelines), `"id":"firstID"`)
+	assert.NotZero(t, r.Date)
+	assert.Equal(t, history.NewID(r.Date, "abc123", "url"), r.ID)
+
+	// applying the commit again, or another dinghyfile of the application,
+	// records new renders
+	time.Sleep(2 * time.Millisecond)
+	b.saveRender("url", "org", "repo", "dinghyfile", "master", "pusher", d, "{}", nil)
+	b.saveRender("other", "org", "repo", "other/dinghyfile", "master", "pusher", d, "{}", nil)
+	assert.Len(t, *store, 3)
+	assert.NotEqual(t, (*store)[0].ID, (*store)[1].ID)
+	assert.NotEqual(t, (*store)[1].ID, (*store)[2].ID)
+}
+
+func TestSaveRenderPrunes(t *testing.T) {
+	store := &memoryHistory{}
+	b := testPipelineBuilder()
+	b.History = store
+	b.RenderHistoryLimit = 2
+	b.Commit = "abc123"
+	d := Dinghyfile{ApplicationSpec: plank.Application{Name: "testapp"}}
+
+	for _, path := range []string{"first", "second", "third"} {
+		b.saveRender(path, "org", "repo", path, "master", "pusher", d, "{}", nil)
+	}
+	assert.Len(t, *store, 2)
+	assert.Equal(t, "second", (*store)[0].Path)
+}
+
+func TestApplyRender(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	pipeline := plank.Pipeline{Name: "first", ID: "firstID", Application: "testapp"}
+
+	client := NewMockPlankClient(ctrl)
+	client.EXPECT().GetApplication("testapp", "").Return(nil, nil).Times(1)
+	client.EXPECT().GetPipelines(gomock.Eq("testapp"), "").Return([]plank.Pipeline{pipeline}, nil).Times(1)
+	client.EXPECT().UpsertPipeline(gomock.Any(), gomock.Eq("firstID"), "").Return(nil).Times(1)
+
+	store := memoryStore{}
+	renders := &memoryHistory{}
+	b := testPipelineBuilder()
+	b.Client = client
+	b.Managed = store
+	b.History = renders
+
+	url := b.Downloader.EncodeURL("org", "repo", "dinghyfile", "master")
+	r := history.Render{
+		ID:          "1-abc123",
+		Application: "testapp",
+		Commit:      "abc123",
+		URL:         url,
+		Org:         "org",
+		Repo:        "repo",
+		Path:        "dinghyfile",
+		Branch:      "master",
+		Dinghyfile:  `{"application": "testapp", "pipelines": [{"name": "first", "application": "testapp"}]}`,
+	}
+	assert.Nil(t, b.ApplyRender(r, "admin"))
+	assert.Equal(t, []managed.Pipeline{{Name: "first"}}, store[url].Pipelines)
+
+	// the rollback is the newest render, what is live
+	if assert.Len(t, *renders, 1) {
+		rollback := (*renders)[0]
+		assert.NotEqual(t, r.ID, rollback.ID)
+		assert.Equal(t, history.NewID(rollback.Date, "abc123", url), rollback.ID)
+		assert.Equal(t, r.ID, rollback.RollbackOf)
+		assert.Equal(t, "abc123", rollback.Commit)
+		assert.Equal(t, "admin", rollback.Pusher)
+		assert.Equal(t, r.Dinghyfile, rollback.Dinghyfile)
+		assert.Contains(t, string(rollback.Pipelines), `"id":"firstID"`)
+	}
+}
+
+func TestApplyRenderOwnership(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// nothing is written to Spinnaker, the failure is notified
+	client := NewMockPlankClient(ctrl)
+	client.EXPECT().GetApplicationNotifications("testapp", "").Return(&plank.NotificationsType{}, nil).AnyTimes()
+	b := testPipelineBuilder()
+	b.Client = client
+	b.OwnershipPolicy = &ownership.Policy{
+		Allowed: map[string][]string{"testapp": {"org/otherrepo"}},
+	}
+
+	r := history.Render{
+		Application: "testapp",
+		Commit:      "abc123",
+		Org:         "org",
+		Repo:        "repo",
+		Path:        "dinghyfile",
+		Dinghyfile:  `{"application": "testapp", "pipelines": [{"name": "first", "application": "testapp"}]}`,
+	}
+	assert.NotNil(t, b.ApplyRender(r, "admin"))
+}
+
+func TestApplyRenderInvalid(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	b := testPipelineBuilder()
+	b.Client = NewMockPlankClient(ctrl)
+	assert.NotNil(t, b.ApplyRender(history.Render{Application: "testapp", Dinghyfile: "{"}, "admin"))
+}
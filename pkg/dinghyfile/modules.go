@@ -0,0 +1,141 @@
+/*
+* Copyright 2026 Armory, Inc.
+
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+
+*    http://www.apache.org/licenses/LICENSE-2.0
+
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package dinghyfile
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/armory/dinghy/pkg/dinghyfile/pipebuilder"
+)
+
+// RootValidation is the outcome of rendering a dinghyfile with the modules
+// of a template repo branch
+type RootValidation struct {
+	URL    string `json:"url"`
+	Org    string `json:"org"`
+	Repo   string `json:"repo"`
+	Path   string `json:"path"`
+	Branch string `json:"branch"`
+	// Modules are the changed modules the dinghyfile uses
+	Modules []string `json:"modules"`
+	Error   string   `json:"error,omitempty"`
+}
+
+func (v RootValidation) Passed() bool {
+	return v.Error == ""
+}
+
+// ValidateModuleRoots renders every dinghyfile using one of modules with the
+// version of the template repo on branch, so module authors know which
+// applications a change would break before merging it. Each dinghyfile is
+// rendered from the branch Dinghy last processed it on.
+//
+// Modules are recorded under the branch of the dinghyfiles using them, so
+// they are looked up on defaultBranch, the default branch of the template
+// repo, and on the branch of every dinghyfile the dependency manager lists.
+func (b *PipelineBuilder) ValidateModuleRoots(org, repo string, modules []string, branch, defaultBranch, pusher string) ([]RootValidation, error) {
+	if b.Action != pipebuilder.Validate {
+		return nil, errors.New("module roots can only be validated by a validating builder")
+	}
+	b.RebuildingModules = true
+	b.ModuleBranch = branch
+	defer func() { b.ModuleBranch = "" }()
+
+	roots := b.moduleRoots(org, repo, modules, defaultBranch)
+	failed := 0
+	for i := range roots {
+		v := &roots[i]
+		b.LoadRawData(v.URL)
+		if _, err := b.ProcessDinghyfile(v.Org, v.Repo, v.Path, v.Branch, pusher); err != nil {
+			v.Error = err.Error()
+			failed++
+		}
+	}
+
+	b.Logger.Infof("Validated %d dinghyfiles using the modules of %s/%s@%s:", len(roots), org, repo, branch)
+	for _, v := range roots {
+		if v.Passed() {
+			b.Logger.Infof("PASS %s", v.URL)
+		} else {
+			b.Logger.Errorf("FAIL %s: %s", v.URL, v.Error)
+		}
+	}
+	if failed > 0 {
+		return roots, fmt.Errorf("%d of %d dinghyfiles using the changed modules failed validation", failed, len(roots))
+	}
+	return roots, nil
+}
+
+// moduleRoots returns the dinghyfiles using any of modules, sorted by URL
+func (b *PipelineBuilder) moduleRoots(org, repo string, modules []string, defaultBranch string) []RootValidation {
+	branches := []string{defaultBranch}
+	seen := map[string]bool{defaultBranch: true}
+	if lister, ok := b.Depman.(RootLister); ok {
+		urls, err := lister.ListRoots()
+		if err != nil {
+			b.Logger.Warnf("Failed to list dinghyfiles, only looking modules up on %s: %s", defaultBranch, err.Error())
+		}
+		for _, url := range urls {
+			if _, _, _, rootBranch, ok := DecodeURL(b.Downloader, url); ok && !seen[rootBranch] {
+				seen[rootBranch] = true
+				branches = append(branches, rootBranch)
+			}
+		}
+	}
+
+	found := map[string]*RootValidation{}
+	for _, module := range modules {
+		for _, moduleBranch := range branches {
+			for _, url := range b.Depman.GetRoots(b.Downloader.EncodeURL(org, repo, module, moduleBranch)) {
+				rootOrg, rootRepo, path, rootBranch, ok := DecodeURL(b.Downloader, url)
+				if !ok || filepath.Base(path) != b.DinghyfileName {
+					continue
+				}
+				v, exists := found[url]
+				if !exists {
+					v = &RootValidation{URL: url, Org: rootOrg, Repo: rootRepo, Path: path, Branch: rootBranch}
+					found[url] = v
+				}
+				if len(v.Modules) == 0 || v.Modules[len(v.Modules)-1] != module {
+					v.Modules = append(v.Modules, module)
+				}
+			}
+		}
+	}
+
+	roots := make([]RootValidation, 0, len(found))
+	for _, v := range found {
+		roots = append(roots, *v)
+	}
+	sort.Slice(roots, func(i, j int) bool { return roots[i].URL < roots[j].URL })
+	return roots
+}
+
+// DecodeURL decodes url with d, reporting whether d encoded it. Downloaders
+// panic on urls they didn't encode, so a match must encode back to url.
+func DecodeURL(d Downloader, url string) (org, repo, path, branch string, ok bool) {
+	defer func() {
+		if recover() != nil {
+			ok = false
+		}
+	}()
+	org, repo, path, branch = d.DecodeURL(url)
+	return org, repo, path, branch, d.EncodeURL(org, repo, path, branch) == url
+}
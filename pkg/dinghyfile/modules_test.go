@@ -0,0 +1,90 @@
+/*
+* Copyright 2026 Armory, Inc.
+
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+
+*    http://www.apache.org/licenses/LICENSE-2.0
+
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package dinghyfile
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/armory/dinghy/pkg/dinghyfile/pipebuilder"
+	"github.com/armory/dinghy/pkg/git/dummy"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateModuleRoots(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	files := dummy.FileService{
+		"master": {
+			"dinghyfile":  `{"application": "app1", "pipelines": [{"name": "deploy", "application": "app1", "stages": [{{ module "wait.module" "waitTime" 10 }}]}]}`,
+			"wait.module": `{"name": "wait", "type": "wait", "refId": "1"}`,
+		},
+		"main": {
+			"dinghyfile":  `{"application": "app2", "pipelines": [{"name": "deploy", "application": "app2", "stages": [{{ module "wait.module" }}]}]}`,
+			"wait.module": `{"name": "wait", "type": "wait", "refId": "1"}`,
+		},
+		// the change breaks the dinghyfiles that don't set waitTime
+		"feature": {
+			"wait.module": `{"name": "wait", "type": "wait", "refId": "1", "waitTime": {{ var "waitTime" }}}`,
+		},
+	}
+
+	b := testBasePipelineBuilder()
+	b.Downloader = files
+	b.TemplateRepo = "templates"
+	b.DinghyfileName = "dinghyfile"
+	b.Action = pipebuilder.Validate
+	// the modules aren't JSON until rendered
+	b.JsonValidationDisabled = true
+	b.Parser = NewDinghyfileParser(b)
+	client := NewMockPlankClient(ctrl)
+	client.EXPECT().GetApplicationNotifications(gomock.Any(), "").Return(nil, errors.New("not found")).AnyTimes()
+	b.Client = client
+
+	app1 := files.EncodeURL("org", "app1", "dinghyfile", "master")
+	app2 := files.EncodeURL("org", "app2", "dinghyfile", "main")
+	b.Depman.SetDeps(app1, []string{files.EncodeURL("armory", "templates", "wait.module", "master")})
+	b.Depman.SetDeps(app2, []string{files.EncodeURL("armory", "templates", "wait.module", "main")})
+	b.Depman.SetDeps(files.EncodeURL("org", "app3", "dinghyfile", "master"), []string{files.EncodeURL("armory", "templates", "other.module", "master")})
+
+	results, err := b.ValidateModuleRoots("armory", "templates", []string{"wait.module"}, "feature", "master", "pusher")
+	assert.EqualError(t, err, "1 of 2 dinghyfiles using the changed modules failed validation")
+	assert.Equal(t, "", b.ModuleBranch)
+	if assert.Len(t, results, 2) {
+		assert.Equal(t, RootValidation{URL: app1, Org: "org", Repo: "app1", Path: "dinghyfile", Branch: "master", Modules: []string{"wait.module"}}, results[0])
+		assert.True(t, results[0].Passed())
+		assert.Equal(t, app2, results[1].URL)
+		assert.Equal(t, "main", results[1].Branch)
+		assert.False(t, results[1].Passed())
+	}
+
+	b.Action = pipebuilder.Process
+	_, err = b.ValidateModuleRoots("armory", "templates", []string{"wait.module"}, "feature", "master", "pusher")
+	assert.NotNil(t, err)
+}
+
+func TestDecodeURL(t *testing.T) {
+	files := dummy.FileService{}
+	org, repo, path, branch, ok := DecodeURL(files, files.EncodeURL("org", "repo", "dinghyfile", "master"))
+	assert.True(t, ok)
+	assert.Equal(t, []string{"org", "repo", "dinghyfile", "master"}, []string{org, repo, path, branch})
+
+	_, _, _, _, ok = DecodeURL(files, "https://gitlab.example.org/unknown")
+	assert.False(t, ok)
+}
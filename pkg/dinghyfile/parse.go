@@ -34,7 +34,9 @@ import (
 
 	"github.com/armory/dinghy/pkg/events"
 	"github.com/armory/dinghy/pkg/preprocessor"
+	"github.com/armory/dinghy/pkg/tracing"
 	"github.com/go-sprout/sprout"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type DinghyfileParser struct {
@@ -225,7 +227,9 @@ func (r *DinghyfileParser) Parse(org, repo, path, branch string, vars []VarMap)
 	deps := make(map[string]bool)
 
 	// Download the template being parsed.
+	endSpan := r.Builder.startSpan(tracing.SpanDownload, attribute.String("dinghy.path", path))
 	contents, err := r.Builder.Downloader.Download(org, repo, path, branch)
+	endSpan(err)
 	if err != nil {
 		r.Builder.Logger.Errorf("Failed to download %s/%s/%s/%s", org, repo, path, branch)
 		// we don't actually have a dinghyfile we can send at this point
@@ -280,8 +284,11 @@ func (r *DinghyfileParser) Parse(org, repo, path, branch string, vars []VarMap)
 	// If we are validating then check always against the modules in master since current branch will
 	// not exists in templare repo
 	var moduleBranch = branch
-	// if we are doing a update on template repo, we should test against the branch
-	if r.Builder.Action == pipebuilder.Validate && r.Builder.TemplateRepo != repo {
+	if r.Builder.ModuleBranch != "" {
+		// validating a template repo branch against the dinghyfiles using it
+		moduleBranch = r.Builder.ModuleBranch
+	} else if r.Builder.Action == pipebuilder.Validate && r.Builder.TemplateRepo != repo {
+		// if we are doing a update on template repo, we should test against the branch
 		moduleBranch = "master"
 	}
 	// NOTE:  I don't think moduleFunc needs to take branch argument;
@@ -0,0 +1,91 @@
+/*
+* Copyright 2026 Armory, Inc.
+
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+
+*    http://www.apache.org/licenses/LICENSE-2.0
+
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package dinghyfile
+
+import (
+	"fmt"
+
+	"github.com/armory/dinghy/pkg/managed"
+)
+
+// pipelineKey is the part of a pipeline definition plank doesn't know about
+type pipelineKey struct {
+	Name     string `json:"name" yaml:"name" hcl:"name"`
+	DinghyID string `json:"dinghyId" yaml:"dinghyId" hcl:"dinghyId"`
+}
+
+// pipelineKeys returns the dinghyId of every pipeline that has one, keyed by
+// pipeline name, or nil when none has. A dinghyId can only be used once per
+// dinghyfile.
+func (b *PipelineBuilder) pipelineKeys(dinghyfile []byte) (map[string]string, error) {
+	var d struct {
+		Pipelines []pipelineKey `json:"pipelines" yaml:"pipelines" hcl:"pipelines"`
+	}
+	for _, ums := range b.Ums {
+		if err := ums.Unmarshal(dinghyfile, &d); err == nil {
+			break
+		}
+	}
+
+	var keys map[string]string
+	names := map[string]string{}
+	for _, p := range d.Pipelines {
+		if p.DinghyID == "" {
+			continue
+		}
+		if keys == nil {
+			keys = map[string]string{}
+		}
+		if other, exists := names[p.DinghyID]; exists {
+			return nil, fmt.Errorf("dinghyId %s is used by pipelines %s and %s", p.DinghyID, other, p.Name)
+		}
+		names[p.DinghyID] = p.Name
+		keys[p.Name] = p.DinghyID
+	}
+	return keys, nil
+}
+
+// renamedPipeline finds the pipeline previously written under another name
+// with the same dinghyId as name, and returns its old name and Front50 ID
+func renamedPipeline(d Dinghyfile, name string, previous *managed.Dinghyfile, ids map[string]string) (string, string, bool) {
+	key := d.PipelineKeys[name]
+	if key == "" || previous == nil || previous.Application != d.ApplicationSpec.Name {
+		return "", "", false
+	}
+	for _, p := range previous.Pipelines {
+		if p.DinghyID != key || p.Name == name {
+			continue
+		}
+		// the old name is still defined, so this isn't a rename
+		if definesPipeline(d, p.Name) {
+			return "", "", false
+		}
+		if id, exists := ids[p.Name]; exists {
+			return p.Name, id, true
+		}
+	}
+	return "", "", false
+}
+
+func definesPipeline(d Dinghyfile, name string) bool {
+	for _, p := range d.Pipelines {
+		if p.Name == name {
+			return true
+		}
+	}
+	return false
+}
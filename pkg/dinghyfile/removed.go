@@ -0,0 +1,187 @@
+/*
+* Copyright 2026 Armory, Inc.
+
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+
+*    http://www.apache.org/licenses/LICENSE-2.0
+
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package dinghyfile
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/armory/dinghy/pkg/dinghyfile/pipebuilder"
+	"github.com/armory/dinghy/pkg/managed"
+	"github.com/armory/plank/v4"
+)
+
+// Policies for the application of a dinghyfile removed from its repository
+const (
+	RemovedDinghyfileIgnore            = "ignore"
+	RemovedDinghyfileDisableTriggers   = "disableTriggers"
+	RemovedDinghyfileDeletePipelines   = "deletePipelines"
+	RemovedDinghyfileDeleteApplication = "deleteApplication"
+)
+
+// ApplicationDeleter is implemented by Spinnaker clients able to delete applications
+type ApplicationDeleter interface {
+	DeleteApplication(name, traceparent string) error
+}
+
+// previouslyManaged returns what the dinghyfile applied last time, nil when
+// managed pipelines aren't recorded or nothing is recorded yet
+func (b *PipelineBuilder) previouslyManaged(url string) *managed.Dinghyfile {
+	if b.Managed == nil || url == "" {
+		return nil
+	}
+	previous, err := b.Managed.GetManaged(url)
+	if err != nil {
+		b.Logger.Warnf("Could not look up the pipelines managed by %s, no pipeline will be considered stale: %s", url, err.Error())
+		return nil
+	}
+	return previous
+}
+
+// recordManaged stores the pipelines a dinghyfile applied, failures only
+// mean a later removal of the dinghyfile can't clean up after it. stale are
+// the pipelines a dry run would have deleted, recorded apart so they can be
+// listed and still be cleaned up on removal.
+func (b *PipelineBuilder) recordManaged(url, org, repo, path string, d Dinghyfile, stale []string) {
+	state := managed.Dinghyfile{
+		Org:         org,
+		Repo:        repo,
+		Path:        path,
+		Commit:      b.Commit,
+		Application: d.ApplicationSpec.Name,
+		Pipelines:   make([]managed.Pipeline, 0, len(d.Pipelines)),
+	}
+	for _, p := range d.Pipelines {
+		state.Pipelines = append(state.Pipelines, managed.Pipeline{Name: p.Name, DinghyID: d.PipelineKeys[p.Name]})
+	}
+	for _, name := range stale {
+		state.Stale = append(state.Stale, managed.Pipeline{Name: name})
+	}
+	if err := b.Managed.SetManaged(url, state); err != nil {
+		b.Logger.Warnf("Could not record the pipelines managed by %s: %s", url, err.Error())
+	}
+}
+
+// RemoveDinghyfile applies the RemovedDinghyfilePolicy to the application of a
+// dinghyfile removed from its repository, then prunes it from the dependency graph
+func (b *PipelineBuilder) RemoveDinghyfile(org, repo, path, branch string) error {
+	if b.Action == pipebuilder.Validate {
+		b.Logger.Infof("Skipping removed dinghyfile %s during validation", path)
+		return nil
+	}
+	url := b.Downloader.EncodeURL(org, repo, path, branch)
+	b.Logger.Infof("Dinghyfile %s was removed", url)
+
+	if b.Managed != nil {
+		state, err := b.Managed.GetManaged(url)
+		if err != nil {
+			return fmt.Errorf("could not look up the pipelines managed by %s: %w", url, err)
+		}
+		if state == nil {
+			b.Logger.Warnf("No pipelines are recorded for %s, leaving Spinnaker untouched", url)
+		} else if err := b.applyRemovedPolicy(*state); err != nil {
+			return err
+		}
+		if err := b.Managed.DeleteManaged(url); err != nil {
+			return err
+		}
+	} else if b.removedPolicy() != RemovedDinghyfileIgnore {
+		b.Logger.Warnf("The persistence backend doesn't record managed pipelines, leaving Spinnaker untouched")
+	}
+
+	return b.Depman.DeleteNode(url)
+}
+
+func (b *PipelineBuilder) removedPolicy() string {
+	if b.RemovedDinghyfilePolicy == "" {
+		return RemovedDinghyfileIgnore
+	}
+	return b.RemovedDinghyfilePolicy
+}
+
+func (b *PipelineBuilder) applyRemovedPolicy(state managed.Dinghyfile) error {
+	policy := b.removedPolicy()
+	switch policy {
+	case RemovedDinghyfileIgnore:
+		return nil
+	case RemovedDinghyfileDisableTriggers, RemovedDinghyfileDeletePipelines, RemovedDinghyfileDeleteApplication:
+	default:
+		return fmt.Errorf("unknown removed dinghyfile policy %q", policy)
+	}
+
+	deleter, canDelete := b.Client.(ApplicationDeleter)
+	if policy == RemovedDinghyfileDeleteApplication && !canDelete {
+		return fmt.Errorf("the Spinnaker client can't delete applications, %s was left untouched", state.Application)
+	}
+
+	pipelines, err := b.Client.GetPipelines(state.Application, b.traceparent())
+	if err != nil {
+		b.Logger.Errorf("Could not retrieve pipelines for %s: %s", state.Application, err.Error())
+		return err
+	}
+	names := map[string]bool{}
+	for _, p := range state.Pipelines {
+		names[p.Name] = true
+	}
+	for _, p := range state.Stale {
+		names[p.Name] = true
+	}
+	if policy == RemovedDinghyfileDeleteApplication {
+		unmanaged := []string{}
+		for _, p := range pipelines {
+			if !names[p.Name] {
+				unmanaged = append(unmanaged, p.Name)
+			}
+		}
+		if len(unmanaged) > 0 {
+			return fmt.Errorf("application %s has pipelines the dinghyfile didn't manage (%s), it was left untouched", state.Application, strings.Join(unmanaged, ", "))
+		}
+	}
+
+	for _, p := range pipelines {
+		if !names[p.Name] {
+			continue
+		}
+		if policy == RemovedDinghyfileDisableTriggers {
+			b.Logger.Infof("Disabling triggers of pipeline %s", p.Name)
+			if err := b.disableTriggers(p); err != nil {
+				return err
+			}
+			continue
+		}
+		b.Logger.Infof("Deleting pipeline %s", p.Name)
+		if err := b.Client.DeletePipeline(p, b.traceparent()); err != nil {
+			b.Logger.Errorf("Could not delete Pipeline %s (Application %s): %s", p.Name, p.Application, err.Error())
+			return err
+		}
+	}
+
+	if policy == RemovedDinghyfileDeleteApplication {
+		b.Logger.Infof("Deleting application %s", state.Application)
+		return deleter.DeleteApplication(state.Application, b.traceparent())
+	}
+	return nil
+}
+
+// disableTriggers stops a pipeline from running on its own, it can still be
+// run manually since plank pipelines have no disabled flag
+func (b *PipelineBuilder) disableTriggers(p plank.Pipeline) error {
+	for _, t := range p.Triggers {
+		t["enabled"] = false
+	}
+	return b.upsertPipeline(p)
+}
@@ -0,0 +1,180 @@
+/*
+* Copyright 2026 Armory, Inc.
+
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+
+*    http://www.apache.org/licenses/LICENSE-2.0
+
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package dinghyfile
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/armory/dinghy/pkg/cache"
+	"github.com/armory/dinghy/pkg/dinghyfile/pipebuilder"
+	"github.com/armory/dinghy/pkg/managed"
+	"github.com/armory/dinghy/pkg/util"
+	"github.com/armory/plank/v4"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+type memoryStore map[string]managed.Dinghyfile
+
+func (m memoryStore) GetManaged(url string) (*managed.Dinghyfile, error) {
+	if d, ok := m[url]; ok {
+		return &d, nil
+	}
+	return nil, nil
+}
+
+func (m memoryStore) SetManaged(url string, d managed.Dinghyfile) error {
+	m[url] = d
+	return nil
+}
+
+func (m memoryStore) DeleteManaged(url string) error {
+	delete(m, url)
+	return nil
+}
+
+var testSource = managed.Marker{Org: "org", Repo: "repo", Path: "dinghyfile"}
+
+// marked returns p as written to Spinnaker by source
+func marked(p plank.Pipeline, source managed.Marker) plank.Pipeline {
+	p.Description = managed.Mark(p.Description, source)
+	return p
+}
+
+func testRemovedBuilder(ctrl *gomock.Controller, policy string) (*PipelineBuilder, *MockPlankClient, memoryStore, string) {
+	client := NewMockPlankClient(ctrl)
+	store := memoryStore{}
+	b := testPipelineBuilder()
+	b.Client = client
+	b.Managed = store
+	b.RemovedDinghyfilePolicy = policy
+
+	url := b.Downloader.EncodeURL("org", "repo", "dinghyfile", "master")
+	b.Depman.SetDeps(url, []string{"module"})
+	store[url] = managed.Dinghyfile{
+		Org:         "org",
+		Repo:        "repo",
+		Path:        "dinghyfile",
+		Application: "testapp",
+		Pipelines:   []managed.Pipeline{{Name: "managed"}},
+	}
+	return b, client, store, url
+}
+
+func testRemovedPipelines() []plank.Pipeline {
+	return []plank.Pipeline{
+		{ID: "1", Name: "managed", Application: "testapp", Triggers: []map[string]interface{}{{"type": "git", "enabled": true}}},
+		{ID: "2", Name: "manual", Application: "testapp"},
+	}
+}
+
+func TestRemoveDinghyfileDeletePipelines(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	b, client, store, url := testRemovedBuilder(ctrl, RemovedDinghyfileDeletePipelines)
+	pipelines := testRemovedPipelines()
+	client.EXPECT().GetPipelines(gomock.Eq("testapp"), "").Return(pipelines, nil).Times(1)
+	client.EXPECT().DeletePipeline(gomock.Eq(pipelines[0]), "").Return(nil).Times(1)
+
+	assert.Nil(t, b.RemoveDinghyfile("org", "repo", "dinghyfile", "master"))
+	assert.Empty(t, store)
+	_, exists := b.Depman.(cache.MemoryCache)[url]
+	assert.False(t, exists, "the dinghyfile should have been pruned from the dependency graph")
+}
+
+func TestRemoveDinghyfileDisableTriggers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	b, client, _, _ := testRemovedBuilder(ctrl, RemovedDinghyfileDisableTriggers)
+	pipelines := testRemovedPipelines()
+	disabled := testRemovedPipelines()[0]
+	disabled.Triggers[0]["enabled"] = false
+	client.EXPECT().GetPipelines(gomock.Eq("testapp"), "").Return(pipelines, nil).Times(1)
+	client.EXPECT().UpsertPipeline(gomock.Eq(disabled), gomock.Eq("1"), "").Return(nil).Times(1)
+
+	assert.Nil(t, b.RemoveDinghyfile("org", "repo", "dinghyfile", "master"))
+}
+
+// testFront50 records the applications deleted through a util.SpinnakerClient
+func testFront50(t *testing.T, client *MockPlankClient) (*util.SpinnakerClient, *[]string) {
+	deleted := []string{}
+	front50 := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/v2/applications/") {
+			deleted = append(deleted, strings.TrimPrefix(r.URL.Path, "/v2/applications/"))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	t.Cleanup(front50.Close)
+	return &util.SpinnakerClient{PlankClient: client, HTTP: front50.Client(), Front50: front50.URL}, &deleted
+}
+
+func TestRemoveDinghyfileDeleteApplication(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// the mock client can't delete applications, nothing is touched
+	b, _, store, _ := testRemovedBuilder(ctrl, RemovedDinghyfileDeleteApplication)
+	assert.EqualError(t, b.RemoveDinghyfile("org", "repo", "dinghyfile", "master"), "the Spinnaker client can't delete applications, testapp was left untouched")
+	assert.NotEmpty(t, store)
+
+	// pipelines the dinghyfile didn't manage keep the application
+	b, client, store, _ := testRemovedBuilder(ctrl, RemovedDinghyfileDeleteApplication)
+	spinnaker, deleted := testFront50(t, client)
+	b.Client = spinnaker
+	client.EXPECT().GetPipelines(gomock.Eq("testapp"), "").Return(testRemovedPipelines(), nil).Times(1)
+	assert.EqualError(t, b.RemoveDinghyfile("org", "repo", "dinghyfile", "master"), "application testapp has pipelines the dinghyfile didn't manage (manual), it was left untouched")
+	assert.NotEmpty(t, store)
+	assert.Empty(t, *deleted)
+
+	// every pipeline is managed, the application goes away
+	pipelines := testRemovedPipelines()[:1]
+	client.EXPECT().GetPipelines(gomock.Eq("testapp"), "").Return(pipelines, nil).Times(1)
+	client.EXPECT().DeletePipeline(gomock.Eq(pipelines[0]), "").Return(nil).Times(1)
+	assert.Nil(t, b.RemoveDinghyfile("org", "repo", "dinghyfile", "master"))
+	assert.Empty(t, store)
+	assert.Equal(t, []string{"testapp"}, *deleted)
+}
+
+func TestRemoveDinghyfileLeavesSpinnaker(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// nothing recorded, the client must not be called
+	b, _, store, url := testRemovedBuilder(ctrl, RemovedDinghyfileDeletePipelines)
+	delete(store, url)
+	assert.Nil(t, b.RemoveDinghyfile("org", "repo", "dinghyfile", "master"))
+
+	// the default policy ignores removals
+	b, _, store, _ = testRemovedBuilder(ctrl, "")
+	assert.Nil(t, b.RemoveDinghyfile("org", "repo", "dinghyfile", "master"))
+	assert.Empty(t, store)
+
+	// validation never touches anything
+	b, _, store, url = testRemovedBuilder(ctrl, RemovedDinghyfileDeletePipelines)
+	b.Action = pipebuilder.Validate
+	assert.Nil(t, b.RemoveDinghyfile("org", "repo", "dinghyfile", "master"))
+	assert.Contains(t, store, url)
+
+	b, _, _, _ = testRemovedBuilder(ctrl, "unknown")
+	assert.NotNil(t, b.RemoveDinghyfile("org", "repo", "dinghyfile", "master"))
+}
@@ -0,0 +1,167 @@
+/*
+* Copyright 2026 Armory, Inc.
+
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+
+*    http://www.apache.org/licenses/LICENSE-2.0
+
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package dinghyfile
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/armory/plank/v4"
+)
+
+// applySnapshot is the state of an application before updatePipelines wrote
+// to it, and what it wrote so far
+type applySnapshot struct {
+	// application is nil when the apply created it
+	application   *plank.Application
+	notifications *plank.NotificationsType
+	// pipelines by ID
+	pipelines map[string]plank.Pipeline
+
+	appCreated           bool
+	appUpdated           bool
+	notificationsUpdated bool
+	written              []plank.Pipeline
+}
+
+func (s *applySnapshot) addPipelines(b *PipelineBuilder, app string) error {
+	pipelines, err := b.Client.GetPipelines(app, b.traceparent())
+	if err != nil {
+		b.Logger.Errorf("Failed to snapshot pipelines of %s: %s", app, err.Error())
+		return err
+	}
+	s.pipelines = make(map[string]plank.Pipeline, len(pipelines))
+	for _, p := range pipelines {
+		s.pipelines[p.ID] = p
+	}
+	return nil
+}
+
+// RollbackReport lists what a failed apply restored and what it could not
+type RollbackReport struct {
+	RolledBack []string `json:"rolledBack"`
+	Failed     []string `json:"failed"`
+}
+
+func (r *RollbackReport) String() string {
+	rolledBack := "nothing"
+	if len(r.RolledBack) > 0 {
+		rolledBack = strings.Join(r.RolledBack, ", ")
+	}
+	if len(r.Failed) == 0 {
+		return "rolled back: " + rolledBack
+	}
+	return fmt.Sprintf("rolled back: %s; could not roll back: %s", rolledBack, strings.Join(r.Failed, ", "))
+}
+
+// RollbackError is returned when an apply failed part way through and what
+// it wrote was rolled back
+type RollbackError struct {
+	Err    error
+	Report RollbackReport
+}
+
+func (e *RollbackError) Error() string {
+	return fmt.Sprintf("%s (%s)", e.Err.Error(), e.Report.String())
+}
+
+func (e *RollbackError) Unwrap() error {
+	return e.Err
+}
+
+// rollback restores snapshot on a best-effort basis after cause made the apply
+// fail. It returns cause untouched when there is no snapshot.
+func (b *PipelineBuilder) rollback(app string, snapshot *applySnapshot, cause error) error {
+	if snapshot == nil {
+		return cause
+	}
+	b.Logger.Warnf("Rolling back application %s", app)
+	report := RollbackReport{}
+	done := func(format string, args ...interface{}) {
+		report.RolledBack = append(report.RolledBack, fmt.Sprintf(format, args...))
+	}
+	failed := func(err error, format string, args ...interface{}) {
+		report.Failed = append(report.Failed, fmt.Sprintf(format, args...)+": "+err.Error())
+	}
+
+	var created map[string]string
+	for i := len(snapshot.written) - 1; i >= 0; i-- {
+		p := snapshot.written[i]
+		if previous, existed := snapshot.pipelines[p.ID]; existed && p.ID != "" {
+			if err := b.upsertPipeline(previous); err != nil {
+				failed(err, "pipeline %s", previous.Name)
+			} else {
+				done("pipeline %s restored", previous.Name)
+			}
+			continue
+		}
+		// the pipeline was created by this apply, its ID is only known now
+		if created == nil {
+			created, _ = b.PipelineIDs(app)
+		}
+		id, exists := created[p.Name]
+		if !exists {
+			failed(fmt.Errorf("not found"), "pipeline %s", p.Name)
+			continue
+		}
+		p.ID = id
+		if err := b.Client.DeletePipeline(p, b.traceparent()); err != nil {
+			failed(err, "pipeline %s", p.Name)
+		} else {
+			done("pipeline %s deleted", p.Name)
+		}
+	}
+
+	if snapshot.notificationsUpdated && snapshot.notifications != nil {
+		if err := b.Client.UpdateApplicationNotifications(*snapshot.notifications, app, b.traceparent()); err != nil {
+			failed(err, "notifications of %s", app)
+		} else {
+			done("notifications of %s restored", app)
+		}
+	}
+	if snapshot.appUpdated && snapshot.application != nil {
+		if err := b.Client.UpdateApplication(*snapshot.application, b.traceparent()); err != nil {
+			failed(err, "application %s", app)
+		} else {
+			done("application %s restored", app)
+		}
+	}
+	if snapshot.appCreated {
+		if deleter, ok := b.Client.(ApplicationDeleter); !ok {
+			failed(fmt.Errorf("the Spinnaker client can't delete applications"), "application %s", app)
+		} else if err := deleter.DeleteApplication(app, b.traceparent()); err != nil {
+			failed(err, "application %s", app)
+		} else {
+			done("application %s deleted", app)
+		}
+	}
+
+	if len(report.Failed) > 0 {
+		b.Logger.Errorf("Rollback of application %s incomplete, %s", app, report.String())
+	} else {
+		b.Logger.Infof("Rollback of application %s complete, %s", app, report.String())
+	}
+	return &RollbackError{Err: cause, Report: report}
+}
+
+// upsertPipeline writes p with the task configured for this builder
+func (b *PipelineBuilder) upsertPipeline(p plank.Pipeline) error {
+	if b.UpsertPipelineUsingOrcaTaskEnabled {
+		return b.Client.UpsertPipelineUsingOrca(p, p.ID, b.traceparent())
+	}
+	return unwrapFront50Error(b.Client.UpsertPipeline(p, p.ID, b.traceparent()))
+}
@@ -0,0 +1,118 @@
+/*
+* Copyright 2026 Armory, Inc.
+
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+
+*    http://www.apache.org/licenses/LICENSE-2.0
+
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package dinghyfile
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/armory/plank/v4"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdatePipelinesRollback(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	oldApp := plank.Application{Name: "testapp", Email: "old@example.org"}
+	newApp := plank.Application{Name: "testapp", Email: "new@example.org"}
+	oldNotifications := plank.NotificationsType{"application": "testapp"}
+
+	oldFirst := plank.Pipeline{Name: "first", ID: "firstID", Application: "testapp", Description: "old"}
+	oldThird := plank.Pipeline{Name: "third", ID: "thirdID", Application: "testapp", Description: "old"}
+	first := plank.Pipeline{Name: "first", ID: "firstID", Application: "testapp", Description: "new"}
+	second := plank.Pipeline{Name: "second", Application: "testapp"}
+	third := plank.Pipeline{Name: "third", ID: "thirdID", Application: "testapp", Description: "new"}
+	created := marked(plank.Pipeline{Name: "second", ID: "secondID", Application: "testapp"}, testSource)
+
+	client := NewMockPlankClient(ctrl)
+	client.EXPECT().GetApplication("testapp", "").Return(&oldApp, nil).Times(1)
+	client.EXPECT().GetApplicationNotifications("testapp", "").Return(&oldNotifications, nil).Times(1)
+	client.EXPECT().UpdateApplication(newApp, "").Return(nil).Times(1)
+	client.EXPECT().UpdateApplicationNotifications(newApp.Notifications, "testapp", "").Return(nil).Times(1)
+	// snapshot, then ids
+	client.EXPECT().GetPipelines("testapp", "").Return([]plank.Pipeline{oldFirst, oldThird}, nil).Times(2)
+	client.EXPECT().UpsertPipeline(marked(first, testSource), "firstID", "").Return(nil).Times(1)
+	client.EXPECT().UpsertPipeline(marked(second, testSource), "", "").Return(nil).Times(1)
+	client.EXPECT().UpsertPipeline(marked(third, testSource), "thirdID", "").Return(errors.New("upsert fail test")).Times(1)
+
+	// rollback
+	client.EXPECT().GetPipelines("testapp", "").Return([]plank.Pipeline{marked(first, testSource), created, oldThird}, nil).Times(1)
+	client.EXPECT().DeletePipeline(created, "").Return(nil).Times(1)
+	client.EXPECT().UpsertPipeline(oldFirst, "firstID", "").Return(errors.New("restore fail test")).Times(1)
+	client.EXPECT().UpdateApplicationNotifications(oldNotifications, "testapp", "").Return(nil).Times(1)
+	client.EXPECT().UpdateApplication(oldApp, "").Return(nil).Times(1)
+
+	b := testPipelineBuilder()
+	b.Client = client
+	b.GlobalVariablesMap = map[string]interface{}{"save_app_on_update": true}
+	b.RollbackOnFailure = true
+
+	dinghyfile := Dinghyfile{
+		ApplicationSpec: newApp,
+		Pipelines:       []plank.Pipeline{first, second, third},
+	}
+
+	_, _, err := b.updatePipelines(dinghyfile, testSource, "pusher", nil)
+	var rollbackErr *RollbackError
+	assert.True(t, errors.As(err, &rollbackErr))
+	assert.Equal(t, []string{"pipeline second deleted", "notifications of testapp restored", "application testapp restored"}, rollbackErr.Report.RolledBack)
+	assert.Equal(t, []string{"pipeline first: restore fail test"}, rollbackErr.Report.Failed)
+	assert.Equal(t, "upsert fail test (rolled back: pipeline second deleted, notifications of testapp restored, application testapp restored; could not roll back: pipeline first: restore fail test)", err.Error())
+}
+
+func TestUpdatePipelinesRollbackCreatedApplication(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	app := plank.Application{Name: "testapp"}
+	pipeline := plank.Pipeline{Name: "first", Application: "testapp"}
+
+	client := NewMockPlankClient(ctrl)
+	client.EXPECT().GetApplication("testapp", "").Return(nil, &plank.FailedResponse{StatusCode: 404}).Times(1)
+	client.EXPECT().CreateApplication(&app, "").Return(nil).Times(1)
+	client.EXPECT().UpdateApplicationNotifications(app.Notifications, "testapp", "").Return(nil).Times(1)
+	client.EXPECT().GetPipelines("testapp", "").Return([]plank.Pipeline{}, nil).Times(2)
+	client.EXPECT().UpsertPipeline(marked(pipeline, testSource), "", "").Return(errors.New("upsert fail test")).Times(1)
+
+	b := testPipelineBuilder()
+	b.Client = client
+	b.RollbackOnFailure = true
+
+	_, _, err := b.updatePipelines(Dinghyfile{ApplicationSpec: app, Pipelines: []plank.Pipeline{pipeline}}, testSource, "pusher", nil)
+	var rollbackErr *RollbackError
+	assert.True(t, errors.As(err, &rollbackErr))
+	// the mock client can't delete the application it created
+	assert.Empty(t, rollbackErr.Report.RolledBack)
+	assert.Equal(t, []string{"application testapp: the Spinnaker client can't delete applications"}, rollbackErr.Report.Failed)
+
+	// the Spinnaker client of dinghy deletes it through Front50
+	client.EXPECT().GetApplication("testapp", "").Return(nil, &plank.FailedResponse{StatusCode: 404}).Times(1)
+	client.EXPECT().CreateApplication(&app, "").Return(nil).Times(1)
+	client.EXPECT().UpdateApplicationNotifications(app.Notifications, "testapp", "").Return(nil).Times(1)
+	client.EXPECT().GetPipelines("testapp", "").Return([]plank.Pipeline{}, nil).Times(2)
+	client.EXPECT().UpsertPipeline(marked(pipeline, testSource), "", "").Return(errors.New("upsert fail test")).Times(1)
+	spinnaker, deleted := testFront50(t, client)
+	b.Client = spinnaker
+
+	_, _, err = b.updatePipelines(Dinghyfile{ApplicationSpec: app, Pipelines: []plank.Pipeline{pipeline}}, testSource, "pusher", nil)
+	assert.True(t, errors.As(err, &rollbackErr))
+	assert.Equal(t, []string{"application testapp deleted"}, rollbackErr.Report.RolledBack)
+	assert.Empty(t, rollbackErr.Report.Failed)
+	assert.Equal(t, []string{"testapp"}, *deleted)
+}
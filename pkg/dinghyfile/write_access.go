@@ -47,6 +47,7 @@ func (w NoOpWritePermissionValidator) Validate(string) error {
 type FiatPermissionsValidator struct {
 	client      util.PlankClient
 	application plank.Application
+	traceparent string
 }
 
 func (v FiatPermissionsValidator) Validate(pusher string) error {
@@ -54,7 +55,7 @@ func (v FiatPermissionsValidator) Validate(pusher string) error {
 		log.Errorf("Got empty string as pusher. Either that attribute is mising in a webhook, or there's problem with mapping")
 		return UserNameEmpty
 	}
-	userRoles, err := v.client.UserRoles(pusher, "")
+	userRoles, err := v.client.UserRoles(pusher, v.traceparent)
 	if err != nil {
 		if failedResponse, ok := err.(*plank.FailedResponse); ok {
 			if failedResponse.StatusCode == 404 {
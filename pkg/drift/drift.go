@@ -0,0 +1,187 @@
+/*
+* Copyright 2026 Armory, Inc.
+
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+
+*    http://www.apache.org/licenses/LICENSE-2.0
+
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+// Package drift compares the last render of every managed dinghyfile with the
+// pipelines in Front50, to notice pipelines edited outside of Dinghy.
+package drift
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/armory/dinghy/pkg/managed"
+	"github.com/armory/plank/v4"
+)
+
+const (
+	// Missing pipelines were deleted from Front50
+	Missing = "missing"
+	// Modified pipelines no longer match their render
+	Modified = "modified"
+)
+
+// ignoredFields are set by Front50 or Dinghy itself when writing a pipeline
+var ignoredFields = map[string]bool{
+	"id":             true,
+	"application":    true,
+	"index":          true,
+	"lastModifiedBy": true,
+	"updateTs":       true,
+	"locked":         true,
+}
+
+// PipelineDrift is a rendered pipeline that Front50 doesn't have as rendered
+type PipelineDrift struct {
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+	// Fields that differ, for modified pipelines
+	Fields []string `json:"fields,omitempty"`
+}
+
+func (p PipelineDrift) String() string {
+	if p.Kind == Modified {
+		return fmt.Sprintf("pipeline %s modified (%s)", p.Name, strings.Join(p.Fields, ", "))
+	}
+	return fmt.Sprintf("pipeline %s %s", p.Name, p.Kind)
+}
+
+// Report is the drift of a single dinghyfile from its last render
+type Report struct {
+	URL         string          `json:"url"`
+	Org         string          `json:"org"`
+	Repo        string          `json:"repo"`
+	Path        string          `json:"path"`
+	Branch      string          `json:"branch"`
+	Application string          `json:"application"`
+	Commit      string          `json:"commit"`
+	Pipelines   []PipelineDrift `json:"pipelines"`
+	Healed      bool            `json:"healed"`
+}
+
+// Drifted reports whether any pipeline drifted
+func (r Report) Drifted() bool {
+	return len(r.Pipelines) > 0
+}
+
+func (r Report) String() string {
+	drifts := make([]string, 0, len(r.Pipelines))
+	for _, p := range r.Pipelines {
+		drifts = append(drifts, p.String())
+	}
+	return fmt.Sprintf("%s drifted from the render of %s: %s", r.Application, r.Commit, strings.Join(drifts, ", "))
+}
+
+// Compare returns how the live pipelines differ from the rendered ones.
+// Pipelines are matched by name and live pipelines that aren't rendered are
+// not reported, deleting those is the job of deleteStalePipelines.
+func Compare(rendered, live []plank.Pipeline) ([]PipelineDrift, error) {
+	byName := make(map[string]plank.Pipeline, len(live))
+	for _, p := range live {
+		byName[p.Name] = p
+	}
+
+	drifts := make([]PipelineDrift, 0)
+	for _, want := range rendered {
+		have, exists := byName[want.Name]
+		if !exists {
+			drifts = append(drifts, PipelineDrift{Name: want.Name, Kind: Missing})
+			continue
+		}
+		fields, err := diffFields(want, have)
+		if err != nil {
+			return nil, err
+		}
+		if len(fields) > 0 {
+			drifts = append(drifts, PipelineDrift{Name: want.Name, Kind: Modified, Fields: fields})
+		}
+	}
+	return drifts, nil
+}
+
+// diffFields returns the sorted top level fields that differ between two
+// pipelines, once both went through JSON so that numbers compare alike
+func diffFields(want, have plank.Pipeline) ([]string, error) {
+	wantFields, err := toFields(want)
+	if err != nil {
+		return nil, err
+	}
+	haveFields, err := toFields(have)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields []string
+	for field, value := range wantFields {
+		if ignoredFields[field] {
+			continue
+		}
+		if !equivalent(value, haveFields[field]) {
+			fields = append(fields, field)
+		}
+	}
+	for field := range haveFields {
+		if _, rendered := wantFields[field]; !rendered && !ignoredFields[field] && !isEmpty(haveFields[field]) {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+	return fields, nil
+}
+
+// toFields returns the fields of a pipeline, the description without the
+// marker Dinghy adds when writing it
+func toFields(p plank.Pipeline) (map[string]interface{}, error) {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	fields := map[string]interface{}{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	if description, ok := fields["description"].(string); ok {
+		if description = managed.Unmark(description); description == "" {
+			delete(fields, "description")
+		} else {
+			fields["description"] = description
+		}
+	}
+	return fields, nil
+}
+
+// equivalent treats null and empty lists or objects alike, Front50 returns
+// the latter for what a dinghyfile leaves out
+func equivalent(a, b interface{}) bool {
+	if isEmpty(a) && isEmpty(b) {
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func isEmpty(v interface{}) bool {
+	switch value := v.(type) {
+	case nil:
+		return true
+	case []interface{}:
+		return len(value) == 0
+	case map[string]interface{}:
+		return len(value) == 0
+	}
+	return false
+}
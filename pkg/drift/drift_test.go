@@ -0,0 +1,61 @@
+/*
+* Copyright 2026 Armory, Inc.
+
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+
+*    http://www.apache.org/licenses/LICENSE-2.0
+
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+package drift
+
+import (
+	"testing"
+
+	"github.com/armory/dinghy/pkg/managed"
+	"github.com/armory/plank/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompare(t *testing.T) {
+	stage := map[string]interface{}{"name": "wait", "type": "wait", "waitTime": 30}
+
+	rendered := []plank.Pipeline{
+		{Name: "same", Stages: []map[string]interface{}{stage}},
+		{Name: "edited", Stages: []map[string]interface{}{stage}},
+		{Name: "described", Stages: []map[string]interface{}{stage}},
+		{Name: "marked", Description: "Deploys app"},
+		{Name: "deleted"},
+	}
+	marker := managed.Marker{Org: "org", Repo: "repo", Path: "dinghyfile"}
+	edited := map[string]interface{}{"name": "wait", "type": "wait", "waitTime": 60}
+	live := []plank.Pipeline{
+		// written by Front50 and Dinghy, not drift
+		{Name: "same", ID: "1", Application: "app", UpdateTs: "123", LastModifiedBy: "dinghy",
+			Stages: []map[string]interface{}{stage}, Triggers: []map[string]interface{}{}},
+		{Name: "edited", ID: "2", Stages: []map[string]interface{}{edited}, KeepWaitingPipelines: true},
+		{Name: "described", ID: "3", Stages: []map[string]interface{}{stage}, Description: managed.Mark("added in Deck", marker)},
+		// the marker Dinghy writes isn't drift
+		{Name: "marked", ID: "4", Description: managed.Mark("Deploys app", marker)},
+		// not rendered, left to deleteStalePipelines
+		{Name: "manual", ID: "5"},
+	}
+
+	drifts, err := Compare(rendered, live)
+	assert.Nil(t, err)
+	assert.Equal(t, []PipelineDrift{
+		{Name: "edited", Kind: Modified, Fields: []string{"keepWaitingPipelines", "stages"}},
+		{Name: "described", Kind: Modified, Fields: []string{"description"}},
+		{Name: "deleted", Kind: Missing},
+	}, drifts)
+
+	report := Report{Application: "app", Commit: "abc123", Pipelines: drifts}
+	assert.True(t, report.Drifted())
+	assert.Equal(t, "app drifted from the render of abc123: pipeline edited modified (keepWaitingPipelines, stages), pipeline described modified (description), pipeline deleted missing", report.String())
+}
@@ -0,0 +1,221 @@
+/*
+* Copyright 2026 Armory, Inc.
+
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+
+*    http://www.apache.org/licenses/LICENSE-2.0
+
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package drift
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/armory/dinghy/pkg/dinghyfile"
+	"github.com/armory/dinghy/pkg/events"
+	"github.com/armory/dinghy/pkg/history"
+	dinghylog "github.com/armory/dinghy/pkg/log"
+	"github.com/armory/dinghy/pkg/managed"
+	"github.com/armory/plank/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	DefaultInterval = 30 * time.Minute
+
+	// EventDriftDetected is the type of the event sent for drifted dinghyfiles
+	EventDriftDetected = "drift-detected"
+	// Pusher is who pipelines re-applied by auto-heal are attributed to
+	Pusher = "dinghy-drift-reconciler"
+)
+
+var (
+	driftedPipelines = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "dinghy_drift_pipelines",
+		Help: "Pipelines that differ from the last render of their dinghyfile, as of the latest reconciliation",
+	}, []string{"application"})
+	driftDetected = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "dinghy_drift_detected_total",
+		Help: "Reconciliations that found a dinghyfile drifted",
+	}, []string{"application"})
+	driftHealed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "dinghy_drift_healed_total",
+		Help: "Drifted dinghyfiles re-applied by auto-heal",
+	}, []string{"application"})
+)
+
+// Reconciler periodically compares the last render of every dinghyfile in the
+// dependency graph with Front50. Drift is logged, counted, sent as an event and
+// notified as a failure of the dinghyfile; with AutoHeal the render is applied
+// again.
+type Reconciler struct {
+	Interval time.Duration
+	AutoHeal bool
+	Roots    dinghyfile.RootLister
+	Managed  managed.Store
+	History  history.Store
+	// NewBuilder returns the builder renders are parsed and healed with,
+	// logging to l
+	NewBuilder func(l dinghylog.DinghyLog) *dinghyfile.PipelineBuilder
+	Logger     log.FieldLogger
+}
+
+// Start reconciles every Interval until ctx is done. The first run waits for
+// a full interval, drift isn't urgent enough to slow down startup.
+func (r *Reconciler) Start(ctx context.Context) {
+	interval := r.Interval
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	go func() {
+		timer := time.NewTicker(interval)
+		defer timer.Stop()
+		for {
+			select {
+			case <-timer.C:
+				r.Run(ctx)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Run checks every root once and returns the reports of the drifted ones
+func (r *Reconciler) Run(ctx context.Context) []Report {
+	roots, err := r.Roots.ListRoots()
+	if err != nil {
+		r.Logger.Errorf("Drift reconciliation failed to list dinghyfiles: %s", err.Error())
+		return nil
+	}
+	r.Logger.Infof("Reconciling %d dinghyfiles with Front50", len(roots))
+
+	drifted := make([]Report, 0)
+	for _, url := range roots {
+		if ctx.Err() != nil {
+			break
+		}
+		report, err := r.Check(url)
+		if err != nil {
+			r.Logger.Warnf("Could not check %s for drift: %s", url, err.Error())
+			continue
+		}
+		if report != nil && report.Drifted() {
+			drifted = append(drifted, *report)
+		}
+	}
+	r.Logger.Infof("Drift reconciliation done, %d dinghyfiles drifted", len(drifted))
+	return drifted
+}
+
+// Check compares the last render of a dinghyfile with Front50. It returns
+// nil when nothing was rendered for it, like for modules.
+func (r *Reconciler) Check(url string) (*Report, error) {
+	state, err := r.Managed.GetManaged(url)
+	if err != nil || state == nil {
+		return nil, err
+	}
+	application := strings.ToLower(state.Application)
+	renders, err := r.History.ListRenders(application)
+	if err != nil {
+		return nil, err
+	}
+	render := latestRender(renders, url, *state)
+	if render == nil {
+		r.Logger.Debugf("No render recorded for %s, skipping drift check", url)
+		return nil, nil
+	}
+
+	b := r.NewBuilder(dinghylog.NewDinghyLogs(r.Logger))
+	d, err := b.UpdateDinghyfile([]byte(render.Dinghyfile))
+	if err != nil {
+		return nil, err
+	}
+	live, err := b.Client.GetPipelines(d.ApplicationSpec.Name, "")
+	if err != nil {
+		return nil, err
+	}
+	// the pipelines as applied are compared when recorded, older renders
+	// only have the dinghyfile
+	rendered := d.Pipelines
+	if len(render.Pipelines) > 0 {
+		var applied []plank.Pipeline
+		if err := json.Unmarshal(render.Pipelines, &applied); err != nil {
+			return nil, err
+		}
+		rendered = applied
+	}
+	drifts, err := Compare(rendered, live)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{
+		URL:         url,
+		Org:         render.Org,
+		Repo:        render.Repo,
+		Path:        render.Path,
+		Branch:      render.Branch,
+		Application: application,
+		Commit:      render.Commit,
+		Pipelines:   drifts,
+	}
+	driftedPipelines.WithLabelValues(application).Set(float64(len(drifts)))
+	if report.Drifted() {
+		r.reportDrift(b, *render, report)
+	}
+	return report, nil
+}
+
+func (r *Reconciler) reportDrift(b *dinghyfile.PipelineBuilder, render history.Render, report *Report) {
+	b.Logger.Warnf("Drift detected, %s", report.String())
+	driftDetected.WithLabelValues(report.Application).Inc()
+	if b.EventClient != nil {
+		now := time.Now().UTC().Unix()
+		b.EventClient.SendEvent(EventDriftDetected, &events.Event{
+			Start:      now,
+			End:        now,
+			Org:        render.Org,
+			Repo:       render.Repo,
+			Path:       render.Path,
+			Branch:     render.Branch,
+			Dinghyfile: render.Dinghyfile,
+		})
+	}
+	b.NotifyFailure(render.Org, render.Repo, render.Path, errors.New(report.String()), render.Dinghyfile)
+
+	if !r.AutoHeal {
+		return
+	}
+	if err := b.ApplyRender(render, Pusher); err != nil {
+		b.Logger.Errorf("Could not heal %s: %s", report.URL, err.Error())
+		return
+	}
+	report.Healed = true
+	driftHealed.WithLabelValues(report.Application).Inc()
+}
+
+// latestRender returns the newest render of a dinghyfile. Renders recorded
+// without their url are matched on the location of the dinghyfile.
+func latestRender(renders []history.Render, url string, state managed.Dinghyfile) *history.Render {
+	for i, r := range renders {
+		if r.URL == url || (r.URL == "" && r.Org == state.Org && r.Repo == state.Repo && r.Path == state.Path) {
+			return &renders[i]
+		}
+	}
+	return nil
+}
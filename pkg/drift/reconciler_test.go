@@ -0,0 +1,187 @@
+/*
+* Copyright 2026 Armory, Inc.
+
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+
+*    http://www.apache.org/licenses/LICENSE-2.0
+
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+package drift
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/armory/dinghy/pkg/cache"
+	"github.com/armory/dinghy/pkg/dinghyfile"
+	"github.com/armory/dinghy/pkg/dinghyfile/pipebuilder"
+	"github.com/armory/dinghy/pkg/history"
+	dinghylog "github.com/armory/dinghy/pkg/log"
+	"github.com/armory/dinghy/pkg/managed"
+	"github.com/armory/plank/v4"
+	"github.com/golang/mock/gomock"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+type memoryManaged map[string]managed.Dinghyfile
+
+func (m memoryManaged) GetManaged(url string) (*managed.Dinghyfile, error) {
+	if d, ok := m[url]; ok {
+		return &d, nil
+	}
+	return nil, nil
+}
+
+func (m memoryManaged) SetManaged(url string, d managed.Dinghyfile) error {
+	m[url] = d
+	return nil
+}
+
+func (m memoryManaged) DeleteManaged(url string) error {
+	delete(m, url)
+	return nil
+}
+
+type memoryHistory []history.Render
+
+func (m *memoryHistory) SaveRender(r history.Render) error {
+	*m = append(memoryHistory{r}, *m...)
+	return nil
+}
+
+func (m *memoryHistory) ListRenders(application string) ([]history.Render, error) {
+	return *m, nil
+}
+
+func (m *memoryHistory) GetRender(application, id string) (*history.Render, error) {
+	return nil, nil
+}
+
+func (m *memoryHistory) PruneRenders(application string, keep int) error {
+	return nil
+}
+
+func testReconciler(ctrl *gomock.Controller) (*Reconciler, *dinghyfile.MockPlankClient) {
+	client := dinghyfile.NewMockPlankClient(ctrl)
+	graph := cache.NewMemoryCache()
+	graph.SetDeps("https://github.com/org/repo/dinghyfile", []string{"https://github.com/org/templates/module"})
+	graph.SetDeps("https://github.com/org/other/dinghyfile", []string{})
+
+	store := memoryManaged{
+		"https://github.com/org/repo/dinghyfile": {Org: "org", Repo: "repo", Path: "dinghyfile", Application: "testapp"},
+	}
+	renders := &memoryHistory{}
+	renders.SaveRender(history.Render{
+		Application: "testapp",
+		Commit:      "old",
+		URL:         "https://github.com/org/repo/dinghyfile",
+		Dinghyfile:  `{"application": "testapp", "pipelines": [{"name": "first", "application": "testapp", "description": "old"}]}`,
+	})
+	renders.SaveRender(history.Render{
+		Application: "testapp",
+		Commit:      "abc123",
+		URL:         "https://github.com/org/repo/dinghyfile",
+		Org:         "org",
+		Repo:        "repo",
+		Path:        "dinghyfile",
+		Branch:      "master",
+		Dinghyfile:  `{"application": "testapp", "pipelines": [{"name": "first", "application": "testapp", "description": "rendered"}]}`,
+	})
+
+	logger := log.New()
+	r := &Reconciler{
+		Roots:   graph,
+		Managed: store,
+		History: renders,
+		Logger:  logger,
+		NewBuilder: func(l dinghylog.DinghyLog) *dinghyfile.PipelineBuilder {
+			return &dinghyfile.PipelineBuilder{
+				Depman:  graph,
+				Client:  client,
+				Logger:  l,
+				Ums:     []dinghyfile.Unmarshaller{&dinghyfile.DinghyJsonUnmarshaller{}},
+				Action:  pipebuilder.Process,
+				Managed: store,
+			}
+		},
+	}
+	return r, client
+}
+
+func TestReconcilerRun(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	r, client := testReconciler(ctrl)
+	live := []plank.Pipeline{{Name: "first", ID: "1", Application: "testapp", Description: "edited in Deck"}}
+	client.EXPECT().GetPipelines("testapp", "").Return(live, nil).Times(1)
+	// the drift is notified, with no notifiers only the notifications are looked up
+	client.EXPECT().GetApplicationNotifications("testapp", "").Return(&plank.NotificationsType{}, nil).Times(1)
+
+	reports := r.Run(context.Background())
+	assert.Equal(t, []Report{{
+		URL:         "https://github.com/org/repo/dinghyfile",
+		Org:         "org",
+		Repo:        "repo",
+		Path:        "dinghyfile",
+		Branch:      "master",
+		Application: "testapp",
+		Commit:      "abc123",
+		Pipelines:   []PipelineDrift{{Name: "first", Kind: Modified, Fields: []string{"description"}}},
+	}}, reports)
+}
+
+func TestReconcilerAutoHeal(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	r, client := testReconciler(ctrl)
+	r.AutoHeal = true
+	live := []plank.Pipeline{{Name: "first", ID: "1", Application: "testapp", Description: "edited in Deck"}}
+	client.EXPECT().GetPipelines("testapp", "").Return(live, nil).Times(2)
+	client.EXPECT().GetApplicationNotifications("testapp", "").Return(&plank.NotificationsType{}, nil).Times(1)
+	// healing
+	client.EXPECT().GetApplication("testapp", "").Return(nil, nil).Times(1)
+	source := managed.Marker{URL: "https://github.com/org/repo/dinghyfile", Org: "org", Repo: "repo", Path: "dinghyfile"}
+	client.EXPECT().UpsertPipeline(plank.Pipeline{Name: "first", ID: "1", Application: "testapp", Description: managed.Mark("rendered", source)}, "1", "").Return(nil).Times(1)
+
+	reports := r.Run(context.Background())
+	assert.Len(t, reports, 1)
+	assert.True(t, reports[0].Healed)
+}
+
+func TestReconcilerInSync(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	r, client := testReconciler(ctrl)
+	// Front50 has the pipelines as applied, with the marker
+	source := managed.Marker{URL: "https://github.com/org/repo/dinghyfile", Org: "org", Repo: "repo", Path: "dinghyfile"}
+	live := []plank.Pipeline{{Name: "first", ID: "1", Application: "testapp", Description: managed.Mark("rendered", source)}}
+	client.EXPECT().GetPipelines("testapp", "").Return(live, nil).Times(2)
+
+	report, err := r.Check("https://github.com/org/repo/dinghyfile")
+	assert.Nil(t, err)
+	assert.False(t, report.Drifted())
+
+	// renders recording the applied pipelines are compared with those
+	applied, _ := json.Marshal([]plank.Pipeline{{Name: "first", Application: "testapp", Description: managed.Mark("rendered", source), Locked: &plank.PipelineLockType{UI: true}}})
+	(*r.History.(*memoryHistory))[0].Pipelines = applied
+	report, err = r.Check("https://github.com/org/repo/dinghyfile")
+	assert.Nil(t, err)
+	assert.False(t, report.Drifted())
+
+	// not managed, nothing to compare with
+	report, err = r.Check("https://github.com/org/other/dinghyfile")
+	assert.Nil(t, err)
+	assert.Nil(t, report)
+}
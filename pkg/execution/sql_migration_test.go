@@ -0,0 +1,67 @@
+/*
+* Copyright 2026 Armory, Inc.
+
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+
+*    http://www.apache.org/licenses/LICENSE-2.0
+
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package execution
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/armory/dinghy/pkg/cache"
+	"github.com/armory/dinghy/pkg/database"
+	"github.com/armory/dinghy/pkg/settings/global"
+	"github.com/armory/dinghy/pkg/util"
+	"github.com/go-redis/redis"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedisToSQLiteMigration(t *testing.T) {
+	redisCache := cache.NewRedisCache(&redis.Options{
+		Addr:     fmt.Sprintf("%s:%s", util.GetenvOrDefault("REDIS_HOST", "redis"), util.GetenvOrDefault("REDIS_PORT", "6379")),
+		Password: util.GetenvOrDefault("REDIS_PASSWORD", ""),
+	}, logrus.New(), context.Background(), make(chan os.Signal, 1), false)
+	if _, err := redisCache.Client.Ping().Result(); err != nil {
+		t.Skip("Could not connect to Redis; skipping test")
+	}
+	redisCache.Clear()
+	redisCache.SetDeps("org/repo/dinghyfile", []string{"stage.module"})
+	redisCache.SetDeps("stage.module", []string{"wait.module"})
+	redisCache.SetRawData("org/repo/dinghyfile", `{"ref":"refs/heads/master"}`)
+
+	sqlClient, err := database.NewSQLiteClient(&database.SQLConfig{DbName: filepath.Join(t.TempDir(), "dinghy.db")}, logrus.New(), context.Background(), make(chan os.Signal, 1))
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	migration := &RedisToSQLMigration{
+		Settings:   &global.Settings{SQL: global.Sqlconfig{Enabled: true, Dialect: database.DialectSQLite}},
+		Logger:     logrus.New(),
+		RedisCache: redisCache,
+		SQLClient:  sqlClient,
+	}
+	migration.Execute()
+	redisCache.Clear()
+
+	assert.Equal(t, []string{"org/repo/dinghyfile"}, sqlClient.GetRoots("wait.module"))
+	rawData, err := sqlClient.GetRawData("org/repo/dinghyfile")
+	assert.Nil(t, err)
+	assert.Equal(t, `{"ref":"refs/heads/master"}`, rawData)
+	// only once
+	assert.False(t, migration.CanExecute())
+}
@@ -78,6 +78,11 @@ type DiffStatResponse struct {
 
 // Details of a single file changed
 type APIDiff struct {
+	// Status is one of added, removed, modified and renamed
+	Status string `json:"status"`
+	Old    struct {
+		Path string `json:"path"`
+	} `json:"old"`
 	New struct {
 		Path string `json:"path"`
 	} `json:"new"`
@@ -99,6 +104,7 @@ type Config struct {
 type Push struct {
 	Payload      WebhookPayload
 	ChangedFiles []string
+	RemovedPaths []string
 	Logger       log.DinghyLog
 	Pusher       string
 }
@@ -113,15 +119,17 @@ func NewPush(payload WebhookPayload, cfg Config) (*Push, error) {
 	p := &Push{
 		Payload:      payload,
 		ChangedFiles: make([]string, 0),
+		RemovedPaths: make([]string, 0),
 		Logger:       cfg.Logger,
 		Pusher:       payload.Actor,
 	}
 
 	changedFilesMap := map[string]bool{}
+	removedFilesMap := map[string]bool{}
 
 	for _, change := range p.changes() {
 		for page := 1; true; page++ {
-			changedFiles, nextPage, err := getFilesChanged(change.Old.Target.Hash, change.New.Target.Hash, page, cfg,
+			changedFiles, removedFiles, nextPage, err := getFilesChanged(change.Old.Target.Hash, change.New.Target.Hash, page, cfg,
 				payload.Repository.FullName)
 			if err != nil {
 				return nil, err
@@ -130,6 +138,9 @@ func NewPush(payload WebhookPayload, cfg Config) (*Push, error) {
 			for _, file := range changedFiles {
 				changedFilesMap[file] = true
 			}
+			for _, file := range removedFiles {
+				removedFilesMap[file] = true
+			}
 			if page == nextPage {
 				break
 			}
@@ -139,6 +150,11 @@ func NewPush(payload WebhookPayload, cfg Config) (*Push, error) {
 	for file := range changedFilesMap {
 		p.ChangedFiles = append(p.ChangedFiles, file)
 	}
+	for file := range removedFilesMap {
+		if !changedFilesMap[file] {
+			p.RemovedPaths = append(p.RemovedPaths, file)
+		}
+	}
 
 	return p, nil
 }
@@ -154,7 +170,7 @@ func (c *WebhookChange) IsMaster() bool {
 }
 
 func getFilesChanged(fromCommitHash, toCommitHash string, page int, cfg Config,
-	repoName string) (changedFiles []string, nextPage int, err error) {
+	repoName string) (changedFiles []string, removedFiles []string, nextPage int, err error) {
 
 	url := fmt.Sprintf(
 		`%s/repositories/%s/diffstat/%s`,
@@ -167,7 +183,7 @@ func getFilesChanged(fromCommitHash, toCommitHash string, page int, cfg Config,
 
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
-		return []string{}, page, err
+		return []string{}, []string{}, page, err
 	}
 
 	query := req.URL.Query()
@@ -186,10 +202,10 @@ func getFilesChanged(fromCommitHash, toCommitHash string, page int, cfg Config,
 	}
 	if err != nil {
 		cfg.Logger.Errorf("Error getting changes: %v", err)
-		return changedFiles, page, err
+		return changedFiles, removedFiles, page, err
 	}
 
-	changedFiles, hasNext, err := handleDiffstatResponse(resp, cfg.Logger)
+	changedFiles, removedFiles, hasNext, err := handleDiffstatResponse(resp, cfg.Logger)
 	if hasNext {
 		nextPage = page + 1
 	}
@@ -197,7 +213,7 @@ func getFilesChanged(fromCommitHash, toCommitHash string, page int, cfg Config,
 	return
 }
 
-func handleDiffstatResponse(resp *http.Response, logger log.DinghyLog) (changedFiles []string, hasNext bool, err error) {
+func handleDiffstatResponse(resp *http.Response, logger log.DinghyLog) (changedFiles []string, removedFiles []string, hasNext bool, err error) {
 	var apiResponse DiffStatResponse
 	respRaw, err := ioutil.ReadAll(resp.Body)
 	respString := string(respRaw)
@@ -205,13 +221,13 @@ func handleDiffstatResponse(resp *http.Response, logger log.DinghyLog) (changedF
 
 	if resp.StatusCode < 200 || resp.StatusCode > 299 {
 		logger.Errorf("Diffstat error: response status code %d\n", resp.StatusCode)
-		return []string{}, false, err
+		return []string{}, []string{}, false, err
 	}
 
 	err = json.Unmarshal(respRaw, &apiResponse)
 	if err != nil {
 		logger.Warnf("Got error parsing JSON response from Bitbucket query: %s", respRaw)
-		return []string{}, false, err
+		return []string{}, []string{}, false, err
 	}
 
 	if apiResponse.CurrentPage < apiResponse.NumberOfPages {
@@ -221,10 +237,17 @@ func handleDiffstatResponse(resp *http.Response, logger log.DinghyLog) (changedF
 	}
 
 	for _, diff := range apiResponse.Diffs {
+		switch diff.Status {
+		case "removed":
+			removedFiles = append(removedFiles, diff.Old.Path)
+			continue
+		case "renamed":
+			removedFiles = append(removedFiles, diff.Old.Path)
+		}
 		changedFiles = append(changedFiles, diff.New.Path)
 	}
 
-	return changedFiles, hasNext, nil
+	return changedFiles, removedFiles, hasNext, nil
 }
 
 // ContainsFile checks to see if a given file is in the push.
@@ -242,6 +265,11 @@ func (p *Push) Files() []string {
 	return p.ChangedFiles
 }
 
+// RemovedFiles returns a slice containing filenames that were removed
+func (p *Push) RemovedFiles() []string {
+	return p.RemovedPaths
+}
+
 // Repo returns the name of the repo.
 func (p *Push) Repo() string {
 	return p.Payload.Repository.Name
@@ -262,6 +262,40 @@ func TestNewPushFromFeatureBranchToMaster(t *testing.T) {
 	assert.True(t, contains(push.ChangedFiles, "dinghyfile"), "Error: expected dinghyfile found in push info")
 }
 
+func TestNewPushRemovedFiles(t *testing.T) {
+	webhookPayload := WebhookPayload{}
+	payloadString := fmt.Sprintf(webhookPayloadOneChange, "master", "master")
+	if err := json.NewDecoder(bytes.NewBufferString(payloadString)).Decode(&webhookPayload); err != nil {
+		t.Fatalf(err.Error())
+	}
+	diffStatResponse := `{
+  "pagelen": 3,
+  "values": [
+    {"status": "removed", "old": {"path": "apps/a/dinghyfile"}, "new": null},
+    {"status": "renamed", "old": {"path": "apps/b/dinghyfile"}, "new": {"path": "apps/c/dinghyfile"}},
+    {"status": "modified", "old": {"path": "Jenkinsfile"}, "new": {"path": "Jenkinsfile"}}
+  ],
+  "page": 1,
+  "size": 1
+}`
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		if _, err := res.Write([]byte(diffStatResponse)); err != nil {
+			t.Fatalf(err.Error())
+		}
+	}))
+	defer func() { testServer.Close() }()
+
+	push, err := NewPush(webhookPayload, Config{Endpoint: testServer.URL, Logger: dinghyfile.NewDinghylog()})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	assert.Equal(t, 2, len(push.ChangedFiles))
+	assert.True(t, contains(push.ChangedFiles, "apps/c/dinghyfile"), "Error: expected renamed dinghyfile found in push info")
+	assert.ElementsMatch(t, []string{"apps/a/dinghyfile", "apps/b/dinghyfile"}, push.RemovedFiles())
+}
+
 func contains(files []string, file string) bool {
 	for _, a := range files {
 		if a == file {
@@ -43,6 +43,11 @@ func (p *Push) Files() []string {
 	return p.FileNames
 }
 
+// RemovedFiles returns a slice containing filenames that were removed
+func (p *Push) RemovedFiles() []string {
+	return []string{}
+}
+
 // Repo returns the name of the repo.
 func (p *Push) Repo() string {
 	return p.RepoName
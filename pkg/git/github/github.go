@@ -163,6 +163,23 @@ func (g *Config) GetShaFromRawData(rawPushData []byte) string {
 	return sha
 }
 
+// ValidateToken checks the configured token by fetching the authenticated user
+func (g *Config) ValidateToken(ctx context.Context) error {
+	client, err := newGitHubClient(ctx, g.Endpoint, g.Token)
+	if err != nil {
+		return err
+	}
+
+	_, _, err = client.Users.Get(ctx, "")
+	if err != nil {
+		if e, ok := err.(*github.RateLimitError); ok {
+			return &util.GithubRateLimitErr{RateLimit: e.Rate.Limit, RateReset: e.Rate.Reset.String()}
+		}
+		return err
+	}
+	return nil
+}
+
 func (g *Config) GetEndpoint() string {
 	return g.Endpoint
 }
@@ -17,6 +17,7 @@
 package github
 
 import (
+	"github.com/armory/dinghy/pkg/git"
 	"github.com/armory/dinghy/pkg/log"
 	"strings"
 )
@@ -37,6 +38,7 @@ type Commit struct {
 	ID       string   `json:"id"`
 	Added    []string `json:"added"`
 	Modified []string `json:"modified"`
+	Removed  []string `json:"removed"`
 }
 
 // Repository is a repo received from Github webhook
@@ -92,6 +94,16 @@ func (p *Push) Files() []string {
 	return ret
 }
 
+// RemovedFiles returns a slice containing filenames that were removed, and
+// not added back by a later commit of the push
+func (p *Push) RemovedFiles() []string {
+	commits := make([]git.CommitFiles, 0, len(p.Commits))
+	for _, c := range p.Commits {
+		commits = append(commits, git.CommitFiles{Added: c.Added, Modified: c.Modified, Removed: c.Removed})
+	}
+	return git.RemovedFiles(commits)
+}
+
 // Repo returns the name of the repo.
 func (p *Push) Repo() string {
 	return p.Repository.Name
@@ -95,3 +95,17 @@ func TestIsBranch(t *testing.T) {
 		})
 	}
 }
+
+func TestRemovedFiles(t *testing.T) {
+	payload := `{"commits": [
+		{"id": "1", "removed": ["apps/a/dinghyfile", "apps/b/dinghyfile"]},
+		{"id": "2", "added": ["apps/b/dinghyfile"], "removed": ["README.md"]}
+	]}`
+	var p Push
+	if err := json.NewDecoder(bytes.NewBufferString(payload)).Decode(&p); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	assert.Equal(t, []string{"apps/a/dinghyfile", "README.md"}, p.RemovedFiles())
+	assert.Equal(t, []string{}, (&Push{}).RemovedFiles())
+}
@@ -17,6 +17,9 @@
 package gitlab
 
 import (
+	"context"
+
+	"github.com/armory/dinghy/pkg/git"
 	"github.com/armory/dinghy/pkg/log"
 	"github.com/armory/dinghy/pkg/settings/global"
 	gitlab "github.com/xanzy/go-gitlab"
@@ -68,6 +71,16 @@ func (p *Push) Files() []string {
 	return ret
 }
 
+// RemovedFiles returns a slice containing filenames that were removed, and
+// not added back by a later commit of the push
+func (p *Push) RemovedFiles() []string {
+	commits := make([]git.CommitFiles, 0, len(p.Event.Commits))
+	for _, c := range p.Event.Commits {
+		commits = append(commits, git.CommitFiles{Added: c.Added, Modified: c.Modified, Removed: c.Removed})
+	}
+	return git.RemovedFiles(commits)
+}
+
 // Repo returns the name of the repo.
 func (p *Push) Repo() string {
 	return p.Event.Project.Name
@@ -104,6 +117,16 @@ func (p *Push) PusherName() string {
 	return p.Event.UserName
 }
 
+// ValidateToken checks the configured token by fetching the current user
+func ValidateToken(ctx context.Context, cfg *global.Settings) error {
+	client, err := gitlab.NewClient(cfg.GitLabToken, gitlab.WithBaseURL(cfg.GitLabEndpoint))
+	if err != nil {
+		return err
+	}
+	_, _, err = client.Users.CurrentUser(gitlab.WithContext(ctx))
+	return err
+}
+
 // ParseWebhook parses the webhook into the struct and returns a file service
 // instance (and error)
 func (p *Push) ParseWebhook(cfg *global.Settings, body []byte) (FileService, error) {
@@ -234,6 +234,56 @@ func TestFiles(t *testing.T) {
 	}
 }
 
+func TestRemovedFiles(t *testing.T) {
+	testCases := map[string]struct {
+		push     *Push
+		expected []string
+	}{
+		"files removed": {
+			push: &Push{
+				Event: &gitlab.PushEvent{
+					Commits: commitsStruct{
+						{
+							Removed: []string{"removed-some-file", "removed-another-file"},
+						},
+					},
+				},
+			},
+			expected: []string{"removed-some-file", "removed-another-file"},
+		},
+		"file removed then added back": {
+			push: &Push{
+				Event: &gitlab.PushEvent{
+					Commits: commitsStruct{
+						{
+							Removed: []string{"removed-some-file", "removed-another-file"},
+						},
+						{
+							Added: []string{"removed-some-file"},
+						},
+					},
+				},
+			},
+			expected: []string{"removed-another-file"},
+		},
+		"Null commits": {
+			push: &Push{
+				Event: &gitlab.PushEvent{
+					Commits: nil,
+				},
+			},
+			expected: []string{},
+		},
+	}
+
+	for desc, tc := range testCases {
+		t.Run(desc, func(t *testing.T) {
+			actual := tc.push.RemovedFiles()
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
 func TestRepo(t *testing.T) {
 	testCases := map[string]struct {
 		push     *Push
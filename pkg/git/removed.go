@@ -0,0 +1,49 @@
+/*
+* Copyright 2026 Armory, Inc.
+
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+
+*    http://www.apache.org/licenses/LICENSE-2.0
+
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package git
+
+// CommitFiles are the files a commit of a push added, modified and removed
+type CommitFiles struct {
+	Added, Modified, Removed []string
+}
+
+// RemovedFiles returns the files removed by the commits of a push, oldest
+// first, that no later commit of the push adds back
+func RemovedFiles(commits []CommitFiles) []string {
+	ret := make([]string, 0)
+	for i, c := range commits {
+		for _, file := range c.Removed {
+			if !readdedLater(commits[i+1:], file) {
+				ret = append(ret, file)
+			}
+		}
+	}
+	return ret
+}
+
+func readdedLater(commits []CommitFiles, file string) bool {
+	for _, c := range commits {
+		for _, files := range [][]string{c.Added, c.Modified} {
+			for _, f := range files {
+				if f == file {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
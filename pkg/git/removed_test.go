@@ -0,0 +1,36 @@
+/*
+* Copyright 2026 Armory, Inc.
+
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+
+*    http://www.apache.org/licenses/LICENSE-2.0
+
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package git
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRemovedFiles(t *testing.T) {
+	added := make([]string, 1, 2)
+	added[0] = "apps/c/dinghyfile"
+	commits := []CommitFiles{
+		{Removed: []string{"apps/a/dinghyfile", "apps/b/dinghyfile"}},
+		{Added: added, Modified: []string{"apps/b/dinghyfile"}},
+		{Removed: []string{"apps/c/dinghyfile"}},
+	}
+	assert.Equal(t, []string{"apps/a/dinghyfile", "apps/c/dinghyfile"}, RemovedFiles(commits))
+	assert.Equal(t, 1, len(commits[1].Added), "the commits must not be modified")
+	assert.Equal(t, []string{}, RemovedFiles(nil))
+}
@@ -17,6 +17,7 @@
 package stash
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -32,6 +33,7 @@ import (
 type Push struct {
 	Payload       WebhookPayload
 	ChangedFiles  []string
+	DeletedFiles  []string
 	StashEndpoint string
 	StashUsername string
 	StashToken    string
@@ -83,6 +85,11 @@ type APIDiff struct {
 	Destination struct {
 		Path string `json:"toString"`
 	} `json:"path"`
+	Source struct {
+		Path string `json:"toString"`
+	} `json:"srcPath"`
+	// Type is one of ADD, COPY, DELETE, MODIFY and MOVE
+	Type string `json:"type"`
 }
 
 func (p *Push) getFilesChanged(fromCommitHash, toCommitHash string, start int) (nextStart int, err error) {
@@ -135,6 +142,13 @@ func (p *Push) getFilesChanged(fromCommitHash, toCommitHash string, start int) (
 		nextStart = body.NextPageStart
 	}
 	for _, diff := range body.Diffs {
+		switch diff.Type {
+		case "DELETE":
+			p.DeletedFiles = append(p.DeletedFiles, diff.Destination.Path)
+			continue
+		case "MOVE":
+			p.DeletedFiles = append(p.DeletedFiles, diff.Source.Path)
+		}
 		p.ChangedFiles = append(p.ChangedFiles, diff.Destination.Path)
 	}
 
@@ -149,11 +163,31 @@ type Config struct {
 	Logger   log.DinghyLog
 }
 
+// ValidateToken checks the configured credentials against the projects api
+func (c Config) ValidateToken(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/projects?limit=1", c.Endpoint), nil)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(c.Username, c.Token)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return fmt.Errorf("got %d from %s", resp.StatusCode, req.URL.String())
+	}
+	return nil
+}
+
 // NewPush creates a new Push
 func NewPush(payload WebhookPayload, cfg Config) (*Push, error) {
 	p := &Push{
 		Payload:      payload,
 		ChangedFiles: make([]string, 0),
+		DeletedFiles: make([]string, 0),
 
 		StashEndpoint: cfg.Endpoint,
 		StashToken:    cfg.Token,
@@ -191,6 +225,11 @@ func (p *Push) Files() []string {
 	return p.ChangedFiles
 }
 
+// RemovedFiles returns a slice containing filenames that were removed
+func (p *Push) RemovedFiles() []string {
+	return p.DeletedFiles
+}
+
 // Repo returns the name of the repo.
 func (p *Push) Repo() string {
 	return p.Payload.Repository.Slug
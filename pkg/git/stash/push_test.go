@@ -18,9 +18,13 @@ package stash
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"github.com/armory/dinghy/pkg/dinghyfile"
 	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 )
 
@@ -118,3 +122,41 @@ func TestIsMaster(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateToken(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != "dinghy" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"values":[]}`))
+	}))
+	defer ts.Close()
+
+	valid := Config{Username: "dinghy", Token: "secret", Endpoint: ts.URL}
+	assert.Nil(t, valid.ValidateToken(context.Background()))
+
+	invalid := Config{Username: "dinghy", Token: "wrong", Endpoint: ts.URL}
+	assert.NotNil(t, invalid.ValidateToken(context.Background()))
+}
+
+func TestNewPushRemovedFiles(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"isLastPage": true, "values": [
+			{"type": "MODIFY", "path": {"toString": "apps/a/dinghyfile"}},
+			{"type": "DELETE", "path": {"toString": "apps/b/dinghyfile"}},
+			{"type": "MOVE", "path": {"toString": "apps/d/dinghyfile"}, "srcPath": {"toString": "apps/c/dinghyfile"}}
+		]}`))
+	}))
+	defer ts.Close()
+
+	payload := WebhookPayload{BBSChanges: []WebhookChange{{RefID: "refs/heads/master", FromHash: "a", ToHash: "b"}}}
+	push, err := NewPush(payload, Config{Endpoint: ts.URL, Logger: dinghyfile.NewDinghylog()})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	assert.Equal(t, []string{"apps/a/dinghyfile", "apps/d/dinghyfile"}, push.Files())
+	assert.Equal(t, []string{"apps/b/dinghyfile", "apps/c/dinghyfile"}, push.RemovedFiles())
+}
@@ -0,0 +1,207 @@
+/*
+* Copyright 2026 Armory, Inc.
+
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+
+*    http://www.apache.org/licenses/LICENSE-2.0
+
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+// Package health keeps track of the dependencies dinghy needs to serve
+// webhooks (persistence, Spinnaker, git providers) so readiness can be
+// reported without taking the pod down.
+package health
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	StatusOk      = "ok"
+	StatusFailing = "failing"
+	StatusPending = "pending"
+
+	DefaultInterval = 10 * time.Second
+	DefaultTimeout  = 5 * time.Second
+)
+
+// Check verifies that a single dependency is reachable
+type Check interface {
+	Name() string
+	Check(ctx context.Context) error
+}
+
+type checkFunc struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+func (c checkFunc) Name() string {
+	return c.name
+}
+
+func (c checkFunc) Check(ctx context.Context) error {
+	return c.fn(ctx)
+}
+
+// NewCheck wraps fn into a Check called name
+func NewCheck(name string, fn func(ctx context.Context) error) Check {
+	return checkFunc{name: name, fn: fn}
+}
+
+// NewHTTPCheck returns a Check that succeeds when a GET on url answers with a 2xx
+func NewHTTPCheck(name, url string, client *http.Client) Check {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return NewCheck(name, func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return fmt.Errorf("%s returned status %d", url, resp.StatusCode)
+		}
+		return nil
+	})
+}
+
+// Result is the outcome of the latest run of a Check
+type Result struct {
+	Status      string     `json:"status"`
+	LatencyMs   int64      `json:"latencyMs"`
+	CheckedAt   *time.Time `json:"checkedAt,omitempty"`
+	LastError   string     `json:"lastError,omitempty"`
+	LastErrorAt *time.Time `json:"lastErrorAt,omitempty"`
+}
+
+// Monitor runs its checks periodically and keeps the latest results, so that
+// probes never wait on (or hammer) the dependencies themselves.
+type Monitor struct {
+	Interval time.Duration
+	Timeout  time.Duration
+	Logger   log.FieldLogger
+
+	mu      sync.RWMutex
+	checks  []Check
+	results map[string]Result
+}
+
+// NewMonitor creates a Monitor, zero durations fall back to the defaults
+func NewMonitor(interval, timeout time.Duration, logger log.FieldLogger) *Monitor {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return &Monitor{
+		Interval: interval,
+		Timeout:  timeout,
+		Logger:   logger,
+		results:  map[string]Result{},
+	}
+}
+
+// AddCheck registers a check, it is reported as pending until it first runs
+func (m *Monitor) AddCheck(c Check) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.checks = append(m.checks, c)
+	m.results[c.Name()] = Result{Status: StatusPending}
+}
+
+// Start runs the checks right away and then every Interval until ctx is done
+func (m *Monitor) Start(ctx context.Context) {
+	go func() {
+		timer := time.NewTicker(m.Interval)
+		defer timer.Stop()
+		m.RunChecks(ctx)
+		for {
+			select {
+			case <-timer.C:
+				m.RunChecks(ctx)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// RunChecks runs every check once, concurrently, and records the results
+func (m *Monitor) RunChecks(ctx context.Context) {
+	m.mu.RLock()
+	checks := make([]Check, len(m.checks))
+	copy(checks, m.checks)
+	m.mu.RUnlock()
+
+	var wg sync.WaitGroup
+	for _, c := range checks {
+		wg.Add(1)
+		go func(c Check) {
+			defer wg.Done()
+			m.run(ctx, c)
+		}(c)
+	}
+	wg.Wait()
+}
+
+func (m *Monitor) run(ctx context.Context, c Check) {
+	checkCtx, cancel := context.WithTimeout(ctx, m.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := c.Check(checkCtx)
+	now := time.Now().UTC()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	result := m.results[c.Name()]
+	result.LatencyMs = time.Since(start).Milliseconds()
+	result.CheckedAt = &now
+	if err != nil {
+		result.Status = StatusFailing
+		result.LastError = err.Error()
+		result.LastErrorAt = &now
+		if m.Logger != nil {
+			m.Logger.Warnf("readiness check %s failed: %s", c.Name(), err.Error())
+		}
+	} else {
+		result.Status = StatusOk
+	}
+	m.results[c.Name()] = result
+}
+
+// Ready reports whether every check passed on its latest run, along with a
+// copy of the results keyed by check name
+func (m *Monitor) Ready() (bool, map[string]Result) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	ready := true
+	results := make(map[string]Result, len(m.results))
+	for name, result := range m.results {
+		if result.Status != StatusOk {
+			ready = false
+		}
+		results[name] = result
+	}
+	return ready, results
+}
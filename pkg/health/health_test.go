@@ -0,0 +1,106 @@
+/*
+* Copyright 2026 Armory, Inc.
+
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+
+*    http://www.apache.org/licenses/LICENSE-2.0
+
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package health
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMonitorPendingIsNotReady(t *testing.T) {
+	m := NewMonitor(0, 0, nil)
+	m.AddCheck(NewCheck("redis", func(ctx context.Context) error { return nil }))
+
+	ready, results := m.Ready()
+	assert.False(t, ready)
+	assert.Equal(t, StatusPending, results["redis"].Status)
+}
+
+func TestMonitorRunChecks(t *testing.T) {
+	failing := true
+	m := NewMonitor(time.Minute, time.Second, nil)
+	m.AddCheck(NewCheck("redis", func(ctx context.Context) error { return nil }))
+	m.AddCheck(NewCheck("sql", func(ctx context.Context) error {
+		if failing {
+			return errors.New("connection refused")
+		}
+		return nil
+	}))
+
+	m.RunChecks(context.Background())
+	ready, results := m.Ready()
+	assert.False(t, ready)
+	assert.Equal(t, StatusOk, results["redis"].Status)
+	assert.Equal(t, StatusFailing, results["sql"].Status)
+	assert.Equal(t, "connection refused", results["sql"].LastError)
+	assert.NotNil(t, results["sql"].LastErrorAt)
+
+	// the last error is kept around after the dependency recovers
+	failing = false
+	m.RunChecks(context.Background())
+	ready, results = m.Ready()
+	assert.True(t, ready)
+	assert.Equal(t, StatusOk, results["sql"].Status)
+	assert.Equal(t, "connection refused", results["sql"].LastError)
+}
+
+func TestMonitorTimeout(t *testing.T) {
+	m := NewMonitor(time.Minute, 10*time.Millisecond, nil)
+	m.AddCheck(NewCheck("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}))
+
+	m.RunChecks(context.Background())
+	ready, results := m.Ready()
+	assert.False(t, ready)
+	assert.Equal(t, context.DeadlineExceeded.Error(), results["slow"].LastError)
+}
+
+func TestHTTPCheck(t *testing.T) {
+	cases := map[string]struct {
+		status  int
+		wantErr bool
+	}{
+		"healthy": {
+			status:  http.StatusOK,
+			wantErr: false,
+		},
+		"unhealthy": {
+			status:  http.StatusServiceUnavailable,
+			wantErr: true,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(c.status)
+			}))
+			defer ts.Close()
+
+			err := NewHTTPCheck("front50", ts.URL+"/health", nil).Check(context.Background())
+			assert.Equal(t, c.wantErr, err != nil)
+		})
+	}
+}
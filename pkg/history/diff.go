@@ -0,0 +1,189 @@
+/*
+* Copyright 2026 Armory, Inc.
+
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+
+*    http://www.apache.org/licenses/LICENSE-2.0
+
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+// Types of change
+const (
+	Added   = "added"
+	Removed = "removed"
+	Changed = "changed"
+)
+
+// Change is a value added, removed or changed at a path of a JSON document,
+// like stages[0].waitTime
+type Change struct {
+	Path string      `json:"path"`
+	Type string      `json:"type"`
+	From interface{} `json:"from,omitempty"`
+	To   interface{} `json:"to,omitempty"`
+}
+
+// PipelineDiff is a pipeline added, removed or changed between two renders
+type PipelineDiff struct {
+	Name    string   `json:"name"`
+	Type    string   `json:"type"`
+	Changes []Change `json:"changes,omitempty"`
+}
+
+// Version identifies a render in a diff
+type Version struct {
+	ID     string `json:"id,omitempty"`
+	Commit string `json:"commit"`
+	Date   int64  `json:"date"`
+	Pusher string `json:"pusher,omitempty"`
+}
+
+// Diff is what changed from a render of an application to another.
+// Dinghyfile lists the changes outside of the pipelines, compared by name.
+type Diff struct {
+	Application string         `json:"application"`
+	From        Version        `json:"from"`
+	To          Version        `json:"to"`
+	Dinghyfile  []Change       `json:"dinghyfile"`
+	Pipelines   []PipelineDiff `json:"pipelines"`
+}
+
+// Compare returns the diff between two renders. The pipelines upserted are
+// compared when both renders recorded them, the rendered ones otherwise.
+func Compare(from, to Render) (*Diff, error) {
+	fromDoc, fromPipelines, err := parseRender(from, to.Pipelines != nil)
+	if err != nil {
+		return nil, fmt.Errorf("render of %s: %v", from.Commit, err)
+	}
+	toDoc, toPipelines, err := parseRender(to, from.Pipelines != nil)
+	if err != nil {
+		return nil, fmt.Errorf("render of %s: %v", to.Commit, err)
+	}
+
+	d := &Diff{
+		Application: to.Application,
+		From:        Version{ID: from.ID, Commit: from.Commit, Date: from.Date, Pusher: from.Pusher},
+		To:          Version{ID: to.ID, Commit: to.Commit, Date: to.Date, Pusher: to.Pusher},
+		Dinghyfile:  diffValues("", fromDoc, toDoc, []Change{}),
+		Pipelines:   []PipelineDiff{},
+	}
+	for _, name := range unionKeys(fromPipelines, toPipelines) {
+		f, inFrom := fromPipelines[name]
+		t, inTo := toPipelines[name]
+		switch {
+		case !inFrom:
+			d.Pipelines = append(d.Pipelines, PipelineDiff{Name: name, Type: Added})
+		case !inTo:
+			d.Pipelines = append(d.Pipelines, PipelineDiff{Name: name, Type: Removed})
+		default:
+			if changes := diffValues("", f, t, nil); len(changes) > 0 {
+				d.Pipelines = append(d.Pipelines, PipelineDiff{Name: name, Type: Changed, Changes: changes})
+			}
+		}
+	}
+	return d, nil
+}
+
+// parseRender returns the rendered dinghyfile without its pipelines and the
+// pipelines by name, the upserted ones when recorded and upserted is true
+func parseRender(r Render, upserted bool) (map[string]interface{}, map[string]interface{}, error) {
+	doc := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(r.Dinghyfile), &doc); err != nil {
+		return nil, nil, err
+	}
+	pipelines, _ := doc["pipelines"].([]interface{})
+	delete(doc, "pipelines")
+	if upserted && r.Pipelines != nil {
+		pipelines = nil
+		if err := json.Unmarshal(r.Pipelines, &pipelines); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	byName := map[string]interface{}{}
+	for i, p := range pipelines {
+		name := "#" + strconv.Itoa(i)
+		if m, ok := p.(map[string]interface{}); ok {
+			if n, ok := m["name"].(string); ok {
+				name = n
+			}
+		}
+		byName[name] = p
+	}
+	return doc, byName, nil
+}
+
+func diffValues(path string, from, to interface{}, changes []Change) []Change {
+	switch f := from.(type) {
+	case map[string]interface{}:
+		if t, ok := to.(map[string]interface{}); ok {
+			for _, k := range unionKeys(f, t) {
+				child := k
+				if path != "" {
+					child = path + "." + k
+				}
+				fv, inFrom := f[k]
+				tv, inTo := t[k]
+				switch {
+				case !inFrom:
+					changes = append(changes, Change{Path: child, Type: Added, To: tv})
+				case !inTo:
+					changes = append(changes, Change{Path: child, Type: Removed, From: fv})
+				default:
+					changes = diffValues(child, fv, tv, changes)
+				}
+			}
+			return changes
+		}
+	case []interface{}:
+		if t, ok := to.([]interface{}); ok {
+			for i := 0; i < len(f) || i < len(t); i++ {
+				child := fmt.Sprintf("%s[%d]", path, i)
+				switch {
+				case i >= len(f):
+					changes = append(changes, Change{Path: child, Type: Added, To: t[i]})
+				case i >= len(t):
+					changes = append(changes, Change{Path: child, Type: Removed, From: f[i]})
+				default:
+					changes = diffValues(child, f[i], t[i], changes)
+				}
+			}
+			return changes
+		}
+	}
+	if !reflect.DeepEqual(from, to) {
+		changes = append(changes, Change{Path: path, Type: Changed, From: from, To: to})
+	}
+	return changes
+}
+
+func unionKeys(a, b map[string]interface{}) []string {
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,86 @@
+/*
+* Copyright 2026 Armory, Inc.
+
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+
+*    http://www.apache.org/licenses/LICENSE-2.0
+
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package history
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompare(t *testing.T) {
+	from := Render{
+		Application: "app", Commit: "a", Date: 1, Pusher: "alice",
+		Dinghyfile: `{"application":"app","spec":{"email":"a@example.com"},"pipelines":[
+			{"name":"deploy","stages":[{"type":"wait","waitTime":10}]},
+			{"name":"old","stages":[]}
+		]}`,
+	}
+	to := Render{
+		Application: "app", Commit: "b", Date: 2, Pusher: "bob",
+		Dinghyfile: `{"application":"app","spec":{"email":"b@example.com","description":"app"},"pipelines":[
+			{"name":"deploy","stages":[{"type":"wait","waitTime":30},{"type":"manualJudgment"}]},
+			{"name":"new","stages":[]}
+		]}`,
+	}
+
+	d, err := Compare(from, to)
+	assert.Nil(t, err)
+	assert.Equal(t, &Diff{
+		Application: "app",
+		From:        Version{Commit: "a", Date: 1, Pusher: "alice"},
+		To:          Version{Commit: "b", Date: 2, Pusher: "bob"},
+		Dinghyfile: []Change{
+			{Path: "spec.description", Type: Added, To: "app"},
+			{Path: "spec.email", Type: Changed, From: "a@example.com", To: "b@example.com"},
+		},
+		Pipelines: []PipelineDiff{
+			{Name: "deploy", Type: Changed, Changes: []Change{
+				{Path: "stages[0].waitTime", Type: Changed, From: float64(10), To: float64(30)},
+				{Path: "stages[1]", Type: Added, To: map[string]interface{}{"type": "manualJudgment"}},
+			}},
+			{Name: "new", Type: Added},
+			{Name: "old", Type: Removed},
+		},
+	}, d)
+
+	d, err = Compare(from, from)
+	assert.Nil(t, err)
+	assert.Empty(t, d.Dinghyfile)
+	assert.Empty(t, d.Pipelines)
+
+	_, err = Compare(from, Render{Commit: "c", Dinghyfile: "{"})
+	assert.EqualError(t, err, "render of c: unexpected end of JSON input")
+}
+
+func TestCompareUpserted(t *testing.T) {
+	from := Render{Commit: "a", Dinghyfile: `{"pipelines":[{"name":"deploy"}]}`, Pipelines: json.RawMessage(`[{"name":"deploy","id":"1","locked":{"ui":true}}]`)}
+	to := Render{Commit: "b", Dinghyfile: `{"pipelines":[{"name":"deploy"}]}`, Pipelines: json.RawMessage(`[{"name":"deploy","id":"1"}]`)}
+
+	d, err := Compare(from, to)
+	assert.Nil(t, err)
+	assert.Equal(t, []PipelineDiff{{Name: "deploy", Type: Changed, Changes: []Change{
+		{Path: "locked", Type: Removed, From: map[string]interface{}{"ui": true}},
+	}}}, d.Pipelines)
+
+	// renders recorded before the upserted pipelines compare what was rendered
+	to.Pipelines = nil
+	d, err = Compare(from, to)
+	assert.Nil(t, err)
+	assert.Empty(t, d.Pipelines)
+}
@@ -0,0 +1,100 @@
+/*
+* Copyright 2026 Armory, Inc.
+
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+
+*    http://www.apache.org/licenses/LICENSE-2.0
+
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+// Package history keeps the rendered dinghyfile of every successful apply and
+// the pipelines it upserted, so changes can be compared and an application
+// rolled back without touching git.
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+)
+
+// Render is a rendered dinghyfile successfully applied to an application. URL
+// is the dinghyfile as encoded by the downloader of its provider.
+type Render struct {
+	// ID identifies the render, a commit can be applied several times and by
+	// several dinghyfiles of the same application
+	ID          string `json:"id"`
+	Application string `json:"application"`
+	Commit      string `json:"commit"`
+	URL         string `json:"url,omitempty"`
+	Org         string `json:"org"`
+	Repo        string `json:"repo"`
+	Path        string `json:"path"`
+	Branch      string `json:"branch"`
+	Pusher      string `json:"pusher,omitempty"`
+	// Date is the unix time of the apply in milliseconds
+	Date       int64  `json:"date"`
+	Dinghyfile string `json:"dinghyfile,omitempty"`
+	// Pipelines are the pipelines upserted, as sent to Spinnaker
+	Pipelines json.RawMessage `json:"pipelines,omitempty"`
+	// RollbackOf is the id of the render a rollback applied again
+	RollbackOf string `json:"rollbackOf,omitempty"`
+}
+
+// NewID returns the id of the render of the dinghyfile at url and commit,
+// applied at date
+func NewID(date int64, commit, url string) string {
+	h := fnv.New32a()
+	h.Write([]byte(url))
+	if len(commit) > 7 {
+		commit = commit[:7]
+	}
+	return fmt.Sprintf("%d-%s-%08x", date, commit, h.Sum32())
+}
+
+// Store keeps the renders of every application, keyed by render id
+type Store interface {
+	// SaveRender records r under its id, renders are never overwritten
+	SaveRender(r Render) error
+	// ListRenders returns the renders of an application, newest first
+	ListRenders(application string) ([]Render, error)
+	// GetRender returns nil when nothing is recorded under the id
+	GetRender(application, id string) (*Render, error)
+	// PruneRenders deletes all but the newest keep renders of an application
+	PruneRenders(application string, keep int) error
+}
+
+// Lister is implemented by the stores able to list the applications they
+// keep renders of, to back them up
+type Lister interface {
+	ListApplications() ([]string, error)
+}
+
+// DefaultLimit is the number of renders kept per application when not configured
+const DefaultLimit = 100
+
+// Find returns the render of an application with the id ref, or else its
+// newest render of the commit ref, nil when there is none
+func Find(s Store, application, ref string) (*Render, error) {
+	r, err := s.GetRender(application, ref)
+	if err != nil || r != nil {
+		return r, err
+	}
+	renders, err := s.ListRenders(application)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range renders {
+		if r.Commit == ref {
+			return &r, nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,73 @@
+/*
+* Copyright 2026 Armory, Inc.
+
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+
+*    http://www.apache.org/licenses/LICENSE-2.0
+
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package history
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type memoryStore []Render
+
+func (m *memoryStore) SaveRender(r Render) error {
+	*m = append([]Render{r}, *m...)
+	return nil
+}
+
+func (m *memoryStore) ListRenders(application string) ([]Render, error) {
+	return *m, nil
+}
+
+func (m *memoryStore) GetRender(application, id string) (*Render, error) {
+	for _, r := range *m {
+		if r.ID == id {
+			return &r, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *memoryStore) PruneRenders(application string, keep int) error {
+	return nil
+}
+
+func TestNewID(t *testing.T) {
+	id := NewID(1760000000000, "4f1c2e9a8b7c", "https://github.com/org/repo/dinghyfile")
+	assert.Regexp(t, `^1760000000000-4f1c2e9-[0-9a-f]{8}$`, id)
+	assert.NotEqual(t, id, NewID(1760000000000, "4f1c2e9a8b7c", "https://github.com/org/repo/other/dinghyfile"))
+	assert.NotEqual(t, id, NewID(1760000000001, "4f1c2e9a8b7c", "https://github.com/org/repo/dinghyfile"))
+}
+
+func TestFind(t *testing.T) {
+	store := &memoryStore{}
+	store.SaveRender(Render{ID: "1-abc", Commit: "abc", Date: 1})
+	store.SaveRender(Render{ID: "2-abc", Commit: "abc", Date: 2})
+
+	r, err := Find(store, "app", "1-abc")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), r.Date)
+
+	// the newest render of the commit
+	r, err = Find(store, "app", "abc")
+	assert.Nil(t, err)
+	assert.Equal(t, "2-abc", r.ID)
+
+	r, err = Find(store, "app", "missing")
+	assert.Nil(t, err)
+	assert.Nil(t, r)
+}
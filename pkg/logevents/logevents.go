@@ -23,6 +23,14 @@ type LogEventsClient interface {
 	SaveLogEvent(logEvent LogEvent) error
 }
 
+// Restorer is implemented by the clients that can save a log event of the
+// past under its own date, to restore a backup
+type Restorer interface {
+	// RestoreLogEvent saves logEvent unless its TTL is over or it's there
+	// already, it reports whether it was saved
+	RestoreLogEvent(logEvent LogEvent) (bool, error)
+}
+
 type LogEvent struct {
 	Org                string   `json:"org" yaml:"org"`
 	Repo               string   `json:"repo" yaml:"repo"`
@@ -34,4 +42,6 @@ type LogEvent struct {
 	RawData            string   `json:"rawdata" yaml:"rawdata"`
 	RenderedDinghyfile string   `json:"rendereddinghyfile" yaml:"rendereddinghyfile"`
 	PullRequest        string   `json:"pullrequest" yaml:"pullrequest"`
+	// id is the primary key of the SQL row the event was listed from
+	id int
 }
@@ -16,6 +16,7 @@ package logevents
 import (
 	"encoding/json"
 	"github.com/armory/dinghy/pkg/cache"
+	"github.com/go-redis/redis"
 	log "github.com/sirupsen/logrus"
 	"strconv"
 	"time"
@@ -29,33 +30,27 @@ type LogEventRedisClient struct {
 func (c LogEventRedisClient) GetLogEvents() ([]LogEvent, error) {
 	loge := log.WithFields(log.Fields{"func": "GetLogEvents"})
 	key := cache.CompileKey("logEvent", "*")
-	var cursor uint64
 	result := []LogEvent{}
-	for {
-		keys, nextcursor, err := c.RedisClient.Client.Scan(cursor, key, 1000).Result()
-		cursor = nextcursor
-		if err != nil {
-			loge.WithFields(log.Fields{"operation": "scan key", "key": cache.CompileKey("logEvent*")}).Error(err)
-			return nil, err
-		}
+	err := c.RedisClient.ScanKeys(key, func(keys []string) error {
 		for _, key := range keys {
 			currentEventLog, errorNoKey := c.RedisClient.Client.Get(key).Result()
 			if errorNoKey != nil {
-				loge.WithFields(log.Fields{"operation": "get key", "key": key}).Error(err)
+				loge.WithFields(log.Fields{"operation": "get key", "key": key}).Error(errorNoKey)
 				continue
 			}
 			var logEvent LogEvent
 			errorUnmarshal := json.Unmarshal([]byte(currentEventLog), &logEvent)
 			if errorUnmarshal != nil {
-				loge.WithFields(log.Fields{"operation": "unmarshall key " + key, "content": currentEventLog}).Error(err)
+				loge.WithFields(log.Fields{"operation": "unmarshall key " + key, "content": currentEventLog}).Error(errorUnmarshal)
 				continue
 			}
 			result = append(result, logEvent)
 		}
-
-		if cursor == 0 {
-			break
-		}
+		return nil
+	})
+	if err != nil {
+		loge.WithFields(log.Fields{"operation": "scan key", "key": key}).Error(err)
+		return nil, err
 	}
 
 	return result, nil
@@ -85,10 +80,71 @@ func (c LogEventRedisClient) SaveLogEvent(logEvent LogEvent) error {
 		loge.WithFields(log.Fields{"operation": "marshall logEvent", "content": logEvent}).Error(err)
 		return err
 	}
-	key := cache.CompileKey("logEvent", strconv.FormatInt(milis, 10))
+	key := logEventKey(logEvent)
 	if _, err := c.RedisClient.Client.Set(key, logEventBytes, c.MinutesTTL*time.Minute).Result(); err != nil {
 		loge.WithFields(log.Fields{"operation": "set key", "key": key, "content": logEventBytes}).Error(err)
 		return err
 	}
 	return nil
 }
+
+// RestoreLogEvent saves logEvent under its own date for what remains of its
+// TTL, an event of the same repository and date is kept
+func (c LogEventRedisClient) RestoreLogEvent(logEvent LogEvent) (bool, error) {
+	ttl := time.Until(time.Unix(0, logEvent.Date*int64(time.Millisecond))) + c.MinutesTTL*time.Minute
+	if ttl <= 0 {
+		return false, nil
+	}
+	logEventBytes, err := json.Marshal(logEvent)
+	if err != nil {
+		return false, err
+	}
+	return c.RedisClient.Client.SetNX(logEventKey(logEvent), logEventBytes, ttl).Result()
+}
+
+// ListLogEvents returns the log events Redis didn't expire yet
+func (c LogEventRedisClient) ListLogEvents() ([]LogEvent, error) {
+	return c.GetLogEvents()
+}
+
+// LoadLogEvents returns the log events as listed, Redis lists them in full
+func (c LogEventRedisClient) LoadLogEvents(logEvents []LogEvent) ([]LogEvent, error) {
+	return logEvents, nil
+}
+
+// DeleteLogEvents deletes the log events of a repo at their date, one key at
+// a time for Redis Cluster
+func (c LogEventRedisClient) DeleteLogEvents(logEvents []LogEvent) error {
+	for _, e := range logEvents {
+		if err := c.RedisClient.Client.Del(logEventKey(e)).Err(); err != nil {
+			return err
+		}
+		if err := c.deleteLegacyLogEvent(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteLegacyLogEvent deletes the event saved under its date alone by older
+// versions, as long as it belongs to the same repo
+func (c LogEventRedisClient) deleteLegacyLogEvent(e LogEvent) error {
+	key := cache.CompileKey("logEvent", strconv.FormatInt(e.Date, 10))
+	saved, err := c.RedisClient.Client.Get(key).Result()
+	if err == redis.Nil {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	var legacy LogEvent
+	if json.Unmarshal([]byte(saved), &legacy) != nil || legacy.Org != e.Org || legacy.Repo != e.Repo {
+		return nil
+	}
+	return c.RedisClient.Client.Del(key).Err()
+}
+
+// logEventKey identifies a log event by its date and repository, like the
+// SQL backend does
+func logEventKey(e LogEvent) string {
+	return cache.CompileKey("logEvent", strconv.FormatInt(e.Date, 10), e.Org, e.Repo)
+}
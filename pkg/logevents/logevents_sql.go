@@ -15,10 +15,14 @@ package logevents
 
 import (
 	"github.com/armory/dinghy/pkg/database"
+	"gorm.io/gorm"
 	"strings"
 	"time"
 )
 
+// sqlBatchSize bounds the values of an IN clause
+const sqlBatchSize = 500
+
 type LogEventSQLClient struct {
 	MinutesTTL time.Duration
 	SQLClient  *database.SQLClient
@@ -98,3 +102,89 @@ func (c LogEventSQLClient) SaveLogEvent(logEvent LogEvent) error {
 	convert.Date = milis
 	return c.SQLClient.Client.Create(&convert).Error
 }
+
+// RestoreLogEvent saves logEvent under its own date unless it's older than
+// the TTL or the repository already has an event of that date
+func (c LogEventSQLClient) RestoreLogEvent(logEvent LogEvent) (bool, error) {
+	if logEvent.Date < (time.Now().UnixNano()-int64(c.MinutesTTL*time.Minute))/1000000 {
+		return false, nil
+	}
+	found := int64(0)
+	query := c.SQLClient.Client.Model(&LogEventSQL{}).Where("commitdate = ? AND org = ? AND repo = ?", logEvent.Date, logEvent.Org, logEvent.Repo)
+	if err := query.Count(&found).Error; err != nil || found > 0 {
+		return false, err
+	}
+	convert := logEvent.ToLogEventSQL()
+	if err := c.SQLClient.Client.Create(&convert).Error; err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ListLogEvents returns every log event, GetLogEvents hides the ones past
+// the TTL. Only the columns the retention decides on are read, not the
+// rendered dinghyfiles and raw data.
+func (c LogEventSQLClient) ListLogEvents() ([]LogEvent, error) {
+	queryLogEvents := []LogEventSQL{}
+	if err := c.SQLClient.Client.Select("id", "org", "repo", "commitdate", "status").Find(&queryLogEvents).Error; err != nil {
+		return nil, err
+	}
+	result := make([]LogEvent, 0, len(queryLogEvents))
+	for _, val := range queryLogEvents {
+		result = append(result, LogEvent{Org: val.Org, Repo: val.Repo, Date: val.Date, Status: val.Status, id: val.Id})
+	}
+	return result, nil
+}
+
+// LoadLogEvents reads the listed log events in full, newest first
+func (c LogEventSQLClient) LoadLogEvents(logEvents []LogEvent) ([]LogEvent, error) {
+	ids := logEventIDs(logEvents)
+	result := make([]LogEvent, 0, len(ids))
+	for start := 0; start < len(ids); start += sqlBatchSize {
+		queryLogEvents := []LogEventSQL{}
+		batch := ids[start:min(start+sqlBatchSize, len(ids))]
+		if err := c.SQLClient.Client.Where("id IN ?", batch).Order("commitdate desc").Find(&queryLogEvents).Error; err != nil {
+			return nil, err
+		}
+		for _, val := range queryLogEvents {
+			e := val.ToLogEvent()
+			e.id = val.Id
+			result = append(result, e)
+		}
+	}
+	return result, nil
+}
+
+// DeleteLogEvents deletes the log events listed by their primary key, the
+// others by the repo and date
+func (c LogEventSQLClient) DeleteLogEvents(logEvents []LogEvent) error {
+	ids := logEventIDs(logEvents)
+	return c.SQLClient.Client.Transaction(func(tx *gorm.DB) error {
+		for start := 0; start < len(ids); start += sqlBatchSize {
+			batch := ids[start:min(start+sqlBatchSize, len(ids))]
+			if err := tx.Where("id IN ?", batch).Delete(&LogEventSQL{}).Error; err != nil {
+				return err
+			}
+		}
+		for _, e := range logEvents {
+			if e.id != 0 {
+				continue
+			}
+			err := tx.Where("commitdate = ? AND org = ? AND repo = ?", e.Date, e.Org, e.Repo).Delete(&LogEventSQL{}).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func logEventIDs(logEvents []LogEvent) []int {
+	ids := make([]int, 0, len(logEvents))
+	for _, e := range logEvents {
+		if e.id != 0 {
+			ids = append(ids, e.id)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,219 @@
+/*
+* Copyright 2026 Armory, Inc.
+
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+
+*    http://www.apache.org/licenses/LICENSE-2.0
+
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package logevents
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	log "github.com/sirupsen/logrus"
+)
+
+const DefaultRetentionInterval = time.Hour
+
+var (
+	purgedLogEvents = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "dinghy_logevents_purged_total",
+		Help: "Log events deleted by the retention janitor",
+	})
+	archivedLogEvents = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "dinghy_logevents_archived_total",
+		Help: "Log events archived by the retention janitor before their deletion",
+	})
+)
+
+// Purger is implemented by the clients the retention janitor can purge
+type Purger interface {
+	// ListLogEvents returns every log event stored, whatever its age. Only
+	// the org, repo, date and status the retention decides on are loaded.
+	ListLogEvents() ([]LogEvent, error)
+	// LoadLogEvents returns listed log events in full, to archive them
+	LoadLogEvents(logEvents []LogEvent) ([]LogEvent, error)
+	// DeleteLogEvents deletes the log events of a repo at their date
+	DeleteLogEvents(logEvents []LogEvent) error
+}
+
+// Retention says which log events to keep. MaxPerRepo keeps the latest events
+// of every repo, unlimited when 0. When FailureMaxAge is set, failed events
+// are only deleted once older than it and don't count towards MaxPerRepo.
+type Retention struct {
+	MaxAge        time.Duration
+	MaxPerRepo    int
+	FailureMaxAge time.Duration
+}
+
+// Longest is how long the backends have to keep a log event for the
+// retention to decide of it
+func (r Retention) Longest() time.Duration {
+	if r.FailureMaxAge > r.MaxAge {
+		return r.FailureMaxAge
+	}
+	return r.MaxAge
+}
+
+// Expired returns the log events the retention deletes at now
+func (r Retention) Expired(logEvents []LogEvent, now time.Time) []LogEvent {
+	sorted := append([]LogEvent{}, logEvents...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Date > sorted[j].Date })
+
+	expired := []LogEvent{}
+	kept := map[string]int{}
+	for _, e := range sorted {
+		age := now.Sub(time.Unix(0, e.Date*int64(time.Millisecond)))
+		if r.FailureMaxAge > 0 && e.Status != "success" {
+			if age > r.FailureMaxAge {
+				expired = append(expired, e)
+			}
+			continue
+		}
+		repo := e.Org + "/" + e.Repo
+		if (r.MaxAge > 0 && age > r.MaxAge) || (r.MaxPerRepo > 0 && kept[repo] >= r.MaxPerRepo) {
+			expired = append(expired, e)
+			continue
+		}
+		kept[repo]++
+	}
+	return expired
+}
+
+// Locker is implemented by the backends shared by the replicas, so only one
+// of them purges every interval
+type Locker interface {
+	// TryLock takes the lock name for ttl, reporting whether it was free
+	TryLock(name string, ttl time.Duration) (bool, error)
+}
+
+// Janitor enforces the retention every Interval, archiving the log events to
+// ArchiveDir before deleting them when set. With a Locker only the replica
+// holding the lock purges, without one every replica does.
+type Janitor struct {
+	Retention  Retention
+	Interval   time.Duration
+	Client     Purger
+	ArchiveDir string
+	Locker     Locker
+	Logger     log.FieldLogger
+}
+
+// Start purges every Interval until ctx is done
+func (j *Janitor) Start(ctx context.Context) {
+	interval := j.Interval
+	if interval <= 0 {
+		interval = DefaultRetentionInterval
+	}
+	go func() {
+		timer := time.NewTicker(interval)
+		defer timer.Stop()
+		for {
+			select {
+			case <-timer.C:
+				if _, err := j.runLocked(time.Now(), interval); err != nil {
+					j.Logger.Errorf("Log event retention failed: %s", err.Error())
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// runLocked runs the janitor unless another replica took the lock in the last
+// interval
+func (j *Janitor) runLocked(now time.Time, interval time.Duration) (int, error) {
+	if j.Locker != nil {
+		locked, err := j.Locker.TryLock("logEventRetention", interval)
+		if err != nil {
+			return 0, fmt.Errorf("failed to take the retention lock: %v", err)
+		}
+		if !locked {
+			j.Logger.Debug("Log event retention is run by another replica")
+			return 0, nil
+		}
+	}
+	return j.Run(now)
+}
+
+// Run purges the log events expired at now once and returns how many were
+// deleted. Nothing is deleted when they can't be archived.
+func (j *Janitor) Run(now time.Time) (int, error) {
+	logEvents, err := j.Client.ListLogEvents()
+	if err != nil {
+		return 0, err
+	}
+	expired := j.Retention.Expired(logEvents, now)
+	if len(expired) == 0 {
+		return 0, nil
+	}
+	if j.ArchiveDir != "" {
+		full, err := j.Client.LoadLogEvents(expired)
+		if err != nil {
+			return 0, fmt.Errorf("failed to load the log events to archive: %v", err)
+		}
+		file, err := archive(j.ArchiveDir, full, now)
+		if err != nil {
+			return 0, fmt.Errorf("failed to archive the log events: %v", err)
+		}
+		archivedLogEvents.Add(float64(len(expired)))
+		j.Logger.Infof("Archived %d log events to %s", len(expired), file)
+	}
+	if err := j.Client.DeleteLogEvents(expired); err != nil {
+		return 0, err
+	}
+	purgedLogEvents.Add(float64(len(expired)))
+	j.Logger.Infof("Purged %d of %d log events", len(expired), len(logEvents))
+	return len(expired), nil
+}
+
+// archive writes the log events to a new gzipped JSON lines file of dir, the
+// host name keeps the replicas from writing the same file
+func archive(dir string, logEvents []LogEvent, now time.Time) (string, error) {
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return "", err
+	}
+	host, _ := os.Hostname()
+	name := filepath.Join(dir, fmt.Sprintf("logevents-%s-%s.jsonl.gz", now.UTC().Format("20060102T150405.000Z"), host))
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0640)
+	if err != nil {
+		return "", err
+	}
+	zw := gzip.NewWriter(f)
+	enc := json.NewEncoder(zw)
+	for _, e := range logEvents {
+		if err = enc.Encode(e); err != nil {
+			break
+		}
+	}
+	if errClose := zw.Close(); err == nil {
+		err = errClose
+	}
+	if errClose := f.Close(); err == nil {
+		err = errClose
+	}
+	if err != nil {
+		os.Remove(name)
+		return "", err
+	}
+	return name, nil
+}
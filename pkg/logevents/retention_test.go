@@ -0,0 +1,295 @@
+/*
+* Copyright 2026 Armory, Inc.
+
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+
+*    http://www.apache.org/licenses/LICENSE-2.0
+
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package logevents
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/armory/dinghy/pkg/cache"
+	"github.com/armory/dinghy/pkg/database"
+	"github.com/armory/dinghy/pkg/util"
+	"github.com/go-redis/redis"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+var now = time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+func at(age time.Duration) int64 {
+	return now.Add(-age).UnixNano() / int64(time.Millisecond)
+}
+
+func testEvents() []LogEvent {
+	return []LogEvent{
+		{Org: "org", Repo: "a", Date: at(time.Minute), Status: "success"},
+		{Org: "org", Repo: "a", Date: at(time.Hour), Status: "error"},
+		{Org: "org", Repo: "a", Date: at(2 * time.Hour), Status: "success"},
+		{Org: "org", Repo: "a", Date: at(3 * 24 * time.Hour), Status: "error"},
+		{Org: "org", Repo: "b", Date: at(3 * time.Hour), Status: "success"},
+	}
+}
+
+func dates(events []LogEvent) []int64 {
+	found := []int64{}
+	for _, e := range events {
+		found = append(found, e.Date)
+	}
+	return found
+}
+
+func TestRetentionExpired(t *testing.T) {
+	cases := map[string]struct {
+		retention Retention
+		expected  []int64
+	}{
+		"nothing": {
+			expected: []int64{},
+		},
+		"max age": {
+			retention: Retention{MaxAge: 90 * time.Minute},
+			expected:  []int64{at(2 * time.Hour), at(3 * time.Hour), at(3 * 24 * time.Hour)},
+		},
+		"max per repo": {
+			retention: Retention{MaxPerRepo: 1},
+			expected:  []int64{at(time.Hour), at(2 * time.Hour), at(3 * 24 * time.Hour)},
+		},
+		"failures kept longer": {
+			retention: Retention{MaxAge: 90 * time.Minute, MaxPerRepo: 1, FailureMaxAge: 24 * time.Hour},
+			expected:  []int64{at(2 * time.Hour), at(3 * time.Hour), at(3 * 24 * time.Hour)},
+		},
+		"failures kept longer than the count": {
+			retention: Retention{MaxPerRepo: 1, FailureMaxAge: 7 * 24 * time.Hour},
+			expected:  []int64{at(2 * time.Hour)},
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			assert.ElementsMatch(t, c.expected, dates(c.retention.Expired(testEvents(), now)))
+		})
+	}
+	assert.Equal(t, 24*time.Hour, Retention{MaxAge: time.Hour, FailureMaxAge: 24 * time.Hour}.Longest())
+	assert.Equal(t, time.Hour, Retention{MaxAge: time.Hour}.Longest())
+}
+
+type memoryPurger struct {
+	events []LogEvent
+	err    error
+}
+
+func (m *memoryPurger) ListLogEvents() ([]LogEvent, error) {
+	return m.events, m.err
+}
+
+func (m *memoryPurger) LoadLogEvents(logEvents []LogEvent) ([]LogEvent, error) {
+	return logEvents, nil
+}
+
+func (m *memoryPurger) DeleteLogEvents(logEvents []LogEvent) error {
+	deleted := map[int64]bool{}
+	for _, e := range logEvents {
+		deleted[e.Date] = true
+	}
+	kept := []LogEvent{}
+	for _, e := range m.events {
+		if !deleted[e.Date] {
+			kept = append(kept, e)
+		}
+	}
+	m.events = kept
+	return nil
+}
+
+func TestJanitor(t *testing.T) {
+	purger := &memoryPurger{events: testEvents()}
+	dir := filepath.Join(t.TempDir(), "archive")
+	janitor := &Janitor{Retention: Retention{MaxPerRepo: 2}, Client: purger, ArchiveDir: dir, Logger: log.New()}
+
+	purged, err := janitor.Run(now)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, purged)
+	assert.Equal(t, []int64{at(time.Minute), at(time.Hour), at(3 * time.Hour)}, dates(purger.events))
+
+	files, _ := filepath.Glob(filepath.Join(dir, "logevents-20261019T120000.000Z-*.jsonl.gz"))
+	if assert.Len(t, files, 1) {
+		assert.Equal(t, []int64{at(2 * time.Hour), at(3 * 24 * time.Hour)}, dates(readArchive(t, files[0])))
+	}
+
+	// nothing left to purge, no empty archive
+	purged, err = janitor.Run(now.Add(time.Second))
+	assert.Nil(t, err)
+	assert.Equal(t, 0, purged)
+	files, _ = filepath.Glob(filepath.Join(dir, "*"))
+	assert.Len(t, files, 1)
+}
+
+func readArchive(t *testing.T, file string) []LogEvent {
+	f, err := os.Open(file)
+	assert.Nil(t, err)
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	assert.Nil(t, err)
+	archived := []LogEvent{}
+	for scanner := bufio.NewScanner(zr); scanner.Scan(); {
+		var e LogEvent
+		assert.Nil(t, json.Unmarshal(scanner.Bytes(), &e))
+		archived = append(archived, e)
+	}
+	return archived
+}
+
+type memoryLocker struct {
+	held map[string]bool
+	err  error
+}
+
+func (m *memoryLocker) TryLock(name string, ttl time.Duration) (bool, error) {
+	if m.err != nil || m.held[name] {
+		return false, m.err
+	}
+	m.held[name] = true
+	return true, nil
+}
+
+func TestJanitorLock(t *testing.T) {
+	locker := &memoryLocker{held: map[string]bool{}}
+	first := &memoryPurger{events: testEvents()}
+	second := &memoryPurger{events: testEvents()}
+	replicas := []*Janitor{
+		{Retention: Retention{MaxPerRepo: 2}, Client: first, Locker: locker, Logger: log.New()},
+		{Retention: Retention{MaxPerRepo: 2}, Client: second, Locker: locker, Logger: log.New()},
+	}
+
+	purged, err := replicas[0].runLocked(now, time.Hour)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, purged)
+	// the other replica skips the interval
+	purged, err = replicas[1].runLocked(now, time.Hour)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, purged)
+	assert.Len(t, second.events, 5)
+
+	locker.err = errors.New("boom")
+	_, err = replicas[0].runLocked(now, time.Hour)
+	assert.NotNil(t, err)
+}
+
+func TestJanitorFailures(t *testing.T) {
+	purger := &memoryPurger{err: errors.New("boom")}
+	janitor := &Janitor{Retention: Retention{MaxPerRepo: 1}, Client: purger, Logger: log.New()}
+	_, err := janitor.Run(now)
+	assert.EqualError(t, err, "boom")
+
+	// the events stay when they can't be archived
+	purger = &memoryPurger{events: testEvents()}
+	notADir := filepath.Join(t.TempDir(), "file")
+	assert.Nil(t, os.WriteFile(notADir, nil, 0600))
+	janitor = &Janitor{Retention: Retention{MaxPerRepo: 1}, Client: purger, ArchiveDir: notADir, Logger: log.New()}
+	_, err = janitor.Run(now)
+	assert.NotNil(t, err)
+	assert.Len(t, purger.events, 5)
+}
+
+func TestRedisJanitor(t *testing.T) {
+	redisCache := cache.NewRedisCache(&redis.Options{
+		Addr:     fmt.Sprintf("%s:%s", util.GetenvOrDefault("REDIS_HOST", "redis"), util.GetenvOrDefault("REDIS_PORT", "6379")),
+		Password: util.GetenvOrDefault("REDIS_PASSWORD", ""),
+	}, log.New(), context.Background(), make(chan os.Signal, 1), false)
+	if err := redisCache.Ping(context.Background()); err != nil {
+		t.Skip("Could not connect to Redis; skipping test")
+	}
+	events := LogEventRedisClient{RedisClient: redisCache, MinutesTTL: 60}
+	clear := func() {
+		all, _ := events.ListLogEvents()
+		events.DeleteLogEvents(all)
+	}
+	clear()
+	defer clear()
+
+	now := time.Now()
+	for _, age := range []time.Duration{time.Minute, 2 * time.Minute} {
+		restored, err := events.RestoreLogEvent(LogEvent{Org: "org", Repo: "repo", Status: "success", Date: now.Add(-age).UnixNano() / int64(time.Millisecond)})
+		assert.Nil(t, err)
+		assert.True(t, restored)
+	}
+	// events of other repos at the same date are distinct
+	date := now.Add(-time.Minute).UnixNano() / int64(time.Millisecond)
+	restored, err := events.RestoreLogEvent(LogEvent{Org: "org", Repo: "other", Status: "success", Date: date})
+	assert.Nil(t, err)
+	assert.True(t, restored)
+	restored, err = events.RestoreLogEvent(LogEvent{Org: "org", Repo: "repo", Status: "failure", Date: date})
+	assert.Nil(t, err)
+	assert.False(t, restored)
+
+	janitor := &Janitor{Retention: Retention{MaxPerRepo: 1}, Client: events, Logger: log.New()}
+	purged, err := janitor.Run(time.Now())
+	assert.Nil(t, err)
+	assert.Equal(t, 1, purged)
+	left, err := events.ListLogEvents()
+	assert.Nil(t, err)
+	assert.Len(t, left, 2)
+}
+
+func TestSQLJanitor(t *testing.T) {
+	sqlClient, err := database.NewSQLiteClient(&database.SQLConfig{DbName: filepath.Join(t.TempDir(), "dinghy.db")}, log.New(), context.Background(), make(chan os.Signal, 1))
+	assert.Nil(t, err)
+	assert.Nil(t, sqlClient.Client.AutoMigrate(&LogEventSQL{}))
+	events := LogEventSQLClient{SQLClient: sqlClient, MinutesTTL: 60 * 24 * 365 * 10}
+	for _, e := range testEvents() {
+		e.RawData = fmt.Sprintf("raw %d", e.Date)
+		e.RenderedDinghyfile = "{}"
+		restored, err := events.RestoreLogEvent(e)
+		assert.Nil(t, err)
+		assert.True(t, restored)
+	}
+
+	// the retention decides on the listed columns only
+	listed, err := events.ListLogEvents()
+	assert.Nil(t, err)
+	for _, e := range listed {
+		assert.NotZero(t, e.id)
+		assert.Empty(t, e.RawData)
+	}
+
+	dir := filepath.Join(t.TempDir(), "archive")
+	janitor := &Janitor{Retention: Retention{MaxPerRepo: 2}, Client: events, ArchiveDir: dir, Logger: log.New()}
+	purged, err := janitor.Run(now)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, purged)
+
+	left, err := events.ListLogEvents()
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []int64{at(time.Minute), at(time.Hour), at(3 * time.Hour)}, dates(left))
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.jsonl.gz"))
+	if assert.Len(t, files, 1) {
+		archived := readArchive(t, files[0])
+		assert.Equal(t, []int64{at(2 * time.Hour), at(3 * 24 * time.Hour)}, dates(archived))
+		for _, e := range archived {
+			assert.Equal(t, fmt.Sprintf("raw %d", e.Date), e.RawData)
+			assert.Equal(t, "{}", e.RenderedDinghyfile)
+		}
+	}
+}
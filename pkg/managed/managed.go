@@ -0,0 +1,157 @@
+/*
+* Copyright 2026 Armory, Inc.
+
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+
+*    http://www.apache.org/licenses/LICENSE-2.0
+
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+// Package managed records what each dinghyfile last applied to Spinnaker, so
+// dinghy can tell the pipelines it manages apart from the ones created by hand.
+package managed
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Pipeline is a pipeline written to Spinnaker by a dinghyfile
+type Pipeline struct {
+	Name string `json:"name"`
+	// DinghyID is the optional key of the pipeline in its dinghyfile, it
+	// maps a renamed pipeline to the Front50 ID of its old name
+	DinghyID string `json:"dinghyId,omitempty"`
+}
+
+// Dinghyfile is the state a dinghyfile applied the last time it was processed,
+// it marks the pipelines listed as managed by that dinghyfile
+type Dinghyfile struct {
+	Org         string     `json:"org"`
+	Repo        string     `json:"repo"`
+	Path        string     `json:"path"`
+	Commit      string     `json:"commit,omitempty"`
+	Application string     `json:"application"`
+	Pipelines   []Pipeline `json:"pipelines"`
+	// Stale lists the pipelines a dry run would have deleted
+	Stale []Pipeline `json:"stale,omitempty"`
+}
+
+// Manages reports whether the pipeline of application was written by d
+func (d *Dinghyfile) Manages(application, pipeline string) bool {
+	if d == nil || d.Application != application {
+		return false
+	}
+	for _, p := range d.Pipelines {
+		if p.Name == pipeline {
+			return true
+		}
+	}
+	return false
+}
+
+// StalePipelines are the pipelines of an application a dinghyfile would have
+// deleted as stale, had deleteStalePipelinesDryRun been off
+type StalePipelines struct {
+	URL       string   `json:"url"`
+	Org       string   `json:"org"`
+	Repo      string   `json:"repo"`
+	Path      string   `json:"path"`
+	Commit    string   `json:"commit,omitempty"`
+	Pipelines []string `json:"pipelines"`
+}
+
+// Store keeps the managed state of every dinghyfile, keyed by dinghyfile url
+type Store interface {
+	// GetManaged returns nil when nothing is recorded for url
+	GetManaged(url string) (*Dinghyfile, error)
+	SetManaged(url string, d Dinghyfile) error
+	DeleteManaged(url string) error
+}
+
+// MarkerPrefix starts the line dinghy appends to the description of every
+// pipeline it writes, the rest of the line is the Marker as JSON
+const MarkerPrefix = "Managed by dinghy: "
+
+// Marker is the source metadata written into the pipelines a dinghyfile
+// applies. Only pipelines carrying the marker of a dinghyfile are ever
+// considered stale by it, pipelines created by hand are never deleted. It
+// leaves the commit out so unchanged pipelines aren't rewritten on every push,
+// the render history records it.
+type Marker struct {
+	URL  string `json:"url,omitempty"`
+	Org  string `json:"org"`
+	Repo string `json:"repo"`
+	Path string `json:"path"`
+}
+
+// SameSource reports whether both markers were written by the same dinghyfile
+func (m Marker) SameSource(o Marker) bool {
+	return m.Org == o.Org && m.Repo == o.Repo && m.Path == o.Path
+}
+
+// Mark returns description with the marker line, replacing any previous one
+func Mark(description string, m Marker) string {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return description
+	}
+	description = strings.TrimRight(unmark(description), "\n")
+	if description == "" {
+		return MarkerPrefix + string(data)
+	}
+	return description + "\n\n" + MarkerPrefix + string(data)
+}
+
+// ParseMarker returns the marker of description, false when the pipeline
+// wasn't written by dinghy
+func ParseMarker(description string) (Marker, bool) {
+	var m Marker
+	i := markerIndex(description)
+	if i < 0 {
+		return m, false
+	}
+	line := description[i+len(MarkerPrefix):]
+	if end := strings.IndexByte(line, '\n'); end >= 0 {
+		line = line[:end]
+	}
+	if err := json.Unmarshal([]byte(line), &m); err != nil || m.Path == "" {
+		return Marker{}, false
+	}
+	return m, true
+}
+
+// Unmark returns description without the marker line
+func Unmark(description string) string {
+	return strings.TrimRight(unmark(description), "\n")
+}
+
+func unmark(description string) string {
+	i := markerIndex(description)
+	if i < 0 {
+		return description
+	}
+	rest := ""
+	if end := strings.IndexByte(description[i:], '\n'); end >= 0 {
+		rest = description[i+end:]
+	}
+	return description[:i] + rest
+}
+
+// markerIndex finds the marker, which always starts a line
+func markerIndex(description string) int {
+	if strings.HasPrefix(description, MarkerPrefix) {
+		return 0
+	}
+	if i := strings.LastIndex(description, "\n"+MarkerPrefix); i >= 0 {
+		return i + 1
+	}
+	return -1
+}
@@ -0,0 +1,65 @@
+/*
+* Copyright 2026 Armory, Inc.
+
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+
+*    http://www.apache.org/licenses/LICENSE-2.0
+
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package managed
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManages(t *testing.T) {
+	d := &Dinghyfile{Application: "biff", Pipelines: []Pipeline{{Name: "deploy"}}}
+
+	assert.True(t, d.Manages("biff", "deploy"))
+	assert.False(t, d.Manages("biff", "manual"))
+	assert.False(t, d.Manages("other", "deploy"))
+
+	var none *Dinghyfile
+	assert.False(t, none.Manages("biff", "deploy"))
+}
+
+func TestMarker(t *testing.T) {
+	m := Marker{Org: "org", Repo: "repo", Path: "app/dinghyfile"}
+
+	marked := Mark("Deploys biff", m)
+	got, ok := ParseMarker(marked)
+	assert.True(t, ok)
+	assert.Equal(t, m, got)
+	assert.Contains(t, marked, "Deploys biff\n\n"+MarkerPrefix)
+	assert.Equal(t, "Deploys biff", Unmark(marked))
+	assert.Equal(t, "", Unmark(Mark("", m)))
+
+	// marking again replaces the marker, an unchanged one leaves the
+	// description as it was
+	assert.Equal(t, marked, Mark(marked, m))
+	m.URL = "https://github.com/org/repo/app/dinghyfile"
+	remarked := Mark(marked, m)
+	got, _ = ParseMarker(remarked)
+	assert.Equal(t, m.URL, got.URL)
+	assert.Equal(t, 1, strings.Count(remarked, MarkerPrefix))
+	assert.Equal(t, MarkerPrefix, Mark("", m)[:len(MarkerPrefix)])
+
+	_, ok = ParseMarker("Created by hand")
+	assert.False(t, ok)
+	_, ok = ParseMarker(MarkerPrefix + "not json")
+	assert.False(t, ok)
+
+	assert.True(t, m.SameSource(Marker{Org: "org", Repo: "repo", Path: "app/dinghyfile", URL: "other"}))
+	assert.False(t, m.SameSource(Marker{Org: "org", Repo: "repo", Path: "other/dinghyfile"}))
+}
@@ -0,0 +1,427 @@
+/*
+* Copyright 2026 Armory, Inc.
+
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+
+*    http://www.apache.org/licenses/LICENSE-2.0
+
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package notifiers
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/armory/dinghy/pkg/settings/global"
+	"github.com/armory/plank/v4"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	DefaultSMTPPort             = 25
+	DefaultSMTPTimeout          = 30 * time.Second
+	DefaultEmailRateLimitWindow = time.Hour
+	DefaultSnippetLines         = 5
+)
+
+// errorLineRegexps find the dinghyfile line of an error, the json and yaml
+// errors say "line N" and the template ones "name:N:"
+var errorLineRegexps = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)\bline (\d+)`),
+	regexp.MustCompile(`:(\d+):`),
+}
+
+var emailText = template.Must(template.New("text").Parse(`The {{ if .Validation }}validation of the {{ end }}dinghyfile {{ .Org }}/{{ .Repo }}/{{ .Path }} failed{{ with .Application }} for application {{ . }}{{ end }}.
+
+Error: {{ .Error }}
+{{ with .Commit }}Commit: {{ . }}
+{{ end }}{{ with .Pusher }}Pusher: {{ . }}
+{{ end }}{{ with .LogEventsURL }}Log events: {{ . }}
+{{ end }}{{ if .Snippet }}
+{{ range .Snippet }}{{ if .Error }}>{{ else }} {{ end }}{{ printf "%4d" .Number }} | {{ .Text }}
+{{ end }}{{ end }}`))
+
+var emailHTML = htmltemplate.Must(htmltemplate.New("html").Parse(`<html>
+<body>
+<p>The {{ if .Validation }}validation of the {{ end }}dinghyfile <b>{{ .Org }}/{{ .Repo }}/{{ .Path }}</b> failed{{ with .Application }} for application <b>{{ . }}</b>{{ end }}.</p>
+<pre>{{ .Error }}</pre>
+<table>
+{{ with .Commit }}<tr><td>Commit</td><td>{{ . }}</td></tr>
+{{ end }}{{ with .Pusher }}<tr><td>Pusher</td><td>{{ . }}</td></tr>
+{{ end }}{{ with .LogEventsURL }}<tr><td>Log events</td><td><a href="{{ . }}">{{ . }}</a></td></tr>
+{{ end }}</table>
+{{ if .Snippet }}<pre>
+{{ range .Snippet }}{{ if .Error }}<b style="background-color: #fdd">{{ printf "%4d" .Number }} | {{ .Text }}</b>{{ else }}{{ printf "%4d" .Number }} | {{ .Text }}{{ end }}
+{{ end }}</pre>
+{{ end }}</body>
+</html>
+`))
+
+// EmailNotifier emails the failures of the dinghyfiles to the owners of their
+// application and to the pusher
+type EmailNotifier struct {
+	// Addr of the SMTP server, host:port
+	Addr string
+	// Auth is nil without an SMTP user
+	Auth smtp.Auth
+	From string
+	// Timeout of the whole SMTP exchange of an email
+	Timeout time.Duration
+	// BaseURL of dinghy, the emails link to the log events of the push when set
+	BaseURL string
+	// OnValidation also emails the failures of pull request validations
+	OnValidation bool
+	// MaxPerApplication emails about an application in Window, unlimited when
+	// 0. Every replica counts the emails it sent on its own.
+	MaxPerApplication int
+	Window            time.Duration
+	// SnippetLines of the dinghyfile shown on each side of the error line
+	SnippetLines int
+	// Queue the emails go through, they're sent by the sender when nil
+	Queue  *Queue
+	Logger log.FieldLogger
+
+	mu   sync.Mutex
+	sent map[string][]time.Time
+}
+
+// NewEmailNotifier returns the email notifier of the settings
+func NewEmailNotifier(settings global.Notifiers, logger log.FieldLogger) (*EmailNotifier, error) {
+	if settings.Email.Host == "" {
+		return nil, errors.New("email notifications need an SMTP host")
+	}
+	if _, err := mail.ParseAddress(settings.Email.From); err != nil {
+		return nil, fmt.Errorf("invalid email sender %q: %s", settings.Email.From, err.Error())
+	}
+	port := settings.Email.Port
+	if port <= 0 {
+		port = DefaultSMTPPort
+	}
+	n := &EmailNotifier{
+		Addr:              net.JoinHostPort(settings.Email.Host, strconv.Itoa(port)),
+		From:              settings.Email.From,
+		Timeout:           time.Duration(settings.Email.TimeoutSeconds) * time.Second,
+		BaseURL:           settings.BaseURL,
+		OnValidation:      settings.Email.SendOnValidation,
+		MaxPerApplication: settings.Email.MaxPerApplication,
+		Window:            time.Duration(settings.Email.RateLimitMinutes) * time.Minute,
+		SnippetLines:      settings.Email.SnippetLines,
+		Queue:             NewQueue(DefaultQueueWorkers, DefaultQueueSize),
+		Logger:            logger,
+	}
+	if settings.Email.Username != "" {
+		n.Auth = smtp.PlainAuth("", settings.Email.Username, settings.Email.Password, settings.Email.Host)
+	}
+	if n.Timeout <= 0 {
+		n.Timeout = DefaultSMTPTimeout
+	}
+	if n.Window <= 0 {
+		n.Window = DefaultEmailRateLimitWindow
+	}
+	if n.SnippetLines <= 0 {
+		n.SnippetLines = DefaultSnippetLines
+	}
+	return n, nil
+}
+
+// SendSuccess doesn't email anything, only failures are
+func (n *EmailNotifier) SendSuccess(org, repo, path string, notifications plank.NotificationsType, content map[string]interface{}) {
+}
+
+func (n *EmailNotifier) SendFailure(org, repo, path string, err error, notifications plank.NotificationsType, content map[string]interface{}) {
+	application, _ := content[ApplicationContent].(string)
+	source := org + "/" + repo + "/" + path
+	recipients := EmailRecipients(notifications, content)
+	if len(recipients) == 0 {
+		n.Logger.Infof("No recipient to email the failure of %s to", source)
+		return
+	}
+	key := application
+	if key == "" {
+		key = source
+	}
+	if !n.allow(key, time.Now()) {
+		n.Logger.Infof("Not emailing the failure of %s, %d emails about %s were sent in the last %s", source, n.MaxPerApplication, key, n.Window)
+		return
+	}
+
+	msg, errMsg := n.message(org, repo, path, err, recipients, content)
+	if errMsg != nil {
+		n.Logger.Errorf("Could not write the email of the failure of %s: %s", source, errMsg.Error())
+		return
+	}
+	send := func() {
+		if errSend := n.sendMail(recipients, msg); errSend != nil {
+			n.Logger.Warnf("Could not email the failure of %s: %s", source, errSend.Error())
+		}
+	}
+	if n.Queue == nil {
+		send()
+	} else if !n.Queue.Submit(send) {
+		n.Logger.Warnf("Could not email the failure of %s: too many emails waiting", source)
+	}
+}
+
+// sendMail is smtp.SendMail with a deadline, a stuck SMTP server would hold a
+// worker forever otherwise
+func (n *EmailNotifier) sendMail(to []string, msg []byte) error {
+	timeout := n.Timeout
+	if timeout <= 0 {
+		timeout = DefaultSMTPTimeout
+	}
+	conn, err := net.DialTimeout("tcp", n.Addr, timeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		return err
+	}
+	host, _, _ := net.SplitHostPort(n.Addr)
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if n.Auth != nil {
+		if ok, _ := c.Extension("AUTH"); !ok {
+			return errors.New("the SMTP server doesn't support authentication")
+		}
+		if err := c.Auth(n.Auth); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(n.From); err != nil {
+		return err
+	}
+	for _, address := range to {
+		if err := c.Rcpt(address); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+func (n *EmailNotifier) SendOnValidation() bool {
+	return n.OnValidation
+}
+
+// allow says if one more email about key can be sent at now, recording it
+func (n *EmailNotifier) allow(key string, now time.Time) bool {
+	if n.MaxPerApplication <= 0 {
+		return true
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.sent == nil {
+		n.sent = map[string][]time.Time{}
+	}
+	recent := []time.Time{}
+	for _, t := range n.sent[key] {
+		if now.Sub(t) < n.Window {
+			recent = append(recent, t)
+		}
+	}
+	if len(recent) >= n.MaxPerApplication {
+		n.sent[key] = recent
+		return false
+	}
+	n.sent[key] = append(recent, now)
+	return true
+}
+
+type emailData struct {
+	Org, Repo, Path string
+	Application     string
+	Validation      bool
+	Error           string
+	Commit, Pusher  string
+	LogEventsURL    string
+	Snippet         []SnippetLine
+}
+
+// message returns the multipart email of a failure, in plain text and HTML
+func (n *EmailNotifier) message(org, repo, path string, err error, recipients []string, content map[string]interface{}) ([]byte, error) {
+	data := emailData{Org: org, Repo: repo, Path: path}
+	data.Application, _ = content[ApplicationContent].(string)
+	data.Commit, _ = content[CommitContent].(string)
+	data.Pusher, _ = content[PusherContent].(string)
+	data.Validation, _ = content[ValidationContent].(bool)
+	data.LogEventsURL = logEventsLink(n.BaseURL, org, repo, data.Commit)
+	if err != nil {
+		data.Error = err.Error()
+	}
+	if dinghyfile, _ := content[DinghyfileContent].(string); dinghyfile != "" {
+		data.Snippet = Snippet(dinghyfile, err, n.SnippetLines)
+	}
+
+	var body bytes.Buffer
+	parts := multipart.NewWriter(&body)
+	for _, part := range []struct {
+		contentType string
+		execute     func(*quotedprintable.Writer) error
+	}{
+		{"text/plain; charset=utf-8", func(w *quotedprintable.Writer) error { return emailText.Execute(w, data) }},
+		{"text/html; charset=utf-8", func(w *quotedprintable.Writer) error { return emailHTML.Execute(w, data) }},
+	} {
+		pw, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(pw)
+		if err := part.execute(qp); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", n.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(recipients, ", "))
+	subject := fmt.Sprintf("Dinghyfile %s/%s/%s failed", org, repo, path)
+	if data.Validation {
+		subject = fmt.Sprintf("Validation of dinghyfile %s/%s/%s failed", org, repo, path)
+	}
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&msg, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&msg, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", parts.Boundary())
+	msg.Write(body.Bytes())
+	return msg.Bytes(), nil
+}
+
+// EmailRecipients returns the addresses a failure is emailed to: the email of
+// the application, its email notifications routing the failure and the pusher's
+func EmailRecipients(notifications plank.NotificationsType, content map[string]interface{}) []string {
+	candidates := []string{}
+	if email, _ := content[ApplicationEmailContent].(string); email != "" {
+		candidates = append(candidates, strings.Split(email, ",")...)
+	}
+	for _, address := range Route(notifications, "email", When(true, content)) {
+		candidates = append(candidates, strings.Split(address, ",")...)
+	}
+	candidates = append(candidates, pusherEmail(content))
+
+	recipients := []string{}
+	seen := map[string]bool{}
+	for _, c := range candidates {
+		address, err := mail.ParseAddress(strings.TrimSpace(c))
+		if err != nil || seen[strings.ToLower(address.Address)] {
+			continue
+		}
+		seen[strings.ToLower(address.Address)] = true
+		recipients = append(recipients, address.Address)
+	}
+	return recipients
+}
+
+// pusherEmail returns the email of the pusher, from the push data of github,
+// gitlab or bitbucket server
+func pusherEmail(content map[string]interface{}) string {
+	if pusher, _ := content[PusherContent].(string); strings.Contains(pusher, "@") {
+		return pusher
+	}
+	raw, _ := content[RawDataContent].(map[string]interface{})
+	if pusher, ok := raw["pusher"].(map[string]interface{}); ok {
+		if email, _ := pusher["email"].(string); email != "" {
+			return email
+		}
+	}
+	if email, _ := raw["user_email"].(string); email != "" {
+		return email
+	}
+	if actor, ok := raw["actor"].(map[string]interface{}); ok {
+		if email, _ := actor["emailAddress"].(string); email != "" {
+			return email
+		}
+	}
+	return ""
+}
+
+// SnippetLine is a numbered line of a dinghyfile, Error is set on the line
+// the error is about
+type SnippetLine struct {
+	Number int
+	Text   string
+	Error  bool
+}
+
+// Snippet returns the lines of a dinghyfile around the line an error is
+// about, or its first lines when the error says no line
+func Snippet(dinghyfile string, err error, context int) []SnippetLine {
+	lines := strings.Split(strings.TrimRight(dinghyfile, "\n"), "\n")
+	errorLine := 0
+	if err != nil {
+		for _, re := range errorLineRegexps {
+			if m := re.FindStringSubmatch(err.Error()); m != nil {
+				errorLine, _ = strconv.Atoi(m[1])
+				break
+			}
+		}
+	}
+
+	start, end := 0, 2*context+1
+	if errorLine >= 1 && errorLine <= len(lines) {
+		start, end = errorLine-1-context, errorLine+context
+	} else {
+		errorLine = 0
+	}
+	if start < 0 {
+		start = 0
+	}
+	if end > len(lines) {
+		end = len(lines)
+	}
+	snippet := []SnippetLine{}
+	for i := start; i < end; i++ {
+		snippet = append(snippet, SnippetLine{Number: i + 1, Text: lines[i], Error: i+1 == errorLine})
+	}
+	return snippet
+}
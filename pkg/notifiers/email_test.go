@@ -0,0 +1,302 @@
+/*
+* Copyright 2026 Armory, Inc.
+
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+
+*    http://www.apache.org/licenses/LICENSE-2.0
+
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package notifiers
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/armory/dinghy/pkg/mock"
+	"github.com/armory/dinghy/pkg/settings/global"
+	"github.com/armory/plank/v4"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+type smtpMessage struct {
+	From string
+	To   []string
+	Data string
+}
+
+// smtpStandIn is an SMTP server recording the messages it receives
+func smtpStandIn(t *testing.T) (string, int, func() []smtpMessage) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	var mu sync.Mutex
+	messages := []smtpMessage{}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				c := textproto.NewConn(conn)
+				defer c.Close()
+				msg := smtpMessage{}
+				c.PrintfLine("220 localhost ESMTP")
+				for {
+					line, err := c.ReadLine()
+					if err != nil {
+						return
+					}
+					verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+					switch verb {
+					case "EHLO", "HELO":
+						c.PrintfLine("250 localhost")
+					case "MAIL":
+						msg.From = strings.Trim(strings.TrimPrefix(line, "MAIL FROM:"), "<>")
+						c.PrintfLine("250 OK")
+					case "RCPT":
+						msg.To = append(msg.To, strings.Trim(strings.TrimPrefix(line, "RCPT TO:"), "<>"))
+						c.PrintfLine("250 OK")
+					case "DATA":
+						c.PrintfLine("354 go ahead")
+						data, _ := io.ReadAll(c.DotReader())
+						msg.Data = string(data)
+						mu.Lock()
+						messages = append(messages, msg)
+						mu.Unlock()
+						msg = smtpMessage{}
+						c.PrintfLine("250 OK")
+					case "QUIT":
+						c.PrintfLine("221 bye")
+						return
+					default:
+						c.PrintfLine("250 OK")
+					}
+				}
+			}()
+		}
+	}()
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	p, _ := strconv.Atoi(port)
+	return host, p, func() []smtpMessage {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]smtpMessage{}, messages...)
+	}
+}
+
+// readEmail returns the subject and the decoded parts of an email by content type
+func readEmail(t *testing.T, data string) (string, map[string]string) {
+	msg, err := mail.ReadMessage(strings.NewReader(data))
+	assert.Nil(t, err)
+	subject, _ := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	_, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	assert.Nil(t, err)
+
+	parts := map[string]string{}
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err != nil {
+			break
+		}
+		body, _ := io.ReadAll(part)
+		mediaType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		parts[mediaType] = string(body)
+	}
+	return subject, parts
+}
+
+func TestEmailSendFailure(t *testing.T) {
+	host, port, messages := smtpStandIn(t)
+	n, err := NewEmailNotifier(global.Notifiers{
+		BaseURL: "https://dinghy.example.com",
+		Email:   global.Email{Enabled: true, Host: host, Port: port, From: "dinghy@example.com", SnippetLines: 1},
+	}, nil)
+	assert.Nil(t, err)
+
+	notifications := plank.NotificationsType{
+		"email": []interface{}{
+			map[string]interface{}{"address": "team@example.com, OWNERS@example.com", "type": "email", "when": []interface{}{"dinghy.failure"}},
+			map[string]interface{}{"address": "pipelines@example.com", "type": "email", "when": []interface{}{"pipeline.failed"}},
+		},
+	}
+	n.SendFailure("org", "repo", "dinghyfile", errors.New("Error in line 3, char 4: invalid character"), notifications, map[string]interface{}{
+		ApplicationContent:      "app",
+		ApplicationEmailContent: "owners@example.com",
+		CommitContent:           "abc",
+		PusherContent:           "octocat",
+		RawDataContent:          map[string]interface{}{"pusher": map[string]interface{}{"name": "octocat", "email": "octocat@example.com"}},
+		DinghyfileContent:       "{\n  \"application\": \"app\",\n  \"pipelines\": <x>\n}\n",
+	})
+	n.Queue.Wait()
+
+	got := messages()
+	assert.Len(t, got, 1)
+	assert.Equal(t, "dinghy@example.com", got[0].From)
+	assert.Equal(t, []string{"owners@example.com", "team@example.com", "octocat@example.com"}, got[0].To)
+
+	subject, parts := readEmail(t, got[0].Data)
+	assert.Equal(t, "Dinghyfile org/repo/dinghyfile failed", subject)
+	assert.Equal(t, `The dinghyfile org/repo/dinghyfile failed for application app.
+
+Error: Error in line 3, char 4: invalid character
+Commit: abc
+Pusher: octocat
+Log events: https://dinghy.example.com/v1/logevents?commit=abc&org=org&repo=repo
+
+    2 |   "application": "app",
+>   3 |   "pipelines": <x>
+    4 | }
+`, parts["text/plain"])
+	assert.Contains(t, parts["text/html"], `<b style="background-color: #fdd">   3 |   &#34;pipelines&#34;: &lt;x&gt;</b>`)
+	assert.Contains(t, parts["text/html"], `<a href="https://dinghy.example.com/v1/logevents?commit=abc&amp;org=org&amp;repo=repo">`)
+}
+
+func TestEmailRateLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	host, port, messages := smtpStandIn(t)
+	logger := mock.NewMockFieldLogger(ctrl)
+	logger.EXPECT().Infof("Not emailing the failure of %s, %d emails about %s were sent in the last %s", "org/repo/dinghyfile", 1, "app", DefaultEmailRateLimitWindow)
+	logger.EXPECT().Infof("No recipient to email the failure of %s to", "org/repo/dinghyfile")
+
+	n, err := NewEmailNotifier(global.Notifiers{
+		Email: global.Email{Enabled: true, Host: host, Port: port, From: "dinghy@example.com", MaxPerApplication: 1},
+	}, logger)
+	assert.Nil(t, err)
+
+	content := map[string]interface{}{ApplicationContent: "app", ApplicationEmailContent: "owners@example.com"}
+	n.SendFailure("org", "repo", "dinghyfile", errors.New("boom"), nil, content)
+	n.SendFailure("org", "repo", "dinghyfile", errors.New("boom"), nil, content)
+	content[ApplicationContent] = "other"
+	n.SendFailure("org", "repo", "dinghyfile", errors.New("boom"), nil, content)
+	n.SendFailure("org", "repo", "dinghyfile", errors.New("boom"), nil, map[string]interface{}{PusherContent: "octocat"})
+	n.SendSuccess("org", "repo", "dinghyfile", nil, content)
+	n.Queue.Wait()
+	assert.Len(t, messages(), 2)
+}
+
+func TestEmailValidation(t *testing.T) {
+	host, port, messages := smtpStandIn(t)
+	n, err := NewEmailNotifier(global.Notifiers{
+		Email: global.Email{Enabled: true, Host: host, Port: port, From: "dinghy@example.com"},
+	}, nil)
+	assert.Nil(t, err)
+
+	notifications := plank.NotificationsType{
+		"email": []interface{}{
+			map[string]interface{}{"address": "failures@example.com", "when": []interface{}{"dinghy.failure"}},
+			map[string]interface{}{"address": "reviews@example.com", "when": []interface{}{"dinghy.validation"}},
+		},
+	}
+	n.SendFailure("org", "repo", "dinghyfile", errors.New("boom"), notifications, map[string]interface{}{ValidationContent: true})
+	n.Queue.Wait()
+
+	got := messages()
+	assert.Len(t, got, 1)
+	assert.Equal(t, []string{"reviews@example.com"}, got[0].To)
+	subject, parts := readEmail(t, got[0].Data)
+	assert.Equal(t, "Validation of dinghyfile org/repo/dinghyfile failed", subject)
+	assert.True(t, strings.HasPrefix(parts["text/plain"], "The validation of the dinghyfile org/repo/dinghyfile failed."))
+}
+
+func TestEmailTimeout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// the server accepts the connection and never greets
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				io.Copy(io.Discard, conn)
+				conn.Close()
+			}()
+		}
+	}()
+
+	logger := mock.NewMockFieldLogger(ctrl)
+	logger.EXPECT().Warnf("Could not email the failure of %s: %s", "org/repo/dinghyfile", gomock.Any())
+	n, err := NewEmailNotifier(global.Notifiers{
+		Email: global.Email{Enabled: true, Host: "127.0.0.1", From: "dinghy@example.com"},
+	}, logger)
+	assert.Nil(t, err)
+	n.Addr = listener.Addr().String()
+	n.Timeout = 50 * time.Millisecond
+
+	start := time.Now()
+	n.SendFailure("org", "repo", "dinghyfile", errors.New("boom"), nil, map[string]interface{}{PusherContent: "octocat@example.com"})
+	n.Queue.Wait()
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestEmailRecipients(t *testing.T) {
+	assert.Equal(t, []string{"gitlab@example.com"}, EmailRecipients(nil, map[string]interface{}{
+		RawDataContent: map[string]interface{}{"user_email": "gitlab@example.com"},
+	}))
+	assert.Equal(t, []string{"stash@example.com"}, EmailRecipients(nil, map[string]interface{}{
+		RawDataContent: map[string]interface{}{"actor": map[string]interface{}{"emailAddress": "stash@example.com"}},
+	}))
+	assert.Equal(t, []string{"pusher@example.com"}, EmailRecipients(nil, map[string]interface{}{
+		PusherContent:           "pusher@example.com",
+		ApplicationEmailContent: "not an email",
+	}))
+	assert.Equal(t, []string{}, EmailRecipients(nil, map[string]interface{}{}))
+}
+
+func TestSnippet(t *testing.T) {
+	dinghyfile := "1\n2\n3\n4\n5\n6\n7\n"
+	assert.Equal(t, []SnippetLine{{5, "5", false}, {6, "6", true}, {7, "7", false}},
+		Snippet(dinghyfile, errors.New("template: dinghy-render:6: function \"foo\" not defined"), 1))
+	assert.Equal(t, []SnippetLine{{1, "1", true}, {2, "2", false}},
+		Snippet(dinghyfile, errors.New("yaml: line 1: did not find expected key"), 1))
+	assert.Equal(t, []SnippetLine{{1, "1", false}, {2, "2", false}, {3, "3", false}},
+		Snippet(dinghyfile, errors.New("malformed json"), 1))
+	assert.Equal(t, []SnippetLine{{1, "1", false}, {2, "2", false}, {3, "3", false}},
+		Snippet(dinghyfile, errors.New("Error in line 42"), 1))
+}
+
+func TestNewEmailNotifierErrors(t *testing.T) {
+	_, err := NewEmailNotifier(global.Notifiers{Email: global.Email{From: "dinghy@example.com"}}, nil)
+	assert.EqualError(t, err, "email notifications need an SMTP host")
+
+	_, err = NewEmailNotifier(global.Notifiers{Email: global.Email{Host: "localhost"}}, nil)
+	assert.NotNil(t, err)
+
+	n, err := NewEmailNotifier(global.Notifiers{Email: global.Email{Host: "localhost", From: "dinghy@example.com", Username: "user", SendOnValidation: true}}, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "localhost:25", n.Addr)
+	assert.Equal(t, DefaultSMTPTimeout, n.Timeout)
+	assert.NotNil(t, n.Auth)
+	assert.True(t, n.SendOnValidation())
+}
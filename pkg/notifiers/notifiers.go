@@ -1,9 +1,48 @@
 package notifiers
 
-import "github.com/armory/plank/v4"
+import (
+	"net/url"
+	"strings"
+
+	"github.com/armory/plank/v4"
+)
+
+// Keys of the content map the notifiers receive
+const (
+	// LogEventContent is the log output of the processing
+	LogEventContent = "logevent"
+	// RawDataContent is the raw push data
+	RawDataContent = "rawdata"
+	// CommitContent is the commit being processed
+	CommitContent = "commit"
+	// PusherContent is the user who pushed the commit
+	PusherContent = "pusher"
+	// ApplicationContent is the application of the dinghyfile, when known
+	ApplicationContent = "application"
+	// DinghyfileContent is the dinghyfile that failed, rendered when it could be
+	DinghyfileContent = "dinghyfile"
+	// ApplicationEmailContent is the email of the application spec of the
+	// dinghyfile that failed
+	ApplicationEmailContent = "applicationEmail"
+	// ValidationContent is true when a pull request is validated
+	ValidationContent = "validation"
+)
 
 type Notifier interface {
 	SendSuccess(org, repo, path string, notifications plank.NotificationsType, content map[string]interface{})
 	SendFailure(org, repo, path string, err error, notifications plank.NotificationsType, content map[string]interface{})
 	SendOnValidation() bool
 }
+
+// logEventsLink returns the url of the log events of a push on the dinghy at
+// baseURL, empty without a base url
+func logEventsLink(baseURL, org, repo, commit string) string {
+	if baseURL == "" {
+		return ""
+	}
+	query := url.Values{"org": {org}, "repo": {repo}}
+	if commit != "" {
+		query.Set("commit", commit)
+	}
+	return strings.TrimSuffix(baseURL, "/") + "/v1/logevents?" + query.Encode()
+}
@@ -0,0 +1,68 @@
+/*
+* Copyright 2026 Armory, Inc.
+
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+
+*    http://www.apache.org/licenses/LICENSE-2.0
+
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package notifiers
+
+import "sync"
+
+const (
+	DefaultQueueWorkers = 2
+	DefaultQueueSize    = 100
+)
+
+// Queue delivers notifications off the request path on a fixed number of
+// workers, deliveries are dropped while Size of them are waiting
+type Queue struct {
+	deliveries chan func()
+	pending    sync.WaitGroup
+}
+
+// NewQueue starts the workers of a queue of size deliveries
+func NewQueue(workers, size int) *Queue {
+	if workers <= 0 {
+		workers = DefaultQueueWorkers
+	}
+	if size <= 0 {
+		size = DefaultQueueSize
+	}
+	q := &Queue{deliveries: make(chan func(), size)}
+	for i := 0; i < workers; i++ {
+		go func() {
+			for deliver := range q.deliveries {
+				deliver()
+				q.pending.Done()
+			}
+		}()
+	}
+	return q
+}
+
+// Submit queues a delivery, reporting false when the queue is full
+func (q *Queue) Submit(deliver func()) bool {
+	q.pending.Add(1)
+	select {
+	case q.deliveries <- deliver:
+		return true
+	default:
+		q.pending.Done()
+		return false
+	}
+}
+
+// Wait returns once the queued deliveries are done
+func (q *Queue) Wait() {
+	q.pending.Wait()
+}
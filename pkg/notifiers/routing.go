@@ -0,0 +1,64 @@
+/*
+* Copyright 2026 Armory, Inc.
+
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+
+*    http://www.apache.org/licenses/LICENSE-2.0
+
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package notifiers
+
+import (
+	"strings"
+
+	"github.com/armory/plank/v4"
+)
+
+// Conditions of the application notifications that route the dinghy
+// outcomes, listed in the when of the entries like the pipeline ones
+const (
+	WhenSuccess    = "dinghy.success"
+	WhenFailure    = "dinghy.failure"
+	WhenValidation = "dinghy.validation"
+)
+
+// When returns the condition of an outcome, pull request validations being
+// dinghy.validation whatever their result
+func When(failed bool, content map[string]interface{}) string {
+	if validation, _ := content[ValidationContent].(bool); validation {
+		return WhenValidation
+	}
+	if failed {
+		return WhenFailure
+	}
+	return WhenSuccess
+}
+
+// Route returns the addresses of the application notifications of a type,
+// slack or email for instance, whose when lists the condition. Entries
+// without dinghy conditions are left to the pipelines.
+func Route(notifications plank.NotificationsType, notificationType, when string) []string {
+	entries, _ := notifications[notificationType].([]interface{})
+	addresses := []string{}
+	for _, e := range entries {
+		entry, _ := e.(map[string]interface{})
+		conditions, _ := entry["when"].([]interface{})
+		for _, c := range conditions {
+			if condition, _ := c.(string); condition == when {
+				if address, _ := entry["address"].(string); strings.TrimSpace(address) != "" {
+					addresses = append(addresses, strings.TrimSpace(address))
+				}
+				break
+			}
+		}
+	}
+	return addresses
+}
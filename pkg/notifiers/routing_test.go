@@ -0,0 +1,53 @@
+/*
+* Copyright 2026 Armory, Inc.
+
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+
+*    http://www.apache.org/licenses/LICENSE-2.0
+
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package notifiers
+
+import (
+	"testing"
+
+	"github.com/armory/plank/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWhen(t *testing.T) {
+	assert.Equal(t, WhenSuccess, When(false, map[string]interface{}{}))
+	assert.Equal(t, WhenFailure, When(true, map[string]interface{}{}))
+	assert.Equal(t, WhenValidation, When(false, map[string]interface{}{ValidationContent: true}))
+	assert.Equal(t, WhenValidation, When(true, map[string]interface{}{ValidationContent: true}))
+}
+
+func TestRoute(t *testing.T) {
+	notifications := plank.NotificationsType{
+		"application": "app",
+		"slack": []interface{}{
+			map[string]interface{}{"address": "deploys", "when": []interface{}{"dinghy.success", "pipeline.complete"}},
+			map[string]interface{}{"address": "alerts", "when": []interface{}{"dinghy.failure", "dinghy.validation"}},
+			map[string]interface{}{"address": "pipelines", "when": []interface{}{"pipeline.failed"}},
+			map[string]interface{}{"address": "nowhen"},
+			map[string]interface{}{"address": " ", "when": []interface{}{"dinghy.failure"}},
+		},
+		"email": []interface{}{
+			map[string]interface{}{"address": "team@example.com", "when": []interface{}{"dinghy.failure"}},
+		},
+	}
+	assert.Equal(t, []string{"deploys"}, Route(notifications, "slack", WhenSuccess))
+	assert.Equal(t, []string{"alerts"}, Route(notifications, "slack", WhenFailure))
+	assert.Equal(t, []string{"alerts"}, Route(notifications, "slack", WhenValidation))
+	assert.Equal(t, []string{"team@example.com"}, Route(notifications, "email", WhenFailure))
+	assert.Equal(t, []string{}, Route(notifications, "email", WhenSuccess))
+	assert.Equal(t, []string{}, Route(nil, "slack", WhenFailure))
+}
@@ -0,0 +1,271 @@
+/*
+* Copyright 2026 Armory, Inc.
+
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+
+*    http://www.apache.org/licenses/LICENSE-2.0
+
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package notifiers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/armory/dinghy/pkg/settings/global"
+	"github.com/armory/plank/v4"
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultSlackAPIURL is the Slack api the bot tokens are used with
+const DefaultSlackAPIURL = "https://slack.com/api"
+
+// SlackDestination is where a message is posted, an incoming webhook or a
+// channel a bot token can post to
+type SlackDestination struct {
+	WebhookURL string
+	Token      string
+	Channel    string
+}
+
+// SlackNotifier posts the results of the dinghyfiles to Slack
+type SlackNotifier struct {
+	// Default is the destination of the repos without one of their own
+	Default SlackDestination
+	// Repos maps an org or an org/repo to its destination
+	Repos map[string]SlackDestination
+	// ApplicationChannels posts to the slack channels of the application
+	// notifications instead, when it has some, with the destination's token
+	ApplicationChannels bool
+	// APIURL is DefaultSlackAPIURL when empty
+	APIURL string
+	// BaseURL of dinghy, the messages link to the log events of the push when set
+	BaseURL string
+	// OnValidation also notifies the results of pull request validations
+	OnValidation bool
+	// Queue the messages go through, they're posted by the sender when nil
+	Queue  *Queue
+	Client *http.Client
+	Logger log.FieldLogger
+}
+
+// NewSlackNotifier returns the Slack notifier of the settings
+func NewSlackNotifier(settings global.Notifiers, logger log.FieldLogger) *SlackNotifier {
+	n := &SlackNotifier{
+		Default: SlackDestination{
+			WebhookURL: settings.Slack.WebhookURL,
+			Token:      settings.Slack.Token,
+			Channel:    settings.Slack.Channel,
+		},
+		Repos:               map[string]SlackDestination{},
+		ApplicationChannels: settings.Slack.ApplicationChannelsEnabled,
+		APIURL:              settings.Slack.APIURL,
+		BaseURL:             settings.BaseURL,
+		OnValidation:        settings.Slack.SendOnValidation,
+		Queue:               NewQueue(DefaultQueueWorkers, DefaultQueueSize),
+		Client:              &http.Client{Timeout: 10 * time.Second},
+		Logger:              logger,
+	}
+	for _, r := range settings.Slack.Repos {
+		n.Repos[r.Source] = SlackDestination{WebhookURL: r.WebhookURL, Token: r.Token, Channel: r.Channel}
+	}
+	return n
+}
+
+type slackMessage struct {
+	Channel     string            `json:"channel,omitempty"`
+	Text        string            `json:"text"`
+	Attachments []slackAttachment `json:"attachments,omitempty"`
+}
+
+type slackAttachment struct {
+	Color  string       `json:"color"`
+	Fields []slackField `json:"fields"`
+}
+
+type slackField struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+	Short bool   `json:"short,omitempty"`
+}
+
+func (n *SlackNotifier) SendSuccess(org, repo, path string, notifications plank.NotificationsType, content map[string]interface{}) {
+	msg := n.message(org, repo, path, nil, content)
+	n.send(org, repo, SlackChannels(notifications, When(false, content)), msg)
+}
+
+func (n *SlackNotifier) SendFailure(org, repo, path string, err error, notifications plank.NotificationsType, content map[string]interface{}) {
+	msg := n.message(org, repo, path, err, content)
+	n.send(org, repo, SlackChannels(notifications, When(true, content)), msg)
+}
+
+func (n *SlackNotifier) SendOnValidation() bool {
+	return n.OnValidation
+}
+
+func (n *SlackNotifier) message(org, repo, path string, err error, content map[string]interface{}) slackMessage {
+	commit, _ := content[CommitContent].(string)
+	pusher, _ := content[PusherContent].(string)
+
+	subject := "Dinghyfile"
+	if validation, _ := content[ValidationContent].(bool); validation {
+		subject = "Validation of dinghyfile"
+	}
+	msg := slackMessage{Text: fmt.Sprintf("%s %s/%s/%s was processed", subject, escapeSlack(org), escapeSlack(repo), escapeSlack(path))}
+	attachment := slackAttachment{Color: "good"}
+	if err != nil {
+		msg.Text = fmt.Sprintf("%s %s/%s/%s failed", subject, escapeSlack(org), escapeSlack(repo), escapeSlack(path))
+		attachment.Color = "danger"
+	}
+	attachment.Fields = append(attachment.Fields,
+		slackField{Title: "Repository", Value: escapeSlack(org + "/" + repo), Short: true},
+		slackField{Title: "Path", Value: escapeSlack(path), Short: true},
+	)
+	if commit != "" {
+		attachment.Fields = append(attachment.Fields, slackField{Title: "Commit", Value: escapeSlack(commit), Short: true})
+	}
+	if pusher != "" {
+		attachment.Fields = append(attachment.Fields, slackField{Title: "Pusher", Value: escapeSlack(pusher), Short: true})
+	}
+	if err != nil {
+		attachment.Fields = append(attachment.Fields, slackField{Title: "Error", Value: "```" + escapeSlack(err.Error()) + "```"})
+	}
+	if link := logEventsLink(n.BaseURL, org, repo, commit); link != "" {
+		attachment.Fields = append(attachment.Fields, slackField{Title: "Log events", Value: "<" + link + "|View the log events>"})
+	}
+	msg.Attachments = []slackAttachment{attachment}
+	return msg
+}
+
+// destination returns the destination of a repo, the one of the repo first,
+// then the one of its org and the default one last
+func (n *SlackNotifier) destination(org, repo string) SlackDestination {
+	d, ok := n.Repos[org+"/"+repo]
+	if !ok {
+		d, ok = n.Repos[org]
+	}
+	if !ok {
+		return n.Default
+	}
+	if d.Token == "" {
+		d.Token = n.Default.Token
+	}
+	return d
+}
+
+// send posts a message to the destination of the repo, or to the channels
+// the application routes the outcome to
+func (n *SlackNotifier) send(org, repo string, channels []string, msg slackMessage) {
+	d := n.destination(org, repo)
+	destinations := []SlackDestination{d}
+	if n.ApplicationChannels && d.Token != "" && len(channels) > 0 {
+		destinations = nil
+		for _, c := range channels {
+			destinations = append(destinations, SlackDestination{Token: d.Token, Channel: c})
+		}
+	}
+	post := func() {
+		for _, d := range destinations {
+			if err := n.post(d, msg); err != nil {
+				n.Logger.Warnf("Could not notify %s/%s to Slack: %s", org, repo, err.Error())
+			}
+		}
+	}
+	if n.Queue == nil {
+		post()
+	} else if !n.Queue.Submit(post) {
+		n.Logger.Warnf("Could not notify %s/%s to Slack: too many notifications waiting", org, repo)
+	}
+}
+
+func (n *SlackNotifier) post(d SlackDestination, msg slackMessage) error {
+	var req *http.Request
+	var err error
+	webAPI := d.Token != "" && d.Channel != ""
+	switch {
+	case webAPI:
+		msg.Channel = d.Channel
+		body, _ := json.Marshal(msg)
+		apiURL := n.APIURL
+		if apiURL == "" {
+			apiURL = DefaultSlackAPIURL
+		}
+		req, err = http.NewRequest(http.MethodPost, strings.TrimSuffix(apiURL, "/")+"/chat.postMessage", bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+d.Token)
+	case d.WebhookURL != "":
+		body, _ := json.Marshal(msg)
+		req, err = http.NewRequest(http.MethodPost, d.WebhookURL, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+	default:
+		return errors.New("no webhook url nor bot token and channel configured")
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+
+	client := n.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("slack answered %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	if !webAPI {
+		return nil
+	}
+	// the web api answers 200 with the error in the body
+	var result struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return fmt.Errorf("unexpected answer from slack: %s", err.Error())
+	}
+	if !result.OK {
+		return fmt.Errorf("slack answered %s", result.Error)
+	}
+	return nil
+}
+
+// SlackChannels returns the channels of the slack entries of application
+// notifications routing the condition
+func SlackChannels(notifications plank.NotificationsType, when string) []string {
+	channels := []string{}
+	seen := map[string]bool{}
+	for _, address := range Route(notifications, "slack", when) {
+		address = strings.TrimPrefix(address, "#")
+		if address != "" && !seen[address] {
+			seen[address] = true
+			channels = append(channels, address)
+		}
+	}
+	return channels
+}
+
+// escapeSlack escapes the characters Slack uses for its markup
+func escapeSlack(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
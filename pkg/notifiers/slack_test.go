@@ -0,0 +1,261 @@
+/*
+* Copyright 2026 Armory, Inc.
+
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+
+*    http://www.apache.org/licenses/LICENSE-2.0
+
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package notifiers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/armory/dinghy/pkg/mock"
+	"github.com/armory/dinghy/pkg/settings/global"
+	"github.com/armory/plank/v4"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+type slackRequest struct {
+	Path          string
+	Authorization string
+	Message       slackMessage
+}
+
+// slackStandIn records the messages posted to it, answering like the web api
+// on /api and like an incoming webhook anywhere else
+func slackStandIn(t *testing.T, apiError string) (*httptest.Server, func() []slackRequest) {
+	var mu sync.Mutex
+	requests := []slackRequest{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg slackMessage
+		assert.Nil(t, json.NewDecoder(r.Body).Decode(&msg))
+		mu.Lock()
+		requests = append(requests, slackRequest{Path: r.URL.Path, Authorization: r.Header.Get("Authorization"), Message: msg})
+		mu.Unlock()
+		if r.URL.Path == "/api/chat.postMessage" {
+			if apiError != "" {
+				w.Write([]byte(`{"ok":false,"error":"` + apiError + `"}`))
+				return
+			}
+			w.Write([]byte(`{"ok":true}`))
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	return server, func() []slackRequest {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]slackRequest{}, requests...)
+	}
+}
+
+func fieldValues(msg slackMessage) map[string]string {
+	values := map[string]string{}
+	for _, f := range msg.Attachments[0].Fields {
+		values[f.Title] = f.Value
+	}
+	return values
+}
+
+func TestSlackSendSuccessToWebhook(t *testing.T) {
+	server, requests := slackStandIn(t, "")
+	defer server.Close()
+
+	n := NewSlackNotifier(global.Notifiers{
+		BaseURL: "https://dinghy.example.com/",
+		Slack:   global.Slack{Enabled: true, WebhookURL: server.URL + "/hooks/default"},
+	}, nil)
+	n.SendSuccess("org", "repo", "dinghyfile", nil, map[string]interface{}{
+		CommitContent: "abc",
+		PusherContent: "octocat",
+	})
+	n.Queue.Wait()
+
+	got := requests()
+	assert.Len(t, got, 1)
+	assert.Equal(t, "/hooks/default", got[0].Path)
+	assert.Equal(t, "", got[0].Authorization)
+	assert.Equal(t, "", got[0].Message.Channel)
+	assert.Equal(t, "Dinghyfile org/repo/dinghyfile was processed", got[0].Message.Text)
+	assert.Equal(t, "good", got[0].Message.Attachments[0].Color)
+	assert.Equal(t, map[string]string{
+		"Repository": "org/repo",
+		"Path":       "dinghyfile",
+		"Commit":     "abc",
+		"Pusher":     "octocat",
+		"Log events": "<https://dinghy.example.com/v1/logevents?commit=abc&org=org&repo=repo|View the log events>",
+	}, fieldValues(got[0].Message))
+}
+
+func TestSlackSendFailureWithToken(t *testing.T) {
+	server, requests := slackStandIn(t, "")
+	defer server.Close()
+
+	n := NewSlackNotifier(global.Notifiers{
+		Slack: global.Slack{Enabled: true, Token: "xoxb-token", Channel: "dinghy", APIURL: server.URL + "/api"},
+	}, nil)
+	n.SendFailure("org", "repo", "dinghyfile", errors.New("template <module> not found"), nil, map[string]interface{}{})
+	n.Queue.Wait()
+
+	got := requests()
+	assert.Len(t, got, 1)
+	assert.Equal(t, "/api/chat.postMessage", got[0].Path)
+	assert.Equal(t, "Bearer xoxb-token", got[0].Authorization)
+	assert.Equal(t, "dinghy", got[0].Message.Channel)
+	assert.Equal(t, "Dinghyfile org/repo/dinghyfile failed", got[0].Message.Text)
+	assert.Equal(t, "danger", got[0].Message.Attachments[0].Color)
+	assert.Equal(t, map[string]string{
+		"Repository": "org/repo",
+		"Path":       "dinghyfile",
+		"Error":      "```template &lt;module&gt; not found```",
+	}, fieldValues(got[0].Message))
+}
+
+func TestSlackRepoDestinations(t *testing.T) {
+	server, requests := slackStandIn(t, "")
+	defer server.Close()
+
+	n := NewSlackNotifier(global.Notifiers{
+		Slack: global.Slack{
+			Enabled:    true,
+			WebhookURL: server.URL + "/hooks/default",
+			Token:      "xoxb-default",
+			APIURL:     server.URL + "/api",
+			Repos: []global.SlackRepo{
+				{Source: "org", WebhookURL: server.URL + "/hooks/org"},
+				{Source: "org/repo", Channel: "repo-channel"},
+			},
+		},
+	}, nil)
+	n.SendSuccess("org", "repo", "dinghyfile", nil, map[string]interface{}{})
+	n.Queue.Wait()
+	n.SendSuccess("org", "other", "dinghyfile", nil, map[string]interface{}{})
+	n.Queue.Wait()
+	n.SendSuccess("another", "repo", "dinghyfile", nil, map[string]interface{}{})
+	n.Queue.Wait()
+
+	got := requests()
+	assert.Len(t, got, 3)
+	assert.Equal(t, "/api/chat.postMessage", got[0].Path)
+	assert.Equal(t, "Bearer xoxb-default", got[0].Authorization)
+	assert.Equal(t, "repo-channel", got[0].Message.Channel)
+	assert.Equal(t, "/hooks/org", got[1].Path)
+	assert.Equal(t, "/hooks/default", got[2].Path)
+}
+
+func TestSlackApplicationChannels(t *testing.T) {
+	server, requests := slackStandIn(t, "")
+	defer server.Close()
+
+	n := NewSlackNotifier(global.Notifiers{
+		Slack: global.Slack{
+			Enabled:                    true,
+			Token:                      "xoxb-token",
+			Channel:                    "dinghy",
+			APIURL:                     server.URL + "/api",
+			ApplicationChannelsEnabled: true,
+		},
+	}, nil)
+	failures := []interface{}{"dinghy.failure"}
+	notifications := plank.NotificationsType{
+		"slack": []interface{}{
+			map[string]interface{}{"address": "#team", "type": "slack", "when": failures},
+			map[string]interface{}{"address": "team", "type": "slack", "when": failures},
+			map[string]interface{}{"address": "alerts", "type": "slack", "when": []interface{}{"pipeline.failed", "dinghy.failure"}},
+			map[string]interface{}{"address": "pipelines", "type": "slack", "when": []interface{}{"pipeline.failed"}},
+		},
+		"email": []interface{}{
+			map[string]interface{}{"address": "team@example.com", "type": "email", "when": failures},
+		},
+	}
+	n.SendFailure("org", "repo", "dinghyfile", errors.New("boom"), notifications, map[string]interface{}{})
+	n.Queue.Wait()
+	n.SendFailure("org", "repo", "dinghyfile", errors.New("boom"), nil, map[string]interface{}{})
+	n.Queue.Wait()
+	n.SendSuccess("org", "repo", "dinghyfile", notifications, map[string]interface{}{})
+	n.Queue.Wait()
+
+	got := requests()
+	assert.Len(t, got, 4)
+	assert.Equal(t, "team", got[0].Message.Channel)
+	assert.Equal(t, "alerts", got[1].Message.Channel)
+	assert.Equal(t, "dinghy", got[2].Message.Channel)
+	assert.Equal(t, "dinghy", got[3].Message.Channel)
+
+	notifications["slack"] = []interface{}{
+		map[string]interface{}{"address": "reviews", "type": "slack", "when": []interface{}{"dinghy.validation"}},
+	}
+	n.SendFailure("org", "repo", "dinghyfile", errors.New("boom"), notifications, map[string]interface{}{ValidationContent: true})
+	n.Queue.Wait()
+	got = requests()
+	assert.Len(t, got, 5)
+	assert.Equal(t, "reviews", got[4].Message.Channel)
+	assert.Equal(t, "Validation of dinghyfile org/repo/dinghyfile failed", got[4].Message.Text)
+}
+
+func TestSlackErrorsAreLogged(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	server, _ := slackStandIn(t, "channel_not_found")
+	defer server.Close()
+
+	logger := mock.NewMockFieldLogger(ctrl)
+	logger.EXPECT().Warnf("Could not notify %s/%s to Slack: %s", "org", "repo", "slack answered channel_not_found")
+	logger.EXPECT().Warnf("Could not notify %s/%s to Slack: %s", "org", "repo", "no webhook url nor bot token and channel configured")
+
+	n := NewSlackNotifier(global.Notifiers{
+		Slack: global.Slack{Enabled: true, Token: "xoxb-token", Channel: "nope", APIURL: server.URL + "/api"},
+	}, logger)
+	n.SendSuccess("org", "repo", "dinghyfile", nil, map[string]interface{}{})
+	n.Queue.Wait()
+
+	n = NewSlackNotifier(global.Notifiers{Slack: global.Slack{Enabled: true}}, logger)
+	n.SendSuccess("org", "repo", "dinghyfile", nil, map[string]interface{}{})
+	n.Queue.Wait()
+}
+
+func TestSlackQueueFull(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+
+	logger := mock.NewMockFieldLogger(ctrl)
+	n := NewSlackNotifier(global.Notifiers{Slack: global.Slack{Enabled: true, WebhookURL: server.URL}}, logger)
+	n.Queue = NewQueue(1, 1)
+	// one message in flight, one waiting, the third is dropped
+	n.SendSuccess("org", "repo", "dinghyfile", nil, map[string]interface{}{})
+	assert.Eventually(t, func() bool { return len(n.Queue.deliveries) == 0 }, time.Second, time.Millisecond)
+	n.SendSuccess("org", "repo", "dinghyfile", nil, map[string]interface{}{})
+	logger.EXPECT().Warnf("Could not notify %s/%s to Slack: too many notifications waiting", "org", "repo")
+	n.SendSuccess("org", "repo", "dinghyfile", nil, map[string]interface{}{})
+	close(release)
+	n.Queue.Wait()
+}
+
+func TestSlackSendOnValidation(t *testing.T) {
+	assert.False(t, NewSlackNotifier(global.Notifiers{}, nil).SendOnValidation())
+	assert.True(t, NewSlackNotifier(global.Notifiers{Slack: global.Slack{SendOnValidation: true}}, nil).SendOnValidation())
+}
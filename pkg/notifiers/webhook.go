@@ -0,0 +1,281 @@
+/*
+* Copyright 2026 Armory, Inc.
+
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+
+*    http://www.apache.org/licenses/LICENSE-2.0
+
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package notifiers
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/armory/dinghy/pkg/settings/global"
+	"github.com/armory/plank/v4"
+	log "github.com/sirupsen/logrus"
+)
+
+// Events the webhooks are notified of
+const (
+	SuccessEvent = "success"
+	FailureEvent = "failure"
+)
+
+const (
+	// SignatureHeader carries the HMAC-SHA256 of the body, as sha256=<hex>
+	SignatureHeader = "X-Dinghy-Signature"
+	// EventHeader carries the event of the notification
+	EventHeader = "X-Dinghy-Event"
+
+	DefaultWebhookBackoff = 500 * time.Millisecond
+)
+
+// WebhookNotifier POSTs the results of the dinghyfiles to a URL, the body is
+// rendered from a template over the notification content
+type WebhookNotifier struct {
+	// Name of the webhook in the logs
+	Name string
+	URL  string
+	// Template of the body, a JSON document of the content when nil
+	Template    *template.Template
+	ContentType string
+	Headers     map[string]string
+	// Secret the body is signed with, nothing is signed when empty
+	Secret string
+	// MaxRetries of a delivery failing with a network error, a 429 or a 5xx
+	MaxRetries int
+	// Backoff before the first retry, doubled for every other one
+	Backoff time.Duration
+	// BaseURL of dinghy, the content links to the log events of the push when set
+	BaseURL string
+	// OnValidation also notifies the results of pull request validations
+	OnValidation bool
+	// Repos, Applications and Events the webhook is limited to, all when empty
+	Repos        []string
+	Applications []string
+	Events       []string
+	Client       *http.Client
+	// Queue the deliveries go through, they're made by the sender when nil
+	Queue  *Queue
+	Logger log.FieldLogger
+}
+
+// NewWebhookNotifier returns the notifier of a webhook of the settings
+func NewWebhookNotifier(settings global.Webhook, baseURL string, logger log.FieldLogger) (*WebhookNotifier, error) {
+	if settings.URL == "" {
+		return nil, fmt.Errorf("webhook %s has no url", settings.Name)
+	}
+	n := &WebhookNotifier{
+		Name:         settings.Name,
+		URL:          settings.URL,
+		ContentType:  settings.ContentType,
+		Headers:      settings.Headers,
+		Secret:       settings.Secret,
+		MaxRetries:   settings.MaxRetries,
+		Backoff:      time.Duration(settings.RetryBackoffMilliseconds) * time.Millisecond,
+		BaseURL:      baseURL,
+		OnValidation: settings.SendOnValidation,
+		Repos:        settings.Repos,
+		Applications: settings.Applications,
+		Events:       settings.Events,
+		Client:       &http.Client{Timeout: 10 * time.Second},
+		Queue:        NewQueue(DefaultQueueWorkers, DefaultQueueSize),
+		Logger:       logger,
+	}
+	if n.Name == "" {
+		n.Name = n.URL
+	}
+	if n.ContentType == "" {
+		n.ContentType = "application/json"
+	}
+	if n.Backoff <= 0 {
+		n.Backoff = DefaultWebhookBackoff
+	}
+	for _, e := range n.Events {
+		if e != SuccessEvent && e != FailureEvent {
+			return nil, fmt.Errorf("webhook %s: unknown event %s, expected %s or %s", n.Name, e, SuccessEvent, FailureEvent)
+		}
+	}
+	if settings.Template != "" {
+		tmpl, err := template.New(n.Name).Funcs(template.FuncMap{"json": toJSON}).Parse(settings.Template)
+		if err != nil {
+			return nil, fmt.Errorf("webhook %s: %s", n.Name, err.Error())
+		}
+		n.Template = tmpl
+	}
+	return n, nil
+}
+
+func (n *WebhookNotifier) SendSuccess(org, repo, path string, notifications plank.NotificationsType, content map[string]interface{}) {
+	n.send(SuccessEvent, org, repo, path, nil, notifications, content)
+}
+
+func (n *WebhookNotifier) SendFailure(org, repo, path string, err error, notifications plank.NotificationsType, content map[string]interface{}) {
+	n.send(FailureEvent, org, repo, path, err, notifications, content)
+}
+
+func (n *WebhookNotifier) SendOnValidation() bool {
+	return n.OnValidation
+}
+
+// Matches says if the webhook is notified of an event of a source
+func (n *WebhookNotifier) Matches(event, org, repo, application string) bool {
+	if len(n.Events) > 0 && !containsString(n.Events, event) {
+		return false
+	}
+	if len(n.Repos) > 0 && !containsString(n.Repos, org) && !containsString(n.Repos, org+"/"+repo) {
+		return false
+	}
+	if len(n.Applications) > 0 {
+		for _, a := range n.Applications {
+			if strings.EqualFold(a, application) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func (n *WebhookNotifier) send(event, org, repo, path string, err error, notifications plank.NotificationsType, content map[string]interface{}) {
+	application, _ := content[ApplicationContent].(string)
+	if !n.Matches(event, org, repo, application) {
+		return
+	}
+
+	data := map[string]interface{}{}
+	for k, v := range content {
+		data[k] = v
+	}
+	data["event"] = event
+	data["when"] = When(event == FailureEvent, content)
+	data["org"] = org
+	data["repo"] = repo
+	data["path"] = path
+	if err != nil {
+		data["error"] = err.Error()
+	}
+	if notifications != nil {
+		data["notifications"] = notifications
+	}
+	commit, _ := content[CommitContent].(string)
+	if link := logEventsLink(n.BaseURL, org, repo, commit); link != "" {
+		data["logeventsUrl"] = link
+	}
+
+	body, errRender := n.render(data)
+	if errRender != nil {
+		n.Logger.Errorf("Could not render the body of webhook %s: %s", n.Name, errRender.Error())
+		return
+	}
+	deliver := func() {
+		if errDeliver := n.deliver(event, body); errDeliver != nil {
+			n.Logger.Warnf("Could not notify %s/%s to webhook %s: %s", org, repo, n.Name, errDeliver.Error())
+		}
+	}
+	if n.Queue == nil {
+		deliver()
+	} else if !n.Queue.Submit(deliver) {
+		n.Logger.Warnf("Could not notify %s/%s to webhook %s: too many notifications waiting", org, repo, n.Name)
+	}
+}
+
+func (n *WebhookNotifier) render(data map[string]interface{}) ([]byte, error) {
+	if n.Template == nil {
+		return json.Marshal(data)
+	}
+	var buf bytes.Buffer
+	if err := n.Template.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// deliver posts the body, retrying the failures worth retrying
+func (n *WebhookNotifier) deliver(event string, body []byte) error {
+	backoff := n.Backoff
+	for attempt := 0; ; attempt++ {
+		retry, err := n.post(event, body)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt >= n.MaxRetries {
+			return err
+		}
+		n.Logger.Infof("Webhook %s failed, retrying in %s: %s", n.Name, backoff, err.Error())
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// post posts the body once, saying if a failure is worth retrying
+func (n *WebhookNotifier) post(event string, body []byte) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, n.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	for name, value := range n.Headers {
+		req.Header.Set(name, value)
+	}
+	req.Header.Set("Content-Type", n.ContentType)
+	req.Header.Set(EventHeader, event)
+	if n.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(n.Secret, body))
+	}
+
+	client := n.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	answer, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	err = fmt.Errorf("answered %d: %s", resp.StatusCode, strings.TrimSpace(string(answer)))
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500, err
+}
+
+// Sign returns the signature of a body sent in SignatureHeader
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func toJSON(v interface{}) (string, error) {
+	b, err := json.Marshal(v)
+	return string(b), err
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
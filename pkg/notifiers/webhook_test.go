@@ -0,0 +1,224 @@
+/*
+* Copyright 2026 Armory, Inc.
+
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+
+*    http://www.apache.org/licenses/LICENSE-2.0
+
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package notifiers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/armory/dinghy/pkg/mock"
+	"github.com/armory/dinghy/pkg/settings/global"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+type webhookRequest struct {
+	Header http.Header
+	Body   string
+}
+
+// webhookStandIn records the requests posted to it, answering them with the
+// statuses in order and 200 once they're used up
+func webhookStandIn(statuses ...int) (*httptest.Server, func() []webhookRequest) {
+	var mu sync.Mutex
+	requests := []webhookRequest{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		requests = append(requests, webhookRequest{Header: r.Header, Body: string(body)})
+		status := http.StatusOK
+		if len(requests) <= len(statuses) {
+			status = statuses[len(requests)-1]
+		}
+		mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	return server, func() []webhookRequest {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]webhookRequest{}, requests...)
+	}
+}
+
+func TestWebhookDefaultBody(t *testing.T) {
+	server, requests := webhookStandIn()
+	defer server.Close()
+
+	n, err := NewWebhookNotifier(global.Webhook{
+		URL:     server.URL,
+		Secret:  "s3cr3t",
+		Headers: map[string]string{"X-Team": "platform"},
+	}, "https://dinghy.example.com", nil)
+	assert.Nil(t, err)
+	n.SendFailure("org", "repo", "dinghyfile", errors.New("boom"), nil, map[string]interface{}{
+		LogEventContent:    "log",
+		RawDataContent:     map[string]interface{}{"ref": "refs/heads/main"},
+		CommitContent:      "abc",
+		ApplicationContent: "app",
+	})
+	n.Queue.Wait()
+
+	got := requests()
+	assert.Len(t, got, 1)
+	assert.Equal(t, "application/json", got[0].Header.Get("Content-Type"))
+	assert.Equal(t, FailureEvent, got[0].Header.Get(EventHeader))
+	assert.Equal(t, "platform", got[0].Header.Get("X-Team"))
+	assert.Equal(t, Sign("s3cr3t", []byte(got[0].Body)), got[0].Header.Get(SignatureHeader))
+
+	var body map[string]interface{}
+	assert.Nil(t, json.Unmarshal([]byte(got[0].Body), &body))
+	assert.Equal(t, map[string]interface{}{
+		"event":        "failure",
+		"when":         "dinghy.failure",
+		"org":          "org",
+		"repo":         "repo",
+		"path":         "dinghyfile",
+		"error":        "boom",
+		"logevent":     "log",
+		"rawdata":      map[string]interface{}{"ref": "refs/heads/main"},
+		"commit":       "abc",
+		"application":  "app",
+		"logeventsUrl": "https://dinghy.example.com/v1/logevents?commit=abc&org=org&repo=repo",
+	}, body)
+}
+
+func TestWebhookTemplate(t *testing.T) {
+	server, requests := webhookStandIn()
+	defer server.Close()
+
+	n, err := NewWebhookNotifier(global.Webhook{
+		URL:         server.URL,
+		ContentType: "text/plain",
+		Template:    `{{ .event }} {{ .org }}/{{ .repo }}/{{ .path }} {{ json .rawdata.ref }}{{ with .error }} {{ . }}{{ end }}`,
+	}, "", nil)
+	assert.Nil(t, err)
+	n.SendSuccess("org", "repo", "dinghyfile", nil, map[string]interface{}{
+		RawDataContent: map[string]interface{}{"ref": "refs/heads/main"},
+	})
+	n.Queue.Wait()
+
+	got := requests()
+	assert.Len(t, got, 1)
+	assert.Equal(t, "text/plain", got[0].Header.Get("Content-Type"))
+	assert.Equal(t, "", got[0].Header.Get(SignatureHeader))
+	assert.Equal(t, `success org/repo/dinghyfile "refs/heads/main"`, got[0].Body)
+}
+
+func TestWebhookRetries(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	logger := mock.NewMockFieldLogger(ctrl)
+	logger.EXPECT().Infof(gomock.Any(), gomock.Any()).AnyTimes()
+
+	server, requests := webhookStandIn(http.StatusServiceUnavailable, http.StatusTooManyRequests)
+	n, err := NewWebhookNotifier(global.Webhook{URL: server.URL, MaxRetries: 2, RetryBackoffMilliseconds: 1}, "", logger)
+	assert.Nil(t, err)
+	n.SendSuccess("org", "repo", "dinghyfile", nil, map[string]interface{}{})
+	n.Queue.Wait()
+	assert.Len(t, requests(), 3)
+	server.Close()
+
+	server, requests = webhookStandIn(http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway)
+	n.URL = server.URL
+	logger.EXPECT().Warnf("Could not notify %s/%s to webhook %s: %s", "org", "repo", n.Name, "answered 502: ")
+	n.SendSuccess("org", "repo", "dinghyfile", nil, map[string]interface{}{})
+	n.Queue.Wait()
+	assert.Len(t, requests(), 3)
+	server.Close()
+
+	server, requests = webhookStandIn(http.StatusBadRequest)
+	defer server.Close()
+	n.URL = server.URL
+	logger.EXPECT().Warnf("Could not notify %s/%s to webhook %s: %s", "org", "repo", n.Name, "answered 400: ")
+	n.SendSuccess("org", "repo", "dinghyfile", nil, map[string]interface{}{})
+	n.Queue.Wait()
+	assert.Len(t, requests(), 1)
+}
+
+func TestWebhookQueueFull(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+
+	logger := mock.NewMockFieldLogger(ctrl)
+	n, err := NewWebhookNotifier(global.Webhook{Name: "slow", URL: server.URL}, "", logger)
+	assert.Nil(t, err)
+	n.Queue = NewQueue(1, 1)
+	// one delivery in flight, one waiting, the third is dropped
+	n.SendSuccess("org", "repo", "dinghyfile", nil, map[string]interface{}{})
+	assert.Eventually(t, func() bool { return len(n.Queue.deliveries) == 0 }, time.Second, time.Millisecond)
+	n.SendSuccess("org", "repo", "dinghyfile", nil, map[string]interface{}{})
+	logger.EXPECT().Warnf("Could not notify %s/%s to webhook %s: too many notifications waiting", "org", "repo", "slow")
+	n.SendSuccess("org", "repo", "dinghyfile", nil, map[string]interface{}{})
+	close(release)
+	n.Queue.Wait()
+}
+
+func TestWebhookMatches(t *testing.T) {
+	n := &WebhookNotifier{
+		Repos:        []string{"org", "other/repo"},
+		Applications: []string{"App"},
+		Events:       []string{FailureEvent},
+	}
+	assert.True(t, n.Matches(FailureEvent, "org", "any", "app"))
+	assert.True(t, n.Matches(FailureEvent, "other", "repo", "app"))
+	assert.False(t, n.Matches(SuccessEvent, "org", "any", "app"))
+	assert.False(t, n.Matches(FailureEvent, "other", "any", "app"))
+	assert.False(t, n.Matches(FailureEvent, "org", "any", "another"))
+	assert.False(t, n.Matches(FailureEvent, "org", "any", ""))
+	assert.True(t, (&WebhookNotifier{}).Matches(SuccessEvent, "org", "repo", ""))
+}
+
+func TestWebhookSkipsUnmatched(t *testing.T) {
+	server, requests := webhookStandIn()
+	defer server.Close()
+
+	n, err := NewWebhookNotifier(global.Webhook{URL: server.URL, Applications: []string{"app"}}, "", nil)
+	assert.Nil(t, err)
+	n.SendSuccess("org", "repo", "dinghyfile", nil, map[string]interface{}{ApplicationContent: "another"})
+	n.SendSuccess("org", "repo", "dinghyfile", nil, map[string]interface{}{ApplicationContent: "app"})
+	n.Queue.Wait()
+	assert.Len(t, requests(), 1)
+}
+
+func TestNewWebhookNotifierErrors(t *testing.T) {
+	_, err := NewWebhookNotifier(global.Webhook{Name: "nourl"}, "", nil)
+	assert.EqualError(t, err, "webhook nourl has no url")
+
+	_, err = NewWebhookNotifier(global.Webhook{URL: "http://localhost", Template: "{{ .org "}, "", nil)
+	assert.NotNil(t, err)
+
+	_, err = NewWebhookNotifier(global.Webhook{URL: "http://localhost", Events: []string{"validation"}}, "", nil)
+	assert.EqualError(t, err, "webhook http://localhost: unknown event validation, expected success or failure")
+
+	n, err := NewWebhookNotifier(global.Webhook{URL: "http://localhost", SendOnValidation: true}, "", nil)
+	assert.Nil(t, err)
+	assert.True(t, n.SendOnValidation())
+	assert.Equal(t, DefaultWebhookBackoff, n.Backoff)
+}
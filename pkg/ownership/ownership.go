@@ -0,0 +1,116 @@
+/*
+* Copyright 2026 Armory, Inc.
+
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+
+*    http://www.apache.org/licenses/LICENSE-2.0
+
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+// Package ownership binds Spinnaker applications to the dinghyfile that
+// manages them, so a repo can't overwrite another team's application by
+// reusing its name.
+package ownership
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Owner identifies the dinghyfile managing an application
+type Owner struct {
+	Org  string `json:"org"`
+	Repo string `json:"repo"`
+	Path string `json:"path"`
+}
+
+func (o Owner) String() string {
+	return fmt.Sprintf("%s/%s/%s", o.Org, o.Repo, o.Path)
+}
+
+// Matches reports whether o is described by source, either "org/repo" for
+// any dinghyfile in the repo or "org/repo/path" for a single one.
+func (o Owner) Matches(source string) bool {
+	parts := strings.SplitN(strings.Trim(source, "/"), "/", 3)
+	if len(parts) < 2 || parts[0] != o.Org || parts[1] != o.Repo {
+		return false
+	}
+	return len(parts) == 2 || parts[2] == o.Path
+}
+
+// Registry stores the owner of every application dinghy manages
+type Registry interface {
+	// GetOwner returns nil when the application has no owner yet
+	GetOwner(application string) (*Owner, error)
+	// ClaimOwner records owner unless the application already has one, and
+	// returns the owner on record
+	ClaimOwner(application string, owner Owner) (*Owner, error)
+	// SetOwner overwrites the owner of an application
+	SetOwner(application string, owner Owner) error
+}
+
+// Lister is implemented by the registries able to list every owner, to back
+// them up
+type Lister interface {
+	// ListOwners returns the owners keyed by application
+	ListOwners() (map[string]Owner, error)
+}
+
+// NotOwnerError is returned when a dinghyfile tries to manage an application
+// owned by another one
+type NotOwnerError struct {
+	Application string
+	Owner       string
+	Source      Owner
+}
+
+func (e *NotOwnerError) Error() string {
+	return fmt.Sprintf("application %s is managed by %s, refusing to update it from %s", e.Application, e.Owner, e.Source)
+}
+
+// Policy decides which dinghyfiles may manage an application
+type Policy struct {
+	Registry Registry
+	// Allowed lists the sources allowed to manage an application, keyed by
+	// application name. It takes precedence over the registry.
+	Allowed map[string][]string
+}
+
+// Check returns a NotOwnerError if source may not manage application. When
+// claim is set and the application has no owner, source becomes its owner.
+func (p *Policy) Check(application string, source Owner, claim bool) error {
+	application = strings.ToLower(application)
+	if sources, ok := p.Allowed[application]; ok {
+		for _, s := range sources {
+			if source.Matches(s) {
+				return nil
+			}
+		}
+		return &NotOwnerError{Application: application, Owner: strings.Join(sources, ", "), Source: source}
+	}
+
+	if p.Registry == nil {
+		return nil
+	}
+	var owner *Owner
+	var err error
+	if claim {
+		owner, err = p.Registry.ClaimOwner(application, source)
+	} else {
+		owner, err = p.Registry.GetOwner(application)
+	}
+	if err != nil {
+		return fmt.Errorf("unable to look up the owner of application %s: %w", application, err)
+	}
+	if owner != nil && *owner != source {
+		return &NotOwnerError{Application: application, Owner: owner.String(), Source: source}
+	}
+	return nil
+}
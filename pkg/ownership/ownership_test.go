@@ -0,0 +1,88 @@
+/*
+* Copyright 2026 Armory, Inc.
+
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+
+*    http://www.apache.org/licenses/LICENSE-2.0
+
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package ownership
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type memoryRegistry map[string]Owner
+
+func (m memoryRegistry) GetOwner(application string) (*Owner, error) {
+	if o, ok := m[application]; ok {
+		return &o, nil
+	}
+	return nil, nil
+}
+
+func (m memoryRegistry) ClaimOwner(application string, owner Owner) (*Owner, error) {
+	if _, ok := m[application]; !ok {
+		m[application] = owner
+	}
+	return m.GetOwner(application)
+}
+
+func (m memoryRegistry) SetOwner(application string, owner Owner) error {
+	m[application] = owner
+	return nil
+}
+
+func TestOwnerMatches(t *testing.T) {
+	o := Owner{Org: "armory", Repo: "pipelines", Path: "apps/biff/dinghyfile"}
+
+	assert.True(t, o.Matches("armory/pipelines"))
+	assert.True(t, o.Matches("armory/pipelines/apps/biff/dinghyfile"))
+	assert.False(t, o.Matches("armory/pipelines/apps/other/dinghyfile"))
+	assert.False(t, o.Matches("armory/other"))
+	assert.False(t, o.Matches("armory"))
+}
+
+func TestPolicyFirstWriterOwns(t *testing.T) {
+	registry := memoryRegistry{}
+	p := &Policy{Registry: registry}
+	first := Owner{Org: "armory", Repo: "team-a", Path: "dinghyfile"}
+	second := Owner{Org: "armory", Repo: "team-b", Path: "dinghyfile"}
+
+	// validation doesn't claim the application
+	assert.Nil(t, p.Check("Biff", second, false))
+	assert.Empty(t, registry)
+
+	assert.Nil(t, p.Check("Biff", first, true))
+	assert.Equal(t, first, registry["biff"])
+	assert.Nil(t, p.Check("biff", first, true))
+
+	err := p.Check("biff", second, true)
+	assert.IsType(t, &NotOwnerError{}, err)
+	assert.Equal(t, "application biff is managed by armory/team-a/dinghyfile, refusing to update it from armory/team-b/dinghyfile", err.Error())
+	assert.NotNil(t, p.Check("biff", second, false))
+}
+
+func TestPolicyAllowList(t *testing.T) {
+	registry := memoryRegistry{}
+	p := &Policy{
+		Registry: registry,
+		Allowed:  map[string][]string{"biff": {"armory/team-a", "armory/shared/biff/dinghyfile"}},
+	}
+
+	assert.Nil(t, p.Check("biff", Owner{Org: "armory", Repo: "team-a", Path: "x/dinghyfile"}, true))
+	assert.Nil(t, p.Check("biff", Owner{Org: "armory", Repo: "shared", Path: "biff/dinghyfile"}, true))
+	assert.NotNil(t, p.Check("biff", Owner{Org: "armory", Repo: "shared", Path: "dinghyfile"}, true))
+	// allow-listed applications are never recorded in the registry
+	assert.Empty(t, registry)
+}
@@ -19,6 +19,7 @@ package global
 
 import (
 	"fmt"
+	"github.com/armory/dinghy/pkg/tracing"
 	"github.com/armory/dinghy/pkg/util"
 	"github.com/armory/go-yaml-tools/pkg/secrets"
 	"github.com/armory/go-yaml-tools/pkg/tls/client"
@@ -155,6 +156,8 @@ type Settings struct {
 	RepositoryRawdataProcessing bool `json:"repositoryRawdataProcessing,omitempty" yaml:"repositoryRawdataProcessing"`
 	// This will be the TTL value to ger dinghyevents data
 	LogEventTTLMinutes time.Duration `json:"LogEventTTLMinutes" yaml:"LogEventTTLMinutes"`
+	// Background purge of the log events, finer than LogEventTTLMinutes
+	LogEventRetention LogEventRetention `json:"logEventRetention,omitempty" yaml:"logEventRetention"`
 	// SQL configuration for dinghy
 	SQL Sqlconfig `json:"sql,omitempty" yaml:"sql"`
 	// Enable regexp2 for .dinghyignore file
@@ -167,19 +170,222 @@ type Settings struct {
 	MultipleBranchesEnabled string `json:"multipleBranchesEnabled" yaml:"multipleBranchesEnabled"`
 	// Enable using savePipeline and updatePipeline tasks from Orca
 	UpsertPipelineUsingOrcaTaskEnabled bool `json:"upsertPipelineUsingOrcaTaskEnabled" yaml:"upsertPipelineUsingOrcaTaskEnabled"`
+	// OpenTelemetry tracing configuration
+	Tracing Tracing `json:"tracing,omitempty" yaml:"tracing"`
+	// Readiness checks configuration
+	Readiness Readiness `json:"readiness,omitempty" yaml:"readiness"`
+	// Authentication for admin endpoints such as /v1/updatePipeline
+	AdminAuth AdminAuth `json:"adminAuth,omitempty" yaml:"adminAuth"`
+	// Application ownership policy
+	Ownership Ownership `json:"ownership,omitempty" yaml:"ownership"`
+	// What to do with the application of a dinghyfile removed from its repository:
+	// ignore (default), disableTriggers, deletePipelines or deleteApplication, the
+	// latter only when every pipeline of the application was managed by the
+	// dinghyfile. disableTriggers leaves the pipelines enabled for manual runs.
+	RemovedDinghyfilePolicy string `json:"removedDinghyfilePolicy,omitempty" yaml:"removedDinghyfilePolicy"`
+	// Only list the stale pipelines deleteStalePipelines would delete, see /v1/applications/{application}/stale
+	DeleteStalePipelinesDryRun bool `json:"deleteStalePipelinesDryRun,omitempty" yaml:"deleteStalePipelinesDryRun"`
+	// Snapshot applications before updating them and restore the snapshot when an update fails part way
+	RollbackOnFailureEnabled bool `json:"rollbackOnFailureEnabled,omitempty" yaml:"rollbackOnFailureEnabled"`
+	// Renders kept per application by backends with render history, the oldest are pruned, by default 100
+	RenderHistoryLimit int `json:"renderHistoryLimit,omitempty" yaml:"renderHistoryLimit"`
+	// Periodic comparison of the last rendered dinghyfiles with Front50
+	Drift Drift `json:"drift,omitempty" yaml:"drift"`
+	// Notifications of the dinghyfile results
+	Notifiers Notifiers `json:"notifiers,omitempty" yaml:"notifiers"`
+}
+
+type Notifiers struct {
+	// Public URL of dinghy, notifications link to the log events of the push when set
+	BaseURL string `json:"baseUrl,omitempty" yaml:"baseUrl"`
+	// Slack notifications
+	Slack Slack `json:"slack,omitempty" yaml:"slack"`
+	// Outbound webhooks
+	Webhooks []Webhook `json:"webhooks,omitempty" yaml:"webhooks"`
+	// Email notifications of the failures
+	Email Email `json:"email,omitempty" yaml:"email"`
+}
+
+type Email struct {
+	// Enabled flag, failures are emailed to the application email, the email notifications of the application and the pusher
+	Enabled bool `json:"enabled,omitempty" yaml:"enabled"`
+	// SMTP server host
+	Host string `json:"host,omitempty" yaml:"host"`
+	// SMTP server port, by default 25
+	Port int `json:"port,omitempty" yaml:"port"`
+	// SMTP user, no authentication when empty
+	Username string `json:"username,omitempty" yaml:"username"`
+	// SMTP password
+	Password string `json:"password,omitempty" yaml:"password"`
+	// Sender address
+	From string `json:"from,omitempty" yaml:"from"`
+	// Also email the failures of pull request validations
+	SendOnValidation bool `json:"sendOnValidation,omitempty" yaml:"sendOnValidation"`
+	// Seconds an email can take to be sent, by default 30
+	TimeoutSeconds int `json:"timeoutSeconds,omitempty" yaml:"timeoutSeconds"`
+	// Maximum emails about an application in RateLimitMinutes, unlimited when 0.
+	// Every replica counts its own emails
+	MaxPerApplication int `json:"maxPerApplication,omitempty" yaml:"maxPerApplication"`
+	// Minutes of the rate limit window, by default 60
+	RateLimitMinutes int `json:"rateLimitMinutes,omitempty" yaml:"rateLimitMinutes"`
+	// Lines of the dinghyfile shown on each side of the error line, by default 5
+	SnippetLines int `json:"snippetLines,omitempty" yaml:"snippetLines"`
+}
+
+type Webhook struct {
+	// Name of the webhook in the logs, its URL when empty
+	Name string `json:"name,omitempty" yaml:"name"`
+	// URL the notifications are POSTed to
+	URL string `json:"url,omitempty" yaml:"url"`
+	// Go template of the body, rendered over the notification content, a JSON document of it when empty
+	Template string `json:"template,omitempty" yaml:"template"`
+	// Content type of the body, by default application/json
+	ContentType string `json:"contentType,omitempty" yaml:"contentType"`
+	// Extra headers of the requests
+	Headers map[string]string `json:"headers,omitempty" yaml:"headers"`
+	// Secret the body is signed with, the HMAC-SHA256 signature is sent in the X-Dinghy-Signature header
+	Secret string `json:"secret,omitempty" yaml:"secret"`
+	// Retries of a failed delivery, none when 0
+	MaxRetries int `json:"maxRetries,omitempty" yaml:"maxRetries"`
+	// Milliseconds before the first retry, doubled for every other one, by default 500
+	RetryBackoffMilliseconds int `json:"retryBackoffMilliseconds,omitempty" yaml:"retryBackoffMilliseconds"`
+	// Also notify the results of pull request validations
+	SendOnValidation bool `json:"sendOnValidation,omitempty" yaml:"sendOnValidation"`
+	// Only notify these sources, either org or org/repo, every source when empty
+	Repos []string `json:"repos,omitempty" yaml:"repos"`
+	// Only notify these applications, every application when empty
+	Applications []string `json:"applications,omitempty" yaml:"applications"`
+	// Only notify these events, success or failure, both when empty
+	Events []string `json:"events,omitempty" yaml:"events"`
+}
+
+type Slack struct {
+	// Enabled flag
+	Enabled bool `json:"enabled,omitempty" yaml:"enabled"`
+	// Incoming webhook the messages are posted to, unless a repo has its own destination
+	WebhookURL string `json:"webhookUrl,omitempty" yaml:"webhookUrl"`
+	// Bot token, messages are posted to Channel with chat.postMessage instead of the webhook
+	Token string `json:"token,omitempty" yaml:"token"`
+	// Channel the bot posts to
+	Channel string `json:"channel,omitempty" yaml:"channel"`
+	// Slack api endpoint, by default https://slack.com/api
+	APIURL string `json:"apiUrl,omitempty" yaml:"apiUrl"`
+	// Also notify the results of pull request validations
+	SendOnValidation bool `json:"sendOnValidation,omitempty" yaml:"sendOnValidation"`
+	// Post to the slack channels of the application notifications with the bot token
+	ApplicationChannelsEnabled bool `json:"applicationChannelsEnabled,omitempty" yaml:"applicationChannelsEnabled"`
+	// Destinations of an org or org/repo, the most specific one wins
+	Repos []SlackRepo `json:"repos,omitempty" yaml:"repos"`
+}
+
+type SlackRepo struct {
+	// Source, either org or org/repo
+	Source string `json:"source,omitempty" yaml:"source"`
+	// Incoming webhook of the source
+	WebhookURL string `json:"webhookUrl,omitempty" yaml:"webhookUrl"`
+	// Bot token of the source, the global token when empty
+	Token string `json:"token,omitempty" yaml:"token"`
+	// Channel of the source
+	Channel string `json:"channel,omitempty" yaml:"channel"`
+}
+
+type Drift struct {
+	// Enabled flag, needs a persistence backend that keeps render history
+	Enabled bool `json:"enabled,omitempty" yaml:"enabled"`
+	// Minutes between reconciliations, by default 30
+	IntervalMinutes int `json:"intervalMinutes,omitempty" yaml:"intervalMinutes"`
+	// Re-apply the last render of drifted dinghyfiles
+	AutoHealEnabled bool `json:"autoHealEnabled,omitempty" yaml:"autoHealEnabled"`
+}
+
+type LogEventRetention struct {
+	// Enabled flag, a janitor then purges the log events of every backend. The
+	// replicas take turns through Redis, with full SQL enable it on only one
+	Enabled bool `json:"enabled,omitempty" yaml:"enabled"`
+	// Minutes between purges, by default 60
+	IntervalMinutes int `json:"intervalMinutes,omitempty" yaml:"intervalMinutes"`
+	// Maximum age of the log events, LogEventTTLMinutes when 0
+	MaxAgeMinutes int `json:"maxAgeMinutes,omitempty" yaml:"maxAgeMinutes"`
+	// Maximum count of log events kept per repo, unlimited when 0
+	MaxPerRepo int `json:"maxPerRepo,omitempty" yaml:"maxPerRepo"`
+	// Maximum age of the failed log events, these don't count towards MaxPerRepo when set
+	FailureMaxAgeMinutes int `json:"failureMaxAgeMinutes,omitempty" yaml:"failureMaxAgeMinutes"`
+	// Directory the purged log events are archived to as gzipped JSON lines, nothing is archived when empty
+	ArchiveDir string `json:"archiveDir,omitempty" yaml:"archiveDir"`
+}
+
+type Ownership struct {
+	// Enabled flag, when enabled an application can only be managed by the first dinghyfile that managed it
+	Enabled bool `json:"enabled,omitempty" yaml:"enabled"`
+	// Explicit owners, these applications are not recorded in the registry
+	Applications []ApplicationOwners `json:"applications,omitempty" yaml:"applications"`
+}
+
+type ApplicationOwners struct {
+	// Application name
+	Application string `json:"application,omitempty" yaml:"application"`
+	// Sources allowed to manage the application, either org/repo or org/repo/path
+	Sources []string `json:"sources,omitempty" yaml:"sources"`
+}
+
+type AdminAuth struct {
+	// Enabled flag, when disabled admin endpoints reject every request
+	Enabled bool `json:"enabled,omitempty" yaml:"enabled"`
+	// Let anyone call the admin endpoints while authentication is disabled, as
+	// before admin authentication existed
+	AnonymousAllowed bool `json:"anonymousAllowed,omitempty" yaml:"anonymousAllowed"`
+	// Static bearer tokens accepted for admin endpoints
+	Tokens []AdminToken `json:"tokens,omitempty" yaml:"tokens"`
+	// Accept the common name of a verified client certificate as the caller
+	ClientCertificatesEnabled bool `json:"clientCertificatesEnabled,omitempty" yaml:"clientCertificatesEnabled"`
+	// Fiat roles allowed to call admin endpoints, any authenticated caller is allowed when empty
+	Roles []string `json:"roles,omitempty" yaml:"roles"`
+}
+
+type AdminToken struct {
+	// User the token authenticates as, used for Fiat checks
+	User string `json:"user,omitempty" yaml:"user"`
+	// Bearer token, can be an encrypted secret
+	Token string `json:"token,omitempty" yaml:"token"`
+}
+
+type Readiness struct {
+	// Seconds between dependency checks, by default 10
+	IntervalSeconds int `json:"intervalSeconds,omitempty" yaml:"intervalSeconds"`
+	// Seconds before a single check is considered failed, by default 5
+	TimeoutSeconds int `json:"timeoutSeconds,omitempty" yaml:"timeoutSeconds"`
+	// Skip validating git provider tokens, useful to save api rate limit
+	GitTokenCheckDisabled bool `json:"gitTokenCheckDisabled,omitempty" yaml:"gitTokenCheckDisabled"`
+}
+
+type Tracing struct {
+	// Enabled flag, when disabled incoming trace context is still propagated to Spinnaker
+	Enabled bool `json:"enabled,omitempty" yaml:"enabled"`
+	// OTLP/HTTP collector endpoint (host:port)
+	Endpoint string `json:"endpoint,omitempty" yaml:"endpoint"`
+	// URL path spans are sent to, by default /v1/traces
+	URLPath string `json:"urlPath,omitempty" yaml:"urlPath"`
+	// Send spans over plain http
+	Insecure bool `json:"insecure,omitempty" yaml:"insecure"`
+	// Service name reported in spans, by default the InstanceId
+	ServiceName string `json:"serviceName,omitempty" yaml:"serviceName"`
 }
 
 type Sqlconfig struct {
 	// Enabled flag
 	Enabled bool `json:"enabled,omitempty" yaml:"enabled"`
+	// Database engine, mysql (default), postgres or sqlite
+	Dialect string `json:"dialect,omitempty" yaml:"dialect"`
 	// Database url
 	BaseUrl string `json:"baseUrl" yaml:"baseUrl"`
 	// User
 	User string `json:"user" yaml:"user"`
 	// Password
 	Password string `json:"password" yaml:"password"`
-	// DB name
+	// DB name, the path of the database file for sqlite
 	DatabaseName string `json:"databaseName" yaml:"databaseName"`
+	// PostgreSQL sslmode, the driver default when empty
+	SSLMode string `json:"sslMode,omitempty" yaml:"sslMode"`
 	// If this flag is enabled only events will be saved in database, redis will continue to be used for relationships
 	EventLogsOnly bool `json:"eventlogsOnly" yaml:"eventlogsOnly"`
 }
@@ -210,7 +416,7 @@ type SpinnakerSupplied struct {
 	Gate SpinnakerService `json:"gate,omitempty" yaml:"gate"`
 	// Fiat service information
 	Fiat Fiat `json:"fiat,omitempty" yaml:"fiat"`
-	// Redis service information, user will always be default
+	// Redis service information, the default user unless Username is set
 	Redis Redis `json:"redis,omitempty" yaml:"redis"`
 }
 
@@ -218,6 +424,41 @@ type Redis struct {
 	BaseURL    string `json:"baseUrl,omitempty" yaml:"baseUrl"`
 	Password   string `json:"password,omitempty" yaml:"password"`
 	Connection string `json:"connection,omitempty" yaml:"connection"`
+	// ACL user, the default user when empty
+	Username string `json:"username,omitempty" yaml:"username"`
+	// Database index, Cluster only has database 0
+	Database int `json:"database,omitempty" yaml:"database"`
+	// Sentinel setup, BaseURL is ignored when a master name is set
+	Sentinel RedisSentinel `json:"sentinel,omitempty" yaml:"sentinel"`
+	// Cluster setup
+	Cluster RedisCluster `json:"cluster,omitempty" yaml:"cluster"`
+	// TLS setup, a rediss:// BaseURL enables it too
+	TLS RedisTLS `json:"tls,omitempty" yaml:"tls"`
+}
+
+type RedisSentinel struct {
+	// Name of the master the sentinels monitor
+	MasterName string `json:"masterName,omitempty" yaml:"masterName"`
+	// host:port of the sentinels
+	Addresses []string `json:"addresses,omitempty" yaml:"addresses"`
+}
+
+type RedisCluster struct {
+	// Enabled flag
+	Enabled bool `json:"enabled,omitempty" yaml:"enabled"`
+	// host:port of the nodes to discover the cluster from, BaseURL when empty
+	Addresses []string `json:"addresses,omitempty" yaml:"addresses"`
+}
+
+type RedisTLS struct {
+	// Enabled flag
+	Enabled bool `json:"enabled,omitempty" yaml:"enabled"`
+	// PEM file of the CAs to trust, the system ones when empty
+	CAFile string `json:"caFile,omitempty" yaml:"caFile"`
+	// Name to verify the certificate of the server against, the host when empty
+	ServerName string `json:"serverName,omitempty" yaml:"serverName"`
+	// Skip the verification of the certificate of the server
+	InsecureSkipVerify bool `json:"insecureSkipVerify,omitempty" yaml:"insecureSkipVerify"`
 }
 
 type Fiat struct {
@@ -297,15 +538,54 @@ func (s *Settings) Redacted() *Settings {
 	if redacted.SpinnakerSupplied.Redis.Password != "" {
 		redacted.SpinnakerSupplied.Redis.Password = "**REDACTED**"
 	}
+	if len(redacted.AdminAuth.Tokens) > 0 {
+		tokens := make([]AdminToken, len(redacted.AdminAuth.Tokens))
+		for i, t := range redacted.AdminAuth.Tokens {
+			tokens[i] = AdminToken{User: t.User, Token: "**REDACTED**"}
+		}
+		redacted.AdminAuth.Tokens = tokens
+	}
+	redacted.Notifiers.Slack.WebhookURL = redact(redacted.Notifiers.Slack.WebhookURL)
+	redacted.Notifiers.Slack.Token = redact(redacted.Notifiers.Slack.Token)
+	if len(redacted.Notifiers.Slack.Repos) > 0 {
+		repos := make([]SlackRepo, len(redacted.Notifiers.Slack.Repos))
+		for i, r := range redacted.Notifiers.Slack.Repos {
+			repos[i] = SlackRepo{Source: r.Source, WebhookURL: redact(r.WebhookURL), Token: redact(r.Token), Channel: r.Channel}
+		}
+		redacted.Notifiers.Slack.Repos = repos
+	}
+	redacted.Notifiers.Email.Password = redact(redacted.Notifiers.Email.Password)
+	if len(redacted.Notifiers.Webhooks) > 0 {
+		webhooks := make([]Webhook, len(redacted.Notifiers.Webhooks))
+		for i, w := range redacted.Notifiers.Webhooks {
+			webhooks[i] = w
+			webhooks[i].Secret = redact(w.Secret)
+			if len(w.Headers) > 0 {
+				webhooks[i].Headers = map[string]string{}
+				for name, value := range w.Headers {
+					webhooks[i].Headers[name] = redact(value)
+				}
+			}
+		}
+		redacted.Notifiers.Webhooks = webhooks
+	}
 	return redacted
 }
 
+// redact hides a sensitive value, keeping empty values empty
+func redact(value string) string {
+	if value == "" {
+		return ""
+	}
+	return "**REDACTED**"
+}
+
 // TraceExtract middleware extracts trace context from http headers following w3c trace context format
 // and adds it to the request context
 func (s *Settings) TraceExtract() func(handler http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			reqWithTraceContext := r
+			reqWithTraceContext := r.WithContext(tracing.Extract(r.Context(), r.Header))
 			next.ServeHTTP(w, reqWithTraceContext)
 		})
 	}
@@ -0,0 +1,123 @@
+/*
+* Copyright 2026 Armory, Inc.
+
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+
+*    http://www.apache.org/licenses/LICENSE-2.0
+
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+// Package tracing wires W3C trace context propagation and OpenTelemetry spans
+// through dinghy, from the incoming webhook down to the calls made to Spinnaker.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.25.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// TracerName is the instrumentation name used for every dinghy span
+	TracerName = "github.com/armory/dinghy"
+	// DefaultURLPath is the OTLP/HTTP path spans are posted to
+	DefaultURLPath = "/v1/traces"
+)
+
+// Span names used across dinghy
+const (
+	SpanWebhook  = "dinghy.webhook"
+	SpanDownload = "dinghy.download"
+	SpanRender   = "dinghy.render"
+	SpanValidate = "dinghy.validate"
+	SpanUpsert   = "dinghy.upsert"
+)
+
+// Config holds what is needed to export spans to an OTLP collector
+type Config struct {
+	ServiceName string
+	Endpoint    string
+	URLPath     string
+	Insecure    bool
+}
+
+var propagator = propagation.TraceContext{}
+
+// Init configures the global tracer provider to export spans over OTLP/HTTP.
+// The returned function flushes and stops the exporter.
+func Init(ctx context.Context, c Config) (func(context.Context) error, error) {
+	if c.Endpoint == "" {
+		return nil, fmt.Errorf("tracing is enabled but no endpoint was configured")
+	}
+	opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(c.Endpoint)}
+	if c.URLPath != "" {
+		opts = append(opts, otlptracehttp.WithURLPath(c.URLPath))
+	}
+	if c.Insecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	exporter, err := otlptracehttp.New(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create otlp exporter: %w", err)
+	}
+
+	res := resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(c.ServiceName))
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagator)
+	return tp.Shutdown, nil
+}
+
+// Extract returns a copy of ctx carrying the remote span context found in the
+// traceparent header, if any.
+func Extract(ctx context.Context, headers http.Header) context.Context {
+	return propagator.Extract(ctx, propagation.HeaderCarrier(headers))
+}
+
+// Traceparent formats the span context held by ctx as a w3c traceparent header
+// value. It returns an empty string when ctx carries no valid span context.
+func Traceparent(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	carrier := propagation.MapCarrier{}
+	propagator.Inject(ctx, carrier)
+	return carrier.Get("traceparent")
+}
+
+// StartSpan starts a span named name as a child of the span held by ctx.
+func StartSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return otel.Tracer(TracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// EndSpan records err on span, if any, and ends it.
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,80 @@
+/*
+* Copyright 2026 Armory, Inc.
+
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+
+*    http://www.apache.org/licenses/LICENSE-2.0
+
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const incoming = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func TestTraceparent(t *testing.T) {
+	cases := map[string]struct {
+		ctx      context.Context
+		expected string
+	}{
+		"nil context": {
+			ctx:      nil,
+			expected: "",
+		},
+		"no span context": {
+			ctx:      context.Background(),
+			expected: "",
+		},
+		"extracted from headers": {
+			ctx:      Extract(context.Background(), http.Header{"Traceparent": []string{incoming}}),
+			expected: incoming,
+		},
+		"malformed header": {
+			ctx:      Extract(context.Background(), http.Header{"Traceparent": []string{"not-a-traceparent"}}),
+			expected: "",
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, c.expected, Traceparent(c.ctx))
+		})
+	}
+}
+
+func TestStartSpanKeepsTrace(t *testing.T) {
+	parent := Extract(context.Background(), http.Header{"Traceparent": []string{incoming}})
+	ctx, span := StartSpan(parent, SpanRender)
+	defer EndSpan(span, errors.New("boom"))
+
+	// Without a tracer provider the incoming trace is forwarded untouched
+	assert.Equal(t, incoming, Traceparent(ctx))
+}
+
+func TestStartSpanNilContext(t *testing.T) {
+	ctx, span := StartSpan(nil, SpanUpsert)
+	defer EndSpan(span, nil)
+
+	assert.NotNil(t, ctx)
+	assert.Equal(t, "", Traceparent(ctx))
+}
+
+func TestInitRequiresEndpoint(t *testing.T) {
+	_, err := Init(context.Background(), Config{ServiceName: "dinghy"})
+	assert.Error(t, err)
+}
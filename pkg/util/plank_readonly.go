@@ -66,6 +66,10 @@ func (p *PlankReadOnly) DeletePipeline(plank.Pipeline, string) error {
 	return nil
 }
 
+func (p *PlankReadOnly) DeleteApplication(string, string) error {
+	return nil
+}
+
 func (p *PlankReadOnly) UpsertPipeline(pipe plank.Pipeline, appName string, traceparent string) error {
 	// This is getting a little complex
 	// When a pipeline does not exists dinghy create it so it can be referenced
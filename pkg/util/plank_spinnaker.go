@@ -0,0 +1,60 @@
+/*
+* Copyright 2026 Armory, Inc.
+
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+
+*    http://www.apache.org/licenses/LICENSE-2.0
+
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package util
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/armory/plank/v4"
+)
+
+// SpinnakerClient is the client dinghy writes to Spinnaker with, it adds the
+// calls plank doesn't make to a PlankClient
+type SpinnakerClient struct {
+	PlankClient
+	HTTP     *http.Client
+	Front50  string
+	FiatUser string
+}
+
+// DeleteApplication deletes an application from Front50. The pipelines of the
+// application must be deleted first, an application already gone isn't an error.
+func (c *SpinnakerClient) DeleteApplication(name, traceparent string) error {
+	req, err := http.NewRequest(http.MethodDelete, fmt.Sprintf("%s/v2/applications/%s", c.Front50, url.PathEscape(name)), nil)
+	if err != nil {
+		return err
+	}
+	if c.FiatUser != "" {
+		req.Header.Set("X-Spinnaker-User", c.FiatUser)
+	}
+	if traceparent != "" {
+		req.Header.Set("traceparent", traceparent)
+	}
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < http.StatusMultipleChoices || resp.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	body, _ := io.ReadAll(resp.Body)
+	return &plank.FailedResponse{Response: body, StatusCode: resp.StatusCode}
+}
@@ -0,0 +1,52 @@
+/*
+* Copyright 2026 Armory, Inc.
+
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+
+*    http://www.apache.org/licenses/LICENSE-2.0
+
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package util
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/armory/plank/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeleteApplication(t *testing.T) {
+	status := http.StatusOK
+	var method, path, user string
+	front50 := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, path, user = r.Method, r.URL.EscapedPath(), r.Header.Get("X-Spinnaker-User")
+		w.WriteHeader(status)
+	}))
+	defer front50.Close()
+
+	c := &SpinnakerClient{HTTP: front50.Client(), Front50: front50.URL, FiatUser: "dinghy"}
+	assert.Nil(t, c.DeleteApplication("my app", ""))
+	assert.Equal(t, http.MethodDelete, method)
+	assert.Equal(t, "/v2/applications/my%20app", path)
+	assert.Equal(t, "dinghy", user)
+
+	// already deleted
+	status = http.StatusNotFound
+	assert.Nil(t, c.DeleteApplication("myapp", ""))
+
+	status = http.StatusInternalServerError
+	err := c.DeleteApplication("myapp", "")
+	if assert.IsType(t, &plank.FailedResponse{}, err) {
+		assert.Equal(t, http.StatusInternalServerError, err.(*plank.FailedResponse).StatusCode)
+	}
+}
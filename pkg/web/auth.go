@@ -0,0 +1,107 @@
+/*
+* Copyright 2026 Armory, Inc.
+
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+
+*    http://www.apache.org/licenses/LICENSE-2.0
+
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package web
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strings"
+
+	dinghylog "github.com/armory/dinghy/pkg/log"
+	"github.com/armory/dinghy/pkg/settings/global"
+	"github.com/armory/dinghy/pkg/tracing"
+	"github.com/armory/dinghy/pkg/util"
+)
+
+var (
+	ErrUnauthenticated = errors.New("missing or invalid credentials")
+	ErrForbidden       = errors.New("caller is not allowed to use admin endpoints")
+	ErrAdminDisabled   = errors.New("admin endpoints are disabled until adminAuth is enabled")
+)
+
+// authenticateAdmin resolves who is calling an admin endpoint, either from a
+// bearer token configured in settings or from a verified client certificate.
+func authenticateAdmin(r *http.Request, auth global.AdminAuth) (string, error) {
+	if header := r.Header.Get("Authorization"); strings.HasPrefix(header, "Bearer ") {
+		token := strings.TrimPrefix(header, "Bearer ")
+		for _, t := range auth.Tokens {
+			if t.Token != "" && subtle.ConstantTimeCompare([]byte(t.Token), []byte(token)) == 1 {
+				return t.User, nil
+			}
+		}
+		return "", ErrUnauthenticated
+	}
+
+	if auth.ClientCertificatesEnabled && r.TLS != nil && len(r.TLS.VerifiedChains) > 0 && len(r.TLS.VerifiedChains[0]) > 0 {
+		if cn := r.TLS.VerifiedChains[0][0].Subject.CommonName; cn != "" {
+			return cn, nil
+		}
+	}
+	return "", ErrUnauthenticated
+}
+
+// authorizeAdmin authenticates the caller of an admin endpoint and, when roles
+// are configured, checks them against Fiat. It writes the http error itself,
+// so handlers should return when ok is false. Without admin authentication
+// every request is rejected, unless anonymous admins are explicitly allowed;
+// the caller is empty then.
+func (wa *WebAPI) authorizeAdmin(w http.ResponseWriter, r *http.Request, s *global.Settings, pc util.PlankClient, l dinghylog.DinghyLog) (caller string, ok bool) {
+	if !s.AdminAuth.Enabled {
+		if s.AdminAuth.AnonymousAllowed {
+			return "", true
+		}
+		l.Warnf("Rejected request to %s: %s", r.URL.Path, ErrAdminDisabled.Error())
+		util.WriteHTTPError(w, http.StatusForbidden, ErrAdminDisabled)
+		return "", false
+	}
+
+	caller, err := authenticateAdmin(r, s.AdminAuth)
+	if err != nil {
+		l.Warnf("Rejected request to %s: %s", r.URL.Path, err.Error())
+		util.WriteHTTPError(w, http.StatusUnauthorized, err)
+		return "", false
+	}
+
+	if len(s.AdminAuth.Roles) > 0 {
+		roles, err := pc.UserRoles(caller, tracing.Traceparent(r.Context()))
+		if err != nil {
+			l.Errorf("Failed to fetch %s's roles from Fiat: %s", caller, err.Error())
+			util.WriteHTTPError(w, http.StatusForbidden, ErrForbidden)
+			return "", false
+		}
+		if !hasAnyRole(roles, s.AdminAuth.Roles) {
+			l.Warnf("Rejected request to %s from %s: missing required role", r.URL.Path, caller)
+			util.WriteHTTPError(w, http.StatusForbidden, ErrForbidden)
+			return "", false
+		}
+	}
+
+	l.Infof("Request to %s authenticated as %s", r.URL.Path, caller)
+	return caller, true
+}
+
+func hasAnyRole(roles []string, allowed []string) bool {
+	for _, role := range roles {
+		for _, a := range allowed {
+			if strings.EqualFold(role, a) {
+				return true
+			}
+		}
+	}
+	return false
+}
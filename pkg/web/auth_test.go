@@ -0,0 +1,192 @@
+/*
+* Copyright 2026 Armory, Inc.
+
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+
+*    http://www.apache.org/licenses/LICENSE-2.0
+
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package web
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/armory/dinghy/pkg/dinghyfile"
+	"github.com/armory/dinghy/pkg/mock"
+	"github.com/armory/dinghy/pkg/settings/global"
+	"github.com/armory/dinghy/pkg/settings/source"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+// anonymousAdmin lets the route tests call admin endpoints without credentials
+var anonymousAdmin = &global.Settings{AdminAuth: global.AdminAuth{AnonymousAllowed: true}}
+
+func TestAuthenticateAdmin(t *testing.T) {
+	auth := global.AdminAuth{
+		Enabled: true,
+		Tokens: []global.AdminToken{
+			{User: "ci-bot", Token: "s3cr3t"},
+		},
+	}
+	withCert := func(r *http.Request, cn string) *http.Request {
+		r.TLS = &tls.ConnectionState{
+			VerifiedChains: [][]*x509.Certificate{{{Subject: pkix.Name{CommonName: cn}}}},
+		}
+		return r
+	}
+
+	cases := map[string]struct {
+		req       *http.Request
+		certs     bool
+		expected  string
+		expectErr error
+	}{
+		"valid token": {
+			req:      withHeader(httptest.NewRequest("POST", "/v1/updatePipeline", nil), "Bearer s3cr3t"),
+			expected: "ci-bot",
+		},
+		"invalid token": {
+			req:       withHeader(httptest.NewRequest("POST", "/v1/updatePipeline", nil), "Bearer nope"),
+			expectErr: ErrUnauthenticated,
+		},
+		"no credentials": {
+			req:       httptest.NewRequest("POST", "/v1/updatePipeline", nil),
+			expectErr: ErrUnauthenticated,
+		},
+		"client certificate": {
+			req:      withCert(httptest.NewRequest("POST", "/v1/updatePipeline", nil), "deployer"),
+			certs:    true,
+			expected: "deployer",
+		},
+		"client certificate not accepted": {
+			req:       withCert(httptest.NewRequest("POST", "/v1/updatePipeline", nil), "deployer"),
+			expectErr: ErrUnauthenticated,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			a := auth
+			a.ClientCertificatesEnabled = c.certs
+			caller, err := authenticateAdmin(c.req, a)
+			assert.Equal(t, c.expectErr, err)
+			assert.Equal(t, c.expected, caller)
+		})
+	}
+}
+
+func TestAuthorizeAdmin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	settings := &global.Settings{
+		AdminAuth: global.AdminAuth{
+			Enabled: true,
+			Tokens:  []global.AdminToken{{User: "ci-bot", Token: "s3cr3t"}},
+			Roles:   []string{"admins"},
+		},
+	}
+	logger := mock.NewMockDinghyLog(ctrl)
+	logger.EXPECT().Infof(gomock.Any(), gomock.Any()).AnyTimes()
+	logger.EXPECT().Warnf(gomock.Any(), gomock.Any()).AnyTimes()
+	logger.EXPECT().Errorf(gomock.Any(), gomock.Any()).AnyTimes()
+
+	cases := map[string]struct {
+		roles    []string
+		rolesErr error
+		status   int
+		ok       bool
+	}{
+		"has role": {
+			roles:  []string{"devs", "admins"},
+			status: http.StatusOK,
+			ok:     true,
+		},
+		"missing role": {
+			roles:  []string{"devs"},
+			status: http.StatusForbidden,
+		},
+		"fiat error": {
+			rolesErr: errors.New("fiat is down"),
+			status:   http.StatusForbidden,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			client := dinghyfile.NewMockPlankClient(ctrl)
+			client.EXPECT().UserRoles(gomock.Eq("ci-bot"), gomock.Any()).Return(c.roles, c.rolesErr).Times(1)
+
+			wa := &WebAPI{}
+			rr := httptest.NewRecorder()
+			req := withHeader(httptest.NewRequest("POST", "/v1/updatePipeline", nil), "Bearer s3cr3t")
+			caller, ok := wa.authorizeAdmin(rr, req, settings, client, logger)
+			assert.Equal(t, c.ok, ok)
+			assert.Equal(t, c.status, rr.Code)
+			if c.ok {
+				assert.Equal(t, "ci-bot", caller)
+			}
+		})
+	}
+}
+
+func TestAuthorizeAdminDisabled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	logger := mock.NewMockDinghyLog(ctrl)
+	logger.EXPECT().Warnf(gomock.Any(), gomock.Any()).Times(1)
+
+	wa := &WebAPI{}
+	rr := httptest.NewRecorder()
+	_, ok := wa.authorizeAdmin(rr, httptest.NewRequest("POST", "/v1/updatePipeline", nil), &global.Settings{}, nil, logger)
+	assert.False(t, ok)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+}
+
+func TestAuthorizeAdminAnonymousAllowed(t *testing.T) {
+	wa := &WebAPI{}
+	rr := httptest.NewRecorder()
+	caller, ok := wa.authorizeAdmin(rr, httptest.NewRequest("POST", "/v1/updatePipeline", nil), anonymousAdmin, nil, nil)
+	assert.True(t, ok)
+	assert.Equal(t, "", caller)
+}
+
+func TestManualUpdateHandlerUnauthenticated(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	logger := mock.NewMockFieldLogger(ctrl)
+	logger.EXPECT().WithFields(gomock.Any())
+	logger.EXPECT().Warnf(gomock.Any(), gomock.Any()).Times(1)
+
+	sourceConfig := source.NewMockSourceConfiguration(ctrl)
+	sourceConfig.EXPECT().GetSettings(gomock.Any(), gomock.Any()).Return(&global.Settings{
+		AdminAuth: global.AdminAuth{Enabled: true},
+	}, dinghyfile.NewMockPlankClient(ctrl), nil)
+
+	wa := NewWebAPI(sourceConfig, nil, nil, logger, nil, nil, nil, nil)
+	rr := httptest.NewRecorder()
+	wa.manualUpdateHandler(rr, httptest.NewRequest("POST", "/v1/updatePipeline", nil))
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+}
+
+func withHeader(r *http.Request, authorization string) *http.Request {
+	r.Header.Set("Authorization", authorization)
+	return r
+}
@@ -0,0 +1,73 @@
+/*
+* Copyright 2026 Armory, Inc.
+
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+
+*    http://www.apache.org/licenses/LICENSE-2.0
+
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package web
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/armory/dinghy/pkg/dinghyfile"
+	"github.com/armory/dinghy/pkg/dinghyfile/pipebuilder"
+	"github.com/armory/dinghy/pkg/drift"
+	"github.com/armory/dinghy/pkg/history"
+	dinghylog "github.com/armory/dinghy/pkg/log"
+	"github.com/armory/dinghy/pkg/managed"
+	"github.com/armory/dinghy/pkg/settings/global"
+	"github.com/armory/dinghy/pkg/util"
+)
+
+var ErrDriftUnsupported = errors.New("drift detection needs a persistence backend that lists dinghyfiles, records managed pipelines and keeps render history")
+
+// NewDriftReconciler returns the drift reconciler of the persistence backend,
+// its builders use the notifiers registered by the time it runs
+func (wa *WebAPI) NewDriftReconciler(s *global.Settings, client util.PlankClient) (*drift.Reconciler, error) {
+	roots, okRoots := wa.Cache.(dinghyfile.RootLister)
+	managedStore, okManaged := wa.Cache.(managed.Store)
+	historyStore, okHistory := wa.Cache.(history.Store)
+	if !okRoots || !okManaged || !okHistory {
+		return nil, ErrDriftUnsupported
+	}
+
+	return &drift.Reconciler{
+		Interval: time.Duration(s.Drift.IntervalMinutes) * time.Minute,
+		AutoHeal: s.Drift.AutoHealEnabled,
+		Roots:    roots,
+		Managed:  managedStore,
+		History:  historyStore,
+		Logger:   wa.Logger,
+		NewBuilder: func(l dinghylog.DinghyLog) *dinghyfile.PipelineBuilder {
+			return &dinghyfile.PipelineBuilder{
+				Depman:                             wa.Cache,
+				Client:                             client,
+				EventClient:                        wa.EventClient,
+				AutolockPipelines:                  s.AutoLockPipelines,
+				Logger:                             l,
+				Ums:                                wa.Ums,
+				Notifiers:                          wa.Notifiers,
+				Action:                             pipebuilder.Process,
+				JsonValidationDisabled:             s.JsonValidationDisabled,
+				UpsertPipelineUsingOrcaTaskEnabled: s.UpsertPipelineUsingOrcaTaskEnabled,
+				StalePipelinesDryRun:               s.DeleteStalePipelinesDryRun,
+				RollbackOnFailure:                  s.RollbackOnFailureEnabled,
+				Managed:                            managedStore,
+				OwnershipPolicy:                    ownershipPolicy(s, wa.Cache),
+				Ctx:                                context.Background(),
+			}
+		},
+	}, nil
+}
@@ -0,0 +1,39 @@
+/*
+* Copyright 2026 Armory, Inc.
+
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+
+*    http://www.apache.org/licenses/LICENSE-2.0
+
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+package web
+
+import (
+	"testing"
+	"time"
+
+	"github.com/armory/dinghy/pkg/cache"
+	"github.com/armory/dinghy/pkg/settings/global"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewDriftReconciler(t *testing.T) {
+	s := &global.Settings{Drift: global.Drift{Enabled: true, IntervalMinutes: 5}}
+
+	wa := NewWebAPI(nil, cache.NewMemoryCache(), nil, nil, nil, nil, nil, nil)
+	_, err := wa.NewDriftReconciler(s, nil)
+	assert.Equal(t, ErrDriftUnsupported, err)
+
+	wa = NewWebAPI(nil, &historyCache{MemoryCache: cache.NewMemoryCache()}, nil, nil, nil, nil, nil, nil)
+	reconciler, err := wa.NewDriftReconciler(s, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 5*time.Minute, reconciler.Interval)
+	assert.NotNil(t, reconciler.NewBuilder(nil))
+}
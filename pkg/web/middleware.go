@@ -69,11 +69,19 @@ type TraceSettings interface {
 type TraceContextKey struct{}
 
 func extractTraceContext(ctx context.Context) (*TraceContext, error) {
-	v, ok := ctx.Value(TraceContextKey{}).(TraceContext)
-	if !ok {
-		return nil, errors.New("unable to extract trace context from request")
+	if v, ok := ctx.Value(TraceContextKey{}).(TraceContext); ok {
+		return &v, nil
 	}
-	return &v, nil
+	// Fall back to the span context extracted by the TraceExtract middleware
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		return &TraceContext{
+			Version: "00",
+			TraceID: sc.TraceID().String(),
+			SpanID:  sc.SpanID().String(),
+			Sampled: sc.TraceFlags().String(),
+		}, nil
+	}
+	return nil, errors.New("unable to extract trace context from request")
 }
 
 // TraceContext maps to the w3c traceparent header  https://w3c.github.io/trace-context/#traceparent-header
@@ -18,6 +18,7 @@ package web
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -28,6 +29,7 @@ import (
 	"github.com/armory/dinghy/pkg/settings/source"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"path/filepath"
 	"strings"
 
@@ -43,15 +45,21 @@ import (
 	"github.com/armory/dinghy/pkg/git/github"
 	"github.com/armory/dinghy/pkg/git/gitlab"
 	"github.com/armory/dinghy/pkg/git/stash"
+	"github.com/armory/dinghy/pkg/health"
+	"github.com/armory/dinghy/pkg/history"
+	"github.com/armory/dinghy/pkg/managed"
 	"github.com/armory/dinghy/pkg/notifiers"
+	"github.com/armory/dinghy/pkg/tracing"
 	"github.com/armory/dinghy/pkg/util"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // Push represents a push notification from a git service.
 type Push interface {
 	ContainsFile(file string) bool
 	Files() []string
+	RemovedFiles() []string
 	Repo() string
 	Org() string
 	Branch() string
@@ -87,7 +95,10 @@ type WebAPI struct {
 	LogEventsClient logevents.LogEventsClient
 	MuxRouter       *mux.Router
 	Logr            *log.Logger
+	Readiness       *health.Monitor
 	MetricsHandler
+
+	resyncJobs resyncJobs
 }
 
 func NewWebAPI(s source.SourceConfiguration, r dinghyfile.DependencyManager, e *events.Client, l log.FieldLogger, depreadonly dinghyfile.DependencyManager, clientreadonly util.PlankClient, logeventsClient logevents.LogEventsClient, logr *log.Logger) *WebAPI {
@@ -129,6 +140,8 @@ func (wa *WebAPI) Router(ts TraceSettings) *mux.Router {
 	r.HandleFunc(wa.MetricsHandler.WrapHandleFunc("/", wa.healthcheck))
 	r.HandleFunc(wa.MetricsHandler.WrapHandleFunc("/health", wa.healthcheck))
 	r.HandleFunc(wa.MetricsHandler.WrapHandleFunc("/healthcheck", wa.healthcheck))
+	r.HandleFunc(wa.MetricsHandler.WrapHandleFunc("/live", wa.healthcheck))
+	r.HandleFunc(wa.MetricsHandler.WrapHandleFunc("/ready", wa.readiness))
 	r.HandleFunc(wa.MetricsHandler.WrapHandleFunc("/v1/logevents", wa.logevents)).Methods("GET")
 	r.HandleFunc(wa.MetricsHandler.WrapHandleFunc("/v1/webhooks/github", wa.githubWebhookHandler)).Methods("POST")
 	r.HandleFunc(wa.MetricsHandler.WrapHandleFunc("/v1/webhooks/gitlab", wa.gitlabWebhookHandler)).Methods("POST")
@@ -137,6 +150,21 @@ func (wa *WebAPI) Router(ts TraceSettings) *mux.Router {
 	// all of the bitbucket webhooks come through this one handler, this is being left for backwards compatibility
 	r.HandleFunc(wa.MetricsHandler.WrapHandleFunc("/v1/webhooks/bitbucket-cloud", wa.bitbucketWebhookHandler)).Methods("POST")
 	r.HandleFunc(wa.MetricsHandler.WrapHandleFunc("/v1/updatePipeline", wa.manualUpdateHandler)).Methods("POST")
+	r.HandleFunc(wa.MetricsHandler.WrapHandleFunc("/v1/ownership/{application}", wa.getOwnership)).Methods("GET")
+	r.HandleFunc(wa.MetricsHandler.WrapHandleFunc("/v1/ownership/{application}", wa.transferOwnership)).Methods("PUT")
+	r.HandleFunc(wa.MetricsHandler.WrapHandleFunc("/v1/resync", wa.startResync)).Methods("POST")
+	r.HandleFunc(wa.MetricsHandler.WrapHandleFunc("/v1/resync/{id}", wa.getResync)).Methods("GET")
+	r.HandleFunc(wa.MetricsHandler.WrapHandleFunc("/v1/graph", wa.getGraph)).Methods("GET")
+	r.HandleFunc(wa.MetricsHandler.WrapHandleFunc("/v1/graph/dependents", wa.getDependents)).Methods("GET")
+	r.HandleFunc(wa.MetricsHandler.WrapHandleFunc("/v1/graph/dependencies", wa.getDependencies)).Methods("GET")
+	r.HandleFunc(wa.MetricsHandler.WrapHandleFunc("/v1/graph/orphans", wa.getOrphans)).Methods("GET")
+	r.HandleFunc(wa.MetricsHandler.WrapHandleFunc("/v1/backup", wa.exportBackup)).Methods("GET")
+	r.HandleFunc(wa.MetricsHandler.WrapHandleFunc("/v1/backup", wa.importBackup)).Methods("POST")
+	r.HandleFunc(wa.MetricsHandler.WrapHandleFunc("/v1/applications/{application}/stale", wa.listStalePipelines)).Methods("GET")
+	r.HandleFunc(wa.MetricsHandler.WrapHandleFunc("/v1/applications/{application}/renders", wa.listRenders)).Methods("GET")
+	r.HandleFunc(wa.MetricsHandler.WrapHandleFunc("/v1/applications/{application}/renders/{render}", wa.getRender)).Methods("GET")
+	r.HandleFunc(wa.MetricsHandler.WrapHandleFunc("/v1/applications/{application}/renders/{render}/rollback", wa.rollbackRender)).Methods("POST")
+	r.HandleFunc(wa.MetricsHandler.WrapHandleFunc("/v1/applications/{application}/renders/{render}/diff/{to}", wa.diffRenders)).Methods("GET")
 	r.Use(RequestLoggingMiddleware)
 	return r
 }
@@ -145,20 +173,62 @@ func (wa *WebAPI) Router(ts TraceSettings) *mux.Router {
 // route handlers
 // ==============
 
+// logevents lists the log events, the org, repo and commit query parameters
+// only keep the events matching all of them
 func (wa *WebAPI) logevents(w http.ResponseWriter, r *http.Request) {
 	logEvents, err := wa.LogEventsClient.GetLogEvents()
 	if err == nil {
 
 	}
+	logEvents = filterLogEvents(logEvents, r.URL.Query())
 	bytesResult, _ := json.Marshal(logEvents)
 	w.Write(bytesResult)
 }
 
+func filterLogEvents(logEvents []logevents.LogEvent, query url.Values) []logevents.LogEvent {
+	org, repo, commit := query.Get("org"), query.Get("repo"), query.Get("commit")
+	if org == "" && repo == "" && commit == "" {
+		return logEvents
+	}
+	filtered := []logevents.LogEvent{}
+	for _, e := range logEvents {
+		if (org != "" && e.Org != org) || (repo != "" && e.Repo != repo) {
+			continue
+		}
+		if commit != "" && !contains(e.Commits, commit) {
+			continue
+		}
+		filtered = append(filtered, e)
+	}
+	return filtered
+}
+
 func (wa *WebAPI) healthcheck(w http.ResponseWriter, r *http.Request) {
 	wa.Logger.Debug(r.RemoteAddr, " Requested ", r.RequestURI)
 	w.Write([]byte(`{"status":"ok"}`))
 }
 
+// readiness reports the latest dependency checks, answering 503 while any of
+// them is failing so traffic is routed away from this instance
+func (wa *WebAPI) readiness(w http.ResponseWriter, r *http.Request) {
+	ready, checks := true, map[string]health.Result{}
+	if wa.Readiness != nil {
+		ready, checks = wa.Readiness.Ready()
+	}
+
+	status := health.StatusOk
+	w.Header().Set("Content-Type", "application/json")
+	if !ready {
+		status = health.StatusFailing
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	bytesResult, _ := json.Marshal(map[string]interface{}{
+		"status": status,
+		"checks": checks,
+	})
+	w.Write(bytesResult)
+}
+
 func (wa *WebAPI) manualUpdateHandler(w http.ResponseWriter, r *http.Request) {
 	logger := DecorateLogger(wa.Logger, RequestContextFields(r.Context()))
 	dinghyLog := dinghylog.NewDinghyLogs(logger)
@@ -168,6 +238,10 @@ func (wa *WebAPI) manualUpdateHandler(w http.ResponseWriter, r *http.Request) {
 		util.WriteHTTPError(w, http.StatusUnprocessableEntity, err)
 		return
 	}
+	caller, ok := wa.authorizeAdmin(w, r, settings, plankClient, dinghyLog)
+	if !ok {
+		return
+	}
 	var fileService = dummy.FileService{}
 
 	builder := &dinghyfile.PipelineBuilder{
@@ -180,7 +254,18 @@ func (wa *WebAPI) manualUpdateHandler(w http.ResponseWriter, r *http.Request) {
 		Ums:                    wa.Ums,
 		Action:                 pipebuilder.Process,
 		JsonValidationDisabled: settings.JsonValidationDisabled,
+		UserWriteAccessValidation: dinghyfile.UserWriteAccessValidation{
+			Enabled: settings.UserWritePermissionsCheckEnabled && caller != "",
+			Client:  plankClient,
+			Ignore:  settings.IgnoreUsersPermissions,
+			Logger:  dinghyLog,
+		},
+		RollbackOnFailure: settings.RollbackOnFailureEnabled,
+		Ctx:               r.Context(),
 	}
+	// a manual update has no repo to own the application, it may only update
+	// applications without an owner and never claims them
+	builder.OwnershipPolicy = ownershipPolicy(settings, wa.CacheReadOnly)
 
 	builder.Parser = wa.Parser
 	builder.Parser.SetBuilder(builder)
@@ -191,7 +276,7 @@ func (wa *WebAPI) manualUpdateHandler(w http.ResponseWriter, r *http.Request) {
 	fileService["master"]["dinghyfile"] = buf.String()
 	wa.Logger.Infof("Received payload: %s", fileService["master"]["dinghyfile"])
 
-	if _, err := builder.ProcessDinghyfile("", "", "dinghyfile", "", ""); err != nil {
+	if _, err := builder.ProcessDinghyfile("", "", "dinghyfile", "", caller); err != nil {
 		util.WriteHTTPError(w, http.StatusInternalServerError, err)
 	}
 }
@@ -258,7 +343,7 @@ func (wa *WebAPI) githubWebhookHandler(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	wa.buildPipelines(&p, body, &fileService, w, dinghyLog, pullRequestUrl, plankClient, settings)
+	wa.buildPipelines(r.Context(), &p, body, &fileService, w, dinghyLog, pullRequestUrl, plankClient, settings)
 }
 
 func contains(whvalidations []string, provider string) bool {
@@ -373,7 +458,7 @@ func (wa *WebAPI) gitlabWebhookHandler(w http.ResponseWriter, r *http.Request) {
 		saveLogEventError(wa.LogEventsClient, &p, dinghyLog, logevents.LogEvent{RawData: string(body)})
 		return
 	}
-	wa.buildPipelines(&p, body, &fileService, w, dinghyLog, "", plankClient, settings)
+	wa.buildPipelines(r.Context(), &p, body, &fileService, w, dinghyLog, "", plankClient, settings)
 }
 
 func (wa *WebAPI) stashWebhookHandler(w http.ResponseWriter, r *http.Request) {
@@ -426,7 +511,7 @@ func (wa *WebAPI) stashWebhookHandler(w http.ResponseWriter, r *http.Request) {
 		Logger: dinghyLog,
 	}
 	dinghyLog.Infof("Building pipeslines from Stash webhook")
-	wa.buildPipelines(p, body, &fileService, w, dinghyLog, "", plankClient, settings)
+	wa.buildPipelines(r.Context(), p, body, &fileService, w, dinghyLog, "", plankClient, settings)
 }
 
 func (wa *WebAPI) bitbucketWebhookHandler(w http.ResponseWriter, r *http.Request) {
@@ -503,7 +588,7 @@ func (wa *WebAPI) bitbucketWebhookHandler(w http.ResponseWriter, r *http.Request
 			Logger: dinghyLog,
 		}
 
-		wa.buildPipelines(p, body, &fileService, w, dinghyLog, "", plankClient, settings)
+		wa.buildPipelines(r.Context(), p, body, &fileService, w, dinghyLog, "", plankClient, settings)
 
 	case "repo:refs_changed", "pr:merged":
 		dinghyLog.Info("Processing bitbucket-server webhook")
@@ -550,7 +635,7 @@ func (wa *WebAPI) bitbucketWebhookHandler(w http.ResponseWriter, r *http.Request
 			Logger: dinghyLog,
 		}
 
-		wa.buildPipelines(p, body, &fileService, w, dinghyLog, "", plankClient, settings)
+		wa.buildPipelines(r.Context(), p, body, &fileService, w, dinghyLog, "", plankClient, settings)
 
 	default:
 		util.WriteHTTPError(w, http.StatusInternalServerError, errors.New("Unknown bitbucket event type"))
@@ -564,6 +649,18 @@ func (wa *WebAPI) bitbucketWebhookHandler(w http.ResponseWriter, r *http.Request
 
 // ProcessPush processes a push using a pipeline builder
 func (wa *WebAPI) ProcessPush(p Push, b *dinghyfile.PipelineBuilder, settings *global.Settings) (string, error) {
+	// Clean up after removed dinghyfiles first, a push can move one.
+	for _, filePath := range p.RemovedFiles() {
+		components := strings.Split(filePath, "/")
+		if components[len(components)-1] == settings.DinghyFilename {
+			if err := b.RemoveDinghyfile(p.Org(), p.Repo(), filePath, p.Branch()); err != nil {
+				b.Logger.Errorf("Error processing removed Dinghyfile: %s", err.Error())
+				p.SetCommitStatus(settings.InstanceId, git.StatusError, fmt.Sprintf("%s", err.Error()))
+				return "", err
+			}
+		}
+	}
+
 	// Ensure dinghyfile was changed.
 	if !p.ContainsFile(settings.DinghyFilename) {
 		b.Logger.Infof("Push does not include %s, skipping.", settings.DinghyFilename)
@@ -609,6 +706,7 @@ type UserWriteAccessValidation struct {
 // TODO: this func should return an error and allow the handlers to return the http response. Additionally,
 // it probably doesn't belong in this file once refactored.
 func (wa *WebAPI) buildPipelines(
+	ctx context.Context,
 	p Push,
 	rawPushBytes []byte,
 	d dinghyfile.Downloader,
@@ -618,6 +716,13 @@ func (wa *WebAPI) buildPipelines(
 	pc util.PlankClient,
 	s *global.Settings,
 ) {
+	ctx, span := tracing.StartSpan(ctx, tracing.SpanWebhook,
+		attribute.String("dinghy.org", p.Org()),
+		attribute.String("dinghy.repo", p.Repo()),
+		attribute.String("dinghy.branch", p.Branch()),
+	)
+	defer span.End()
+
 	l.Infof("Processing request for branch: %s", p.Branch())
 
 	// deserialize push data to a map.  used in template logic later
@@ -651,13 +756,29 @@ func (wa *WebAPI) buildPipelines(
 			Logger:  l,
 		},
 		UpsertPipelineUsingOrcaTaskEnabled: s.UpsertPipelineUsingOrcaTaskEnabled,
+		StalePipelinesDryRun:               s.DeleteStalePipelinesDryRun,
+		RollbackOnFailure:                  s.RollbackOnFailureEnabled,
+		RenderHistoryLimit:                 s.RenderHistoryLimit,
+		Ctx:                                ctx,
+	}
+	if commits := p.GetCommits(); len(commits) > 0 {
+		builder.Commit = commits[len(commits)-1]
 	}
+	builder.Pusher = p.PusherName()
 
 	if shouldRunValidation(p, s, l) {
 		builder.Client = wa.ClientReadOnly
 		builder.Depman = wa.CacheReadOnly
 		builder.Action = pipebuilder.Validate
 	}
+	builder.OwnershipPolicy = ownershipPolicy(s, builder.Depman)
+	if store, ok := builder.Depman.(managed.Store); ok {
+		builder.Managed = store
+	}
+	if store, ok := builder.Depman.(history.Store); ok {
+		builder.History = store
+	}
+	builder.RemovedDinghyfilePolicy = s.RemovedDinghyfilePolicy
 
 	builder.Parser = wa.Parser
 	builder.Parser.SetBuilder(builder)
@@ -706,7 +827,9 @@ func (wa *WebAPI) buildPipelines(
 			ignoreFile = NewRegexpIgnoreFile(ignoreFilePatterns, l)
 		}
 
-		// For each module pushed, rebuild dependent dinghyfiles
+		// For each module pushed, rebuild dependent dinghyfiles. Validation
+		// renders them all at once with the modules of the pushed branch.
+		var modules []string
 		for _, file := range p.Files() {
 			if !ignoreFile.ShouldIgnore(file) {
 				// ensure module is correctly parsed
@@ -720,6 +843,10 @@ func (wa *WebAPI) buildPipelines(
 					})
 					return
 				}
+				if builder.Action == pipebuilder.Validate {
+					modules = append(modules, file)
+					continue
+				}
 				if err := builder.RebuildModuleRoots(p.Org(), p.Repo(), file, p.Branch(), p.PusherName()); err != nil {
 					switch err.(type) {
 					case *util.GitHubFileNotFoundErr:
@@ -739,6 +866,21 @@ func (wa *WebAPI) buildPipelines(
 				modulesProcessed++
 			}
 		}
+		if len(modules) > 0 {
+			if roots, err := builder.ValidateModuleRoots(p.Org(), p.Repo(), modules, p.Branch(), templateBranch(p, s), p.PusherName()); err != nil {
+				failed := failedModuleRoots(roots)
+				writeModuleRootsError(w, err, failed)
+				setCommitStatus(p, s.InstanceId, git.StatusFailure, moduleRootsStatus(err, failed))
+				l.Errorf("ValidateModuleRoots Failed: %s", err.Error())
+				saveLogEventError(wa.LogEventsClient, p, l, logevents.LogEvent{
+					RawData:            string(rawPushBytes),
+					PullRequest:        pullRequest,
+					RenderedDinghyfile: renderedDinghyfile,
+				})
+				return
+			}
+			modulesProcessed += len(modules)
+		}
 		setCommitStatusByAction(p, s.InstanceId, git.StatusSuccess, builder.Action)
 
 		if modulesProcessed > 0 {
@@ -785,6 +927,51 @@ func shouldRunValidation(p Push, settings *global.Settings, dinghyLog dinghylog.
 	return false
 }
 
+// templateBranch is the branch the modules of the template repo are used from
+func templateBranch(p Push, settings *global.Settings) string {
+	if rc := settings.GetRepoConfig(p.Name(), settings.TemplateRepo, p.Branch()); rc != nil && rc.Branch != "" {
+		return rc.Branch
+	}
+	return "master"
+}
+
+// failedModuleRoots keeps the dinghyfiles that failed a module validation
+func failedModuleRoots(roots []dinghyfile.RootValidation) []dinghyfile.RootValidation {
+	failed := []dinghyfile.RootValidation{}
+	for _, v := range roots {
+		if !v.Passed() {
+			failed = append(failed, v)
+		}
+	}
+	return failed
+}
+
+// moduleRootsStatus names the dinghyfiles that failed a module validation,
+// the commit status has no room for their errors
+func moduleRootsStatus(err error, failed []dinghyfile.RootValidation) string {
+	if len(failed) == 0 {
+		return err.Error()
+	}
+	names := make([]string, 0, len(failed))
+	for _, v := range failed {
+		names = append(names, fmt.Sprintf("%s/%s/%s", v.Org, v.Repo, v.Path))
+	}
+	return fmt.Sprintf("%s: %s", err.Error(), strings.Join(names, ", "))
+}
+
+// writeModuleRootsError answers with the dinghyfiles that failed a module
+// validation and why
+func writeModuleRootsError(w http.ResponseWriter, err error, failed []dinghyfile.RootValidation) {
+	bytesResult, _ := json.Marshal(map[string]interface{}{
+		"status": http.StatusUnprocessableEntity,
+		"error":  err.Error(),
+		"failed": failed,
+	})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnprocessableEntity)
+	w.Write(bytesResult)
+}
+
 func setCommitStatus(p Push, instanceId string, s git.Status, description string) {
 	p.SetCommitStatus(instanceId, s, description)
 }
@@ -0,0 +1,88 @@
+/*
+* Copyright 2026 Armory, Inc.
+
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+
+*    http://www.apache.org/licenses/LICENSE-2.0
+
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/armory/dinghy/pkg/backup"
+	dinghylog "github.com/armory/dinghy/pkg/log"
+	"github.com/armory/dinghy/pkg/util"
+)
+
+var ErrBackupUnsupported = errors.New("the configured persistence backend can't be backed up")
+
+// exportBackup returns the dependency graph, the raw data, the owners, the
+// managed pipelines, the renders and the log events as JSON lines
+func (wa *WebAPI) exportBackup(w http.ResponseWriter, r *http.Request) {
+	graph, dinghyLog, ok := wa.backupGraph(w, r)
+	if !ok {
+		return
+	}
+	var archive bytes.Buffer
+	report, err := backup.Export(&archive, graph, wa.LogEventsClient)
+	if err != nil {
+		dinghyLog.Errorf("Failed to export the backup: %s", err)
+		util.WriteHTTPError(w, http.StatusInternalServerError, err)
+		return
+	}
+	dinghyLog.Infof("Exported %d nodes, %d owners, %d managed dinghyfiles, %d renders and %d log events", report.Nodes, report.Owners, report.Managed, report.Renders, report.LogEvents)
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Write(archive.Bytes())
+}
+
+// importBackup loads the archive of the body and reports what the backends
+// return afterwards
+func (wa *WebAPI) importBackup(w http.ResponseWriter, r *http.Request) {
+	graph, dinghyLog, ok := wa.backupGraph(w, r)
+	if !ok {
+		return
+	}
+	report, err := backup.Import(r.Body, graph, wa.LogEventsClient)
+	if err != nil {
+		dinghyLog.Errorf("Failed to import the backup: %s", err)
+		util.WriteHTTPError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	dinghyLog.Infof("Imported %d nodes, %d owners, %d managed dinghyfiles, %d renders and %d log events, %d mismatches", report.Nodes, report.Owners, report.Managed, report.Renders, report.LogEventsRestored, len(report.Mismatches))
+	bytesResult, _ := json.Marshal(report)
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(bytesResult)
+}
+
+func (wa *WebAPI) backupGraph(w http.ResponseWriter, r *http.Request) (backup.Graph, dinghylog.DinghyLog, bool) {
+	logger := DecorateLogger(wa.Logger, RequestContextFields(r.Context()))
+	dinghyLog := dinghylog.NewDinghyLogs(logger)
+	settings, plankClient, err := wa.SourceConfig.GetSettings(r, wa.Logr)
+	if err != nil {
+		dinghyLog.Errorf("Failed to get the settings: %s", err)
+		util.WriteHTTPError(w, http.StatusUnprocessableEntity, err)
+		return nil, nil, false
+	}
+	if _, ok := wa.authorizeAdmin(w, r, settings, plankClient, dinghyLog); !ok {
+		return nil, nil, false
+	}
+	graph, ok := wa.Cache.(backup.Graph)
+	if !ok {
+		util.WriteHTTPError(w, http.StatusNotImplemented, ErrBackupUnsupported)
+		return nil, nil, false
+	}
+	return graph, dinghyLog, true
+}
@@ -0,0 +1,73 @@
+/*
+* Copyright 2026 Armory, Inc.
+
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+
+*    http://www.apache.org/licenses/LICENSE-2.0
+
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/armory/dinghy/pkg/backup"
+	"github.com/armory/dinghy/pkg/cache"
+	"github.com/armory/dinghy/pkg/dinghyfile"
+	"github.com/armory/dinghy/pkg/settings/global"
+	"github.com/armory/dinghy/pkg/settings/source"
+	"github.com/golang/mock/gomock"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackupRoutes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	sc := source.NewMockSourceConfiguration(ctrl)
+	sc.EXPECT().GetSettings(gomock.Any(), gomock.Any()).Return(anonymousAdmin, dinghyfile.NewMockPlankClient(ctrl), nil).AnyTimes()
+	router := func(graph dinghyfile.DependencyManager) http.Handler {
+		wa := NewWebAPI(sc, graph, nil, logrus.New(), nil, nil, nil, nil)
+		wa.MetricsHandler = new(NoOpMetricsHandler)
+		return wa.Router(new(global.Settings))
+	}
+
+	graph := cache.NewMemoryCache()
+	graph.SetDeps("app1/dinghyfile", []string{"stage.module"})
+	graph.SetRawData("app1/dinghyfile", "{}")
+	rr := httptest.NewRecorder()
+	router(graph).ServeHTTP(rr, httptest.NewRequest("GET", "/v1/backup", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/x-ndjson", rr.Header().Get("Content-Type"))
+	archive := rr.Body.String()
+
+	restored := cache.NewMemoryCache()
+	rr = httptest.NewRecorder()
+	router(restored).ServeHTTP(rr, httptest.NewRequest("POST", "/v1/backup", strings.NewReader(archive)))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var report backup.Report
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &report))
+	assert.Equal(t, backup.Report{Nodes: 2, Edges: 1, RawData: 1, Mismatches: []string{}}, report)
+	assert.Equal(t, []string{"app1/dinghyfile"}, restored.GetRoots("stage.module"))
+
+	rr = httptest.NewRecorder()
+	router(restored).ServeHTTP(rr, httptest.NewRequest("POST", "/v1/backup", strings.NewReader(`{"kind":"node"}`)))
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+
+	rr = httptest.NewRecorder()
+	router(dinghyfile.NewMockDependencyManager(ctrl)).ServeHTTP(rr, httptest.NewRequest("GET", "/v1/backup", nil))
+	assert.Equal(t, http.StatusNotImplemented, rr.Code)
+}
@@ -0,0 +1,136 @@
+/*
+* Copyright 2026 Armory, Inc.
+
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+
+*    http://www.apache.org/licenses/LICENSE-2.0
+
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/armory/dinghy/pkg/depgraph"
+	dinghylog "github.com/armory/dinghy/pkg/log"
+	"github.com/armory/dinghy/pkg/settings/global"
+	"github.com/armory/dinghy/pkg/util"
+)
+
+var ErrGraphUnsupported = errors.New("the configured persistence backend can't walk the dependency graph")
+
+// GraphTree answers the dependents and dependencies endpoints. Dinghyfiles
+// are the dinghyfiles impacted by a change of the module, for dependents.
+type GraphTree struct {
+	Tree        *depgraph.Tree `json:"tree"`
+	Dinghyfiles []string       `json:"dinghyfiles,omitempty"`
+}
+
+// getGraph exports the whole dependency graph
+func (wa *WebAPI) getGraph(w http.ResponseWriter, r *http.Request) {
+	reader, _, ok := wa.dependencyGraph(w, r)
+	if !ok {
+		return
+	}
+	g, err := depgraph.Export(reader)
+	if err != nil {
+		util.WriteHTTPError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeGraph(w, r, g, g)
+}
+
+// getDependents returns everything using the url query parameter, transitively
+func (wa *WebAPI) getDependents(w http.ResponseWriter, r *http.Request) {
+	wa.writeGraphTree(w, r, true)
+}
+
+// getDependencies returns everything the url query parameter uses, transitively
+func (wa *WebAPI) getDependencies(w http.ResponseWriter, r *http.Request) {
+	wa.writeGraphTree(w, r, false)
+}
+
+func (wa *WebAPI) writeGraphTree(w http.ResponseWriter, r *http.Request, dependents bool) {
+	reader, _, ok := wa.dependencyGraph(w, r)
+	if !ok {
+		return
+	}
+	url := r.URL.Query().Get("url")
+	if url == "" {
+		util.WriteHTTPError(w, http.StatusUnprocessableEntity, errors.New("missing url query parameter"))
+		return
+	}
+
+	walk := depgraph.Dependencies
+	if dependents {
+		walk = depgraph.Dependents
+	}
+	tree, err := walk(reader, url)
+	if err != nil {
+		util.WriteHTTPError(w, http.StatusInternalServerError, err)
+		return
+	}
+	result := GraphTree{Tree: tree}
+	if dependents {
+		result.Dinghyfiles = tree.Leaves()
+	}
+	writeGraph(w, r, result, tree.Graph(dependents))
+}
+
+// getOrphans returns the modules no dinghyfile uses anymore
+func (wa *WebAPI) getOrphans(w http.ResponseWriter, r *http.Request) {
+	reader, settings, ok := wa.dependencyGraph(w, r)
+	if !ok {
+		return
+	}
+	orphans, err := depgraph.Orphans(reader, settings.DinghyFilename)
+	if err != nil {
+		util.WriteHTTPError(w, http.StatusInternalServerError, err)
+		return
+	}
+	bytesResult, _ := json.Marshal(orphans)
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(bytesResult)
+}
+
+// writeGraph writes result as JSON, or g in the DOT language with ?format=dot
+func writeGraph(w http.ResponseWriter, r *http.Request, result interface{}, g *depgraph.Graph) {
+	if r.URL.Query().Get("format") == "dot" {
+		w.Header().Set("Content-Type", "text/vnd.graphviz")
+		w.Write([]byte(g.DOT()))
+		return
+	}
+	bytesResult, _ := json.Marshal(result)
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(bytesResult)
+}
+
+func (wa *WebAPI) dependencyGraph(w http.ResponseWriter, r *http.Request) (depgraph.Reader, *global.Settings, bool) {
+	logger := DecorateLogger(wa.Logger, RequestContextFields(r.Context()))
+	dinghyLog := dinghylog.NewDinghyLogs(logger)
+	settings, plankClient, err := wa.SourceConfig.GetSettings(r, wa.Logr)
+	if err != nil {
+		dinghyLog.Errorf("Failed to get the settings: %s", err)
+		util.WriteHTTPError(w, http.StatusUnprocessableEntity, err)
+		return nil, nil, false
+	}
+	if _, ok := wa.authorizeAdmin(w, r, settings, plankClient, dinghyLog); !ok {
+		return nil, nil, false
+	}
+	reader, ok := wa.Cache.(depgraph.Reader)
+	if !ok {
+		util.WriteHTTPError(w, http.StatusNotImplemented, ErrGraphUnsupported)
+		return nil, nil, false
+	}
+	return reader, settings, true
+}
@@ -0,0 +1,88 @@
+/*
+* Copyright 2026 Armory, Inc.
+
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+
+*    http://www.apache.org/licenses/LICENSE-2.0
+
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/armory/dinghy/pkg/cache"
+	"github.com/armory/dinghy/pkg/dinghyfile"
+	"github.com/armory/dinghy/pkg/settings/global"
+	"github.com/armory/dinghy/pkg/settings/source"
+	"github.com/golang/mock/gomock"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGraphRoutes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	sc := source.NewMockSourceConfiguration(ctrl)
+	sc.EXPECT().GetSettings(gomock.Any(), gomock.Any()).Return(&global.Settings{DinghyFilename: "dinghyfile", AdminAuth: anonymousAdmin.AdminAuth}, dinghyfile.NewMockPlankClient(ctrl), nil).AnyTimes()
+
+	graph := cache.NewMemoryCache()
+	graph.SetDeps("app1/dinghyfile", []string{"stage.module"})
+	graph.SetDeps("app2/dinghyfile", []string{"stage.module"})
+	graph.SetDeps("stage.module", []string{"wait.module"})
+	graph.SetDeps("old.module", nil)
+
+	wa := NewWebAPI(sc, graph, nil, logrus.New(), nil, nil, nil, nil)
+	wa.MetricsHandler = new(NoOpMetricsHandler)
+	router := wa.Router(new(global.Settings))
+	get := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+		return rr
+	}
+
+	rr := get("/v1/graph/dependents?url=" + url.QueryEscape("wait.module"))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `{"tree":{"url":"wait.module","children":[{"url":"stage.module","children":[{"url":"app1/dinghyfile"},{"url":"app2/dinghyfile"}]}]},"dinghyfiles":["app1/dinghyfile","app2/dinghyfile"]}`, rr.Body.String())
+
+	rr = get("/v1/graph/dependencies?format=dot&url=" + url.QueryEscape("app1/dinghyfile"))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "text/vnd.graphviz", rr.Header().Get("Content-Type"))
+	assert.Equal(t, "digraph dinghy {\n  rankdir=LR;\n  \"app1/dinghyfile\" -> \"stage.module\";\n  \"stage.module\" -> \"wait.module\";\n}\n", rr.Body.String())
+
+	rr = get("/v1/graph/orphans")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `["old.module"]`, rr.Body.String())
+
+	rr = get("/v1/graph")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `{"nodes":["app1/dinghyfile","app2/dinghyfile","old.module","stage.module","wait.module"],"edges":[{"from":"app1/dinghyfile","to":"stage.module"},{"from":"app2/dinghyfile","to":"stage.module"},{"from":"stage.module","to":"wait.module"}]}`, rr.Body.String())
+
+	assert.Equal(t, http.StatusUnprocessableEntity, get("/v1/graph/dependents").Code)
+}
+
+func TestGraphUnsupported(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	sc := source.NewMockSourceConfiguration(ctrl)
+	sc.EXPECT().GetSettings(gomock.Any(), gomock.Any()).Return(anonymousAdmin, dinghyfile.NewMockPlankClient(ctrl), nil).AnyTimes()
+
+	wa := NewWebAPI(sc, dinghyfile.NewMockDependencyManager(ctrl), nil, logrus.New(), nil, nil, nil, nil)
+	wa.MetricsHandler = new(NoOpMetricsHandler)
+	rr := httptest.NewRecorder()
+	wa.Router(new(global.Settings)).ServeHTTP(rr, httptest.NewRequest("GET", "/v1/graph/orphans", nil))
+	assert.Equal(t, http.StatusNotImplemented, rr.Code)
+}
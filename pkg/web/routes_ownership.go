@@ -0,0 +1,122 @@
+/*
+* Copyright 2026 Armory, Inc.
+
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+
+*    http://www.apache.org/licenses/LICENSE-2.0
+
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/armory/dinghy/pkg/dinghyfile"
+	dinghylog "github.com/armory/dinghy/pkg/log"
+	"github.com/armory/dinghy/pkg/ownership"
+	"github.com/armory/dinghy/pkg/settings/global"
+	"github.com/armory/dinghy/pkg/util"
+	"github.com/gorilla/mux"
+)
+
+var ErrOwnershipUnsupported = errors.New("the configured persistence backend does not support application ownership")
+
+// ownershipPolicy builds the ownership policy from settings, the registry is
+// the dependency manager when it supports it. It returns nil when disabled.
+func ownershipPolicy(s *global.Settings, depman dinghyfile.DependencyManager) *ownership.Policy {
+	if !s.Ownership.Enabled {
+		return nil
+	}
+	policy := &ownership.Policy{Allowed: map[string][]string{}}
+	for _, a := range s.Ownership.Applications {
+		name := strings.ToLower(a.Application)
+		policy.Allowed[name] = append(policy.Allowed[name], a.Sources...)
+	}
+	if registry, ok := depman.(ownership.Registry); ok {
+		policy.Registry = registry
+	}
+	return policy
+}
+
+func (wa *WebAPI) getOwnership(w http.ResponseWriter, r *http.Request) {
+	logger := DecorateLogger(wa.Logger, RequestContextFields(r.Context()))
+	dinghyLog := dinghylog.NewDinghyLogs(logger)
+	registry, ok := wa.ownershipRegistry(w, r, dinghyLog)
+	if !ok {
+		return
+	}
+
+	application := strings.ToLower(mux.Vars(r)["application"])
+	owner, err := registry.GetOwner(application)
+	if err != nil {
+		util.WriteHTTPError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if owner == nil {
+		util.WriteHTTPError(w, http.StatusNotFound, errors.New("application "+application+" has no owner"))
+		return
+	}
+	bytesResult, _ := json.Marshal(owner)
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(bytesResult)
+}
+
+// transferOwnership binds an application to the dinghyfile in the body
+func (wa *WebAPI) transferOwnership(w http.ResponseWriter, r *http.Request) {
+	logger := DecorateLogger(wa.Logger, RequestContextFields(r.Context()))
+	dinghyLog := dinghylog.NewDinghyLogs(logger)
+	registry, ok := wa.ownershipRegistry(w, r, dinghyLog)
+	if !ok {
+		return
+	}
+
+	var owner ownership.Owner
+	if err := json.NewDecoder(r.Body).Decode(&owner); err != nil {
+		util.WriteHTTPError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	if owner.Org == "" || owner.Repo == "" || owner.Path == "" {
+		util.WriteHTTPError(w, http.StatusUnprocessableEntity, errors.New("org, repo and path are required"))
+		return
+	}
+
+	application := strings.ToLower(mux.Vars(r)["application"])
+	if err := registry.SetOwner(application, owner); err != nil {
+		util.WriteHTTPError(w, http.StatusInternalServerError, err)
+		return
+	}
+	dinghyLog.Infof("Ownership of application %s transferred to %s", application, owner)
+	bytesResult, _ := json.Marshal(owner)
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(bytesResult)
+}
+
+// ownershipRegistry authorizes an admin request and returns the registry
+func (wa *WebAPI) ownershipRegistry(w http.ResponseWriter, r *http.Request, l dinghylog.DinghyLog) (ownership.Registry, bool) {
+	settings, plankClient, err := wa.SourceConfig.GetSettings(r, wa.Logr)
+	if err != nil {
+		l.Errorf("Failed to get the settings: %s", err)
+		util.WriteHTTPError(w, http.StatusUnprocessableEntity, err)
+		return nil, false
+	}
+	if _, ok := wa.authorizeAdmin(w, r, settings, plankClient, l); !ok {
+		return nil, false
+	}
+	registry, ok := wa.Cache.(ownership.Registry)
+	if !ok {
+		util.WriteHTTPError(w, http.StatusNotImplemented, ErrOwnershipUnsupported)
+		return nil, false
+	}
+	return registry, true
+}
@@ -0,0 +1,145 @@
+/*
+* Copyright 2026 Armory, Inc.
+
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+
+*    http://www.apache.org/licenses/LICENSE-2.0
+
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package web
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/armory/dinghy/pkg/cache"
+	"github.com/armory/dinghy/pkg/dinghyfile"
+	"github.com/armory/dinghy/pkg/mock"
+	"github.com/armory/dinghy/pkg/ownership"
+	"github.com/armory/dinghy/pkg/settings/global"
+	"github.com/armory/dinghy/pkg/settings/source"
+	"github.com/armory/plank/v4"
+	"github.com/golang/mock/gomock"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+// ownershipCache is a dependency manager that also keeps application owners
+type ownershipCache struct {
+	cache.MemoryCache
+	owners map[string]ownership.Owner
+}
+
+func (c *ownershipCache) GetOwner(application string) (*ownership.Owner, error) {
+	if o, ok := c.owners[application]; ok {
+		return &o, nil
+	}
+	return nil, nil
+}
+
+func (c *ownershipCache) ClaimOwner(application string, owner ownership.Owner) (*ownership.Owner, error) {
+	if _, ok := c.owners[application]; !ok {
+		c.owners[application] = owner
+	}
+	return c.GetOwner(application)
+}
+
+func (c *ownershipCache) SetOwner(application string, owner ownership.Owner) error {
+	c.owners[application] = owner
+	return nil
+}
+
+func TestOwnershipPolicy(t *testing.T) {
+	registry := &ownershipCache{MemoryCache: cache.NewMemoryCache(), owners: map[string]ownership.Owner{}}
+
+	assert.Nil(t, ownershipPolicy(&global.Settings{}, registry))
+
+	s := &global.Settings{Ownership: global.Ownership{
+		Enabled: true,
+		Applications: []global.ApplicationOwners{
+			{Application: "Biff", Sources: []string{"armory/team-a"}},
+		},
+	}}
+	policy := ownershipPolicy(s, registry)
+	assert.Equal(t, registry, policy.Registry)
+	assert.Equal(t, map[string][]string{"biff": {"armory/team-a"}}, policy.Allowed)
+
+	// a backend without ownership support only enforces the allow-list
+	policy = ownershipPolicy(s, cache.NewMemoryCache())
+	assert.Nil(t, policy.Registry)
+}
+
+func TestTransferOwnership(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	logger := mock.NewMockFieldLogger(ctrl)
+	logger.EXPECT().WithFields(gomock.Any()).AnyTimes()
+	logger.EXPECT().Infof(gomock.Any(), gomock.Any()).AnyTimes()
+
+	sc := source.NewMockSourceConfiguration(ctrl)
+	sc.EXPECT().GetSettings(gomock.Any(), gomock.Any()).Return(anonymousAdmin, dinghyfile.NewMockPlankClient(ctrl), nil).AnyTimes()
+
+	registry := &ownershipCache{MemoryCache: cache.NewMemoryCache(), owners: map[string]ownership.Owner{
+		"biff": {Org: "armory", Repo: "team-a", Path: "dinghyfile"},
+	}}
+	wa := NewWebAPI(sc, registry, nil, logger, nil, nil, nil, nil)
+	wa.MetricsHandler = new(NoOpMetricsHandler)
+	router := wa.Router(new(global.Settings))
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("PUT", "/v1/ownership/Biff", bytes.NewBufferString(`{"org":"armory","repo":"team-b","path":"apps/dinghyfile"}`)))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, ownership.Owner{Org: "armory", Repo: "team-b", Path: "apps/dinghyfile"}, registry.owners["biff"])
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/v1/ownership/biff", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `{"org":"armory","repo":"team-b","path":"apps/dinghyfile"}`, rr.Body.String())
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/v1/ownership/other", nil))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("PUT", "/v1/ownership/biff", bytes.NewBufferString(`{"org":"armory"}`)))
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+}
+
+func TestManualUpdateOwnership(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	settings := &global.Settings{AdminAuth: anonymousAdmin.AdminAuth, Ownership: global.Ownership{Enabled: true}}
+	client := dinghyfile.NewMockPlankClient(ctrl)
+	client.EXPECT().GetApplicationNotifications(gomock.Any(), gomock.Any()).Return(&plank.NotificationsType{}, nil).AnyTimes()
+	sc := source.NewMockSourceConfiguration(ctrl)
+	sc.EXPECT().GetSettings(gomock.Any(), gomock.Any()).Return(settings, client, nil).AnyTimes()
+
+	registry := &ownershipCache{MemoryCache: cache.NewMemoryCache(), owners: map[string]ownership.Owner{
+		"biff": {Org: "armory", Repo: "team-a", Path: "dinghyfile"},
+	}}
+	wa := NewWebAPI(sc, registry, nil, logrus.New(), registry, nil, nil, nil)
+	wa.AddDinghyfileUnmarshaller(&dinghyfile.DinghyJsonUnmarshaller{})
+	parser := dinghyfile.NewMockParser(ctrl)
+	parser.EXPECT().SetBuilder(gomock.Any()).Times(1)
+	parser.EXPECT().Parse("", "", "dinghyfile", "", nil).Return(bytes.NewBufferString(`{"application":"biff"}`), nil).Times(1)
+	wa.SetDinghyfileParser(parser)
+
+	// the manual update doesn't come from the owner of the application
+	rr := httptest.NewRecorder()
+	wa.manualUpdateHandler(rr, httptest.NewRequest("POST", "/v1/updatePipeline", nil))
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	assert.Contains(t, rr.Body.String(), "application biff is managed by armory/team-a/dinghyfile")
+	assert.Equal(t, ownership.Owner{Org: "armory", Repo: "team-a", Path: "dinghyfile"}, registry.owners["biff"])
+}
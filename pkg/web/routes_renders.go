@@ -0,0 +1,256 @@
+/*
+* Copyright 2026 Armory, Inc.
+
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+
+*    http://www.apache.org/licenses/LICENSE-2.0
+
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/armory/dinghy/pkg/dinghyfile"
+	"github.com/armory/dinghy/pkg/dinghyfile/pipebuilder"
+	"github.com/armory/dinghy/pkg/history"
+	dinghylog "github.com/armory/dinghy/pkg/log"
+	"github.com/armory/dinghy/pkg/logevents"
+	"github.com/armory/dinghy/pkg/managed"
+	"github.com/armory/dinghy/pkg/util"
+	"github.com/gorilla/mux"
+)
+
+var ErrHistoryUnsupported = errors.New("the configured persistence backend does not keep render history")
+
+// listRenders returns the recorded renders of an application, newest first,
+// without their contents. The since and until RFC 3339 query parameters
+// bound their dates.
+func (wa *WebAPI) listRenders(w http.ResponseWriter, r *http.Request) {
+	logger := DecorateLogger(wa.Logger, RequestContextFields(r.Context()))
+	dinghyLog := dinghylog.NewDinghyLogs(logger)
+	store, ok := wa.renderHistory(w, r, dinghyLog)
+	if !ok {
+		return
+	}
+	since, errSince := parseRenderDate(r.URL.Query().Get("since"), 0)
+	until, errUntil := parseRenderDate(r.URL.Query().Get("until"), math.MaxInt64)
+	if errSince != nil || errUntil != nil {
+		util.WriteHTTPError(w, http.StatusUnprocessableEntity, errors.New("since and until must be RFC 3339 dates"))
+		return
+	}
+
+	renders, err := store.ListRenders(strings.ToLower(mux.Vars(r)["application"]))
+	if err != nil {
+		util.WriteHTTPError(w, http.StatusInternalServerError, err)
+		return
+	}
+	found := []history.Render{}
+	for _, render := range renders {
+		if render.Date < since || render.Date > until {
+			continue
+		}
+		render.Dinghyfile = ""
+		render.Pipelines = nil
+		found = append(found, render)
+	}
+	renders = found
+	bytesResult, _ := json.Marshal(renders)
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(bytesResult)
+}
+
+// getRender returns a render, given by id or commit
+func (wa *WebAPI) getRender(w http.ResponseWriter, r *http.Request) {
+	logger := DecorateLogger(wa.Logger, RequestContextFields(r.Context()))
+	dinghyLog := dinghylog.NewDinghyLogs(logger)
+	store, ok := wa.renderHistory(w, r, dinghyLog)
+	if !ok {
+		return
+	}
+
+	render, ok := findRender(w, r, store)
+	if !ok {
+		return
+	}
+	bytesResult, _ := json.Marshal(render)
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(bytesResult)
+}
+
+// rollbackRender applies a recorded render again, straight to Spinnaker
+func (wa *WebAPI) rollbackRender(w http.ResponseWriter, r *http.Request) {
+	logger := DecorateLogger(wa.Logger, RequestContextFields(r.Context()))
+	dinghyLog := dinghylog.NewDinghyLogs(logger)
+	settings, plankClient, err := wa.SourceConfig.GetSettings(r, wa.Logr)
+	if err != nil {
+		dinghyLog.Errorf("Failed to get the settings: %s", err)
+		util.WriteHTTPError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	caller, ok := wa.authorizeAdmin(w, r, settings, plankClient, dinghyLog)
+	if !ok {
+		return
+	}
+	store, ok := wa.Cache.(history.Store)
+	if !ok {
+		util.WriteHTTPError(w, http.StatusNotImplemented, ErrHistoryUnsupported)
+		return
+	}
+	render, ok := findRender(w, r, store)
+	if !ok {
+		return
+	}
+
+	builder := &dinghyfile.PipelineBuilder{
+		Depman:                             wa.Cache,
+		Client:                             plankClient,
+		EventClient:                        wa.EventClient,
+		AutolockPipelines:                  settings.AutoLockPipelines,
+		Logger:                             dinghyLog,
+		Ums:                                wa.Ums,
+		Notifiers:                          wa.Notifiers,
+		Action:                             pipebuilder.Process,
+		JsonValidationDisabled:             settings.JsonValidationDisabled,
+		UpsertPipelineUsingOrcaTaskEnabled: settings.UpsertPipelineUsingOrcaTaskEnabled,
+		StalePipelinesDryRun:               settings.DeleteStalePipelinesDryRun,
+		RollbackOnFailure:                  settings.RollbackOnFailureEnabled,
+		RenderHistoryLimit:                 settings.RenderHistoryLimit,
+		History:                            store,
+		Ctx:                                r.Context(),
+	}
+	builder.OwnershipPolicy = ownershipPolicy(settings, builder.Depman)
+	if managedStore, ok := wa.Cache.(managed.Store); ok {
+		builder.Managed = managedStore
+	}
+
+	if caller == "" {
+		caller = "an anonymous admin"
+	}
+	builder.Pusher = caller
+	dinghyLog.Infof("Rollback of application %s to %s requested by %s", render.Application, render.Commit, caller)
+	err = builder.ApplyRender(*render, caller)
+
+	status := "success"
+	if err != nil {
+		status = "error"
+	}
+	logEvent := logevents.LogEvent{
+		Org:                render.Org,
+		Repo:               render.Repo,
+		Files:              []string{render.Path},
+		Commits:            []string{render.Commit},
+		Date:               time.Now().UnixNano() / int64(time.Millisecond),
+		Status:             status,
+		RenderedDinghyfile: render.Dinghyfile,
+		Message:            fmt.Sprintf("rollback of %s to %s by %s", render.Application, render.Commit, caller),
+	}
+	if buf, errBuf := dinghyLog.GetBytesBuffByLoggerKey(dinghylog.LogEventKey); errBuf == nil {
+		logEvent.Message = fmt.Sprintf("%s\n%v", logEvent.Message, buf)
+	}
+	if wa.LogEventsClient != nil {
+		wa.LogEventsClient.SaveLogEvent(logEvent)
+	}
+
+	if err != nil {
+		util.WriteHTTPError(w, http.StatusInternalServerError, err)
+		return
+	}
+	render.Dinghyfile = ""
+	render.Pipelines = nil
+	bytesResult, _ := json.Marshal(render)
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(bytesResult)
+}
+
+// diffRenders returns what changed from a render to another, each given by
+// id or commit
+func (wa *WebAPI) diffRenders(w http.ResponseWriter, r *http.Request) {
+	logger := DecorateLogger(wa.Logger, RequestContextFields(r.Context()))
+	dinghyLog := dinghylog.NewDinghyLogs(logger)
+	store, ok := wa.renderHistory(w, r, dinghyLog)
+	if !ok {
+		return
+	}
+
+	application := strings.ToLower(mux.Vars(r)["application"])
+	from, ok := findRenderByRef(w, store, application, mux.Vars(r)["render"])
+	if !ok {
+		return
+	}
+	to, ok := findRenderByRef(w, store, application, mux.Vars(r)["to"])
+	if !ok {
+		return
+	}
+	diff, err := history.Compare(*from, *to)
+	if err != nil {
+		util.WriteHTTPError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	bytesResult, _ := json.Marshal(diff)
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(bytesResult)
+}
+
+// parseRenderDate returns an RFC 3339 date in milliseconds, otherwise when empty
+func parseRenderDate(value string, otherwise int64) (int64, error) {
+	if value == "" {
+		return otherwise, nil
+	}
+	date, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return 0, err
+	}
+	return date.UnixNano() / int64(time.Millisecond), nil
+}
+
+func findRender(w http.ResponseWriter, r *http.Request, store history.Store) (*history.Render, bool) {
+	return findRenderByRef(w, store, strings.ToLower(mux.Vars(r)["application"]), mux.Vars(r)["render"])
+}
+
+// findRenderByRef finds a render by id, or else the newest render of a commit
+func findRenderByRef(w http.ResponseWriter, store history.Store, application, ref string) (*history.Render, bool) {
+	render, err := history.Find(store, application, ref)
+	if err != nil {
+		util.WriteHTTPError(w, http.StatusInternalServerError, err)
+		return nil, false
+	}
+	if render == nil {
+		util.WriteHTTPError(w, http.StatusNotFound, fmt.Errorf("no render of %s at %s", application, ref))
+		return nil, false
+	}
+	return render, true
+}
+
+// renderHistory authorizes an admin request and returns the render history
+func (wa *WebAPI) renderHistory(w http.ResponseWriter, r *http.Request, l dinghylog.DinghyLog) (history.Store, bool) {
+	settings, plankClient, err := wa.SourceConfig.GetSettings(r, wa.Logr)
+	if err != nil {
+		l.Errorf("Failed to get the settings: %s", err)
+		util.WriteHTTPError(w, http.StatusUnprocessableEntity, err)
+		return nil, false
+	}
+	if _, ok := wa.authorizeAdmin(w, r, settings, plankClient, l); !ok {
+		return nil, false
+	}
+	store, ok := wa.Cache.(history.Store)
+	if !ok {
+		util.WriteHTTPError(w, http.StatusNotImplemented, ErrHistoryUnsupported)
+		return nil, false
+	}
+	return store, true
+}
@@ -0,0 +1,196 @@
+/*
+* Copyright 2026 Armory, Inc.
+
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+
+*    http://www.apache.org/licenses/LICENSE-2.0
+
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/armory/dinghy/pkg/cache"
+	"github.com/armory/dinghy/pkg/dinghyfile"
+	"github.com/armory/dinghy/pkg/history"
+	"github.com/armory/dinghy/pkg/logevents"
+	"github.com/armory/dinghy/pkg/managed"
+	"github.com/armory/dinghy/pkg/mock"
+	"github.com/armory/dinghy/pkg/settings/global"
+	"github.com/armory/dinghy/pkg/settings/source"
+	"github.com/armory/plank/v4"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+// historyCache is a dependency manager that also keeps renders and managed
+// pipelines
+type historyCache struct {
+	cache.MemoryCache
+	renders []history.Render
+	managed map[string]managed.Dinghyfile
+}
+
+func (c *historyCache) GetManaged(url string) (*managed.Dinghyfile, error) {
+	if d, ok := c.managed[url]; ok {
+		return &d, nil
+	}
+	return nil, nil
+}
+
+func (c *historyCache) SetManaged(url string, d managed.Dinghyfile) error {
+	c.managed[url] = d
+	return nil
+}
+
+func (c *historyCache) DeleteManaged(url string) error {
+	delete(c.managed, url)
+	return nil
+}
+
+func (c *historyCache) SaveRender(r history.Render) error {
+	c.renders = append([]history.Render{r}, c.renders...)
+	return nil
+}
+
+func (c *historyCache) ListRenders(application string) ([]history.Render, error) {
+	var renders []history.Render
+	for _, r := range c.renders {
+		if r.Application == application {
+			renders = append(renders, r)
+		}
+	}
+	return renders, nil
+}
+
+func (c *historyCache) GetRender(application, id string) (*history.Render, error) {
+	for _, r := range c.renders {
+		if r.Application == application && r.ID == id {
+			return &r, nil
+		}
+	}
+	return nil, nil
+}
+
+func (c *historyCache) PruneRenders(application string, keep int) error {
+	return nil
+}
+
+func TestRenders(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	logger := mock.NewMockFieldLogger(ctrl)
+	logger.EXPECT().WithFields(gomock.Any()).AnyTimes()
+	logger.EXPECT().Infof(gomock.Any(), gomock.Any()).AnyTimes()
+	logger.EXPECT().Info(gomock.Any()).AnyTimes()
+	logger.EXPECT().Debug(gomock.Any()).AnyTimes()
+	logger.EXPECT().Warnf(gomock.Any(), gomock.Any()).AnyTimes()
+	logger.EXPECT().Debugf(gomock.Any(), gomock.Any()).AnyTimes()
+
+	pipeline := plank.Pipeline{Name: "first", ID: "firstID", Application: "testapp"}
+	client := dinghyfile.NewMockPlankClient(ctrl)
+	client.EXPECT().GetApplication("testapp", gomock.Any()).Return(nil, nil).Times(1)
+	client.EXPECT().GetPipelines("testapp", gomock.Any()).Return([]plank.Pipeline{pipeline}, nil).Times(1)
+	client.EXPECT().UpsertPipeline(gomock.Any(), "firstID", gomock.Any()).Return(nil).Times(1)
+
+	settings := &global.Settings{AdminAuth: global.AdminAuth{
+		Enabled: true,
+		Tokens:  []global.AdminToken{{User: "ops", Token: "s3cr3t"}},
+	}}
+	sc := source.NewMockSourceConfiguration(ctrl)
+	sc.EXPECT().GetSettings(gomock.Any(), gomock.Any()).Return(settings, client, nil).AnyTimes()
+
+	var saved logevents.LogEvent
+	lec := logevents.NewMockLogEventsClient(ctrl)
+	lec.EXPECT().SaveLogEvent(gomock.Any()).DoAndReturn(func(e logevents.LogEvent) error {
+		saved = e
+		return nil
+	}).Times(1)
+
+	store := &historyCache{MemoryCache: cache.NewMemoryCache(), managed: map[string]managed.Dinghyfile{}}
+	store.SaveRender(history.Render{
+		ID: "1-old", Application: "testapp", Commit: "old", URL: "https://github.com/org/repo/dinghyfile", Org: "org", Repo: "repo", Path: "dinghyfile", Branch: "master", Date: 1,
+		Dinghyfile: `{"application": "testapp", "pipelines": [{"name": "first", "application": "testapp"}]}`,
+	})
+	store.SaveRender(history.Render{ID: "2-new", Application: "testapp", Commit: "new", Date: 2, Dinghyfile: "{}"})
+
+	wa := NewWebAPI(sc, store, nil, logger, nil, nil, lec, nil)
+	wa.AddDinghyfileUnmarshaller(&dinghyfile.DinghyJsonUnmarshaller{})
+	wa.MetricsHandler = new(NoOpMetricsHandler)
+	router := wa.Router(new(global.Settings))
+	do := func(method, path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, withHeader(httptest.NewRequest(method, path, nil), "Bearer s3cr3t"))
+		return rr
+	}
+
+	rr := do("GET", "/v1/applications/TestApp/renders")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `[{"id":"2-new","application":"testapp","commit":"new","org":"","repo":"","path":"","branch":"","date":2},{"id":"1-old","application":"testapp","commit":"old","url":"https://github.com/org/repo/dinghyfile","org":"org","repo":"repo","path":"dinghyfile","branch":"master","date":1}]`, rr.Body.String())
+
+	rr = do("GET", "/v1/applications/testapp/renders/old")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"dinghyfile":"{\"application\": \"testapp\"`)
+	assert.Equal(t, rr.Body.String(), do("GET", "/v1/applications/testapp/renders/1-old").Body.String())
+
+	rr = do("GET", "/v1/applications/testapp/renders?since=1970-01-01T00:00:00.002Z")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `[{"id":"2-new","application":"testapp","commit":"new","org":"","repo":"","path":"","branch":"","date":2}]`, rr.Body.String())
+	assert.Equal(t, `[]`, do("GET", "/v1/applications/testapp/renders?until=1969-12-31T00:00:00Z").Body.String())
+	assert.Equal(t, http.StatusUnprocessableEntity, do("GET", "/v1/applications/testapp/renders?since=tuesday").Code)
+
+	rr = do("GET", "/v1/applications/testapp/renders/old/diff/new")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `{"application":"testapp","from":{"id":"1-old","commit":"old","date":1},"to":{"id":"2-new","commit":"new","date":2},"dinghyfile":[{"path":"application","type":"removed","from":"testapp"}],"pipelines":[{"name":"first","type":"removed"}]}`, rr.Body.String())
+	assert.Equal(t, http.StatusNotFound, do("GET", "/v1/applications/testapp/renders/old/diff/missing").Code)
+
+	assert.Equal(t, http.StatusNotFound, do("GET", "/v1/applications/testapp/renders/missing").Code)
+	assert.Equal(t, http.StatusNotFound, do("POST", "/v1/applications/testapp/renders/missing/rollback").Code)
+
+	rr = do("POST", "/v1/applications/testapp/renders/old/rollback")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "success", saved.Status)
+	assert.Equal(t, []string{"old"}, saved.Commits)
+	assert.Equal(t, []string{"dinghyfile"}, saved.Files)
+	assert.Contains(t, saved.Message, "rollback of testapp to old by ops")
+	assert.Equal(t, []managed.Pipeline{{Name: "first"}}, store.managed["https://github.com/org/repo/dinghyfile"].Pipelines)
+	renders, _ := store.ListRenders("testapp")
+	if assert.Len(t, renders, 3) {
+		assert.Equal(t, "1-old", renders[0].RollbackOf)
+		assert.Equal(t, "old", renders[0].Commit)
+		assert.Equal(t, "ops", renders[0].Pusher)
+	}
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("POST", "/v1/applications/testapp/renders/old/rollback", nil))
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+}
+
+func TestRendersUnsupported(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	logger := mock.NewMockFieldLogger(ctrl)
+	logger.EXPECT().WithFields(gomock.Any()).AnyTimes()
+
+	sc := source.NewMockSourceConfiguration(ctrl)
+	sc.EXPECT().GetSettings(gomock.Any(), gomock.Any()).Return(anonymousAdmin, dinghyfile.NewMockPlankClient(ctrl), nil).AnyTimes()
+
+	wa := NewWebAPI(sc, cache.NewMemoryCache(), nil, logger, nil, nil, nil, nil)
+	wa.MetricsHandler = new(NoOpMetricsHandler)
+	rr := httptest.NewRecorder()
+	wa.Router(new(global.Settings)).ServeHTTP(rr, httptest.NewRequest("GET", "/v1/applications/testapp/renders", nil))
+	assert.Equal(t, http.StatusNotImplemented, rr.Code)
+}
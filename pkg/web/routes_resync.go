@@ -0,0 +1,396 @@
+/*
+* Copyright 2026 Armory, Inc.
+
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+
+*    http://www.apache.org/licenses/LICENSE-2.0
+
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/armory/dinghy/pkg/dinghyfile"
+	"github.com/armory/dinghy/pkg/dinghyfile/pipebuilder"
+	"github.com/armory/dinghy/pkg/git/bbcloud"
+	"github.com/armory/dinghy/pkg/git/github"
+	"github.com/armory/dinghy/pkg/git/gitlab"
+	"github.com/armory/dinghy/pkg/git/stash"
+	"github.com/armory/dinghy/pkg/history"
+	dinghylog "github.com/armory/dinghy/pkg/log"
+	"github.com/armory/dinghy/pkg/managed"
+	"github.com/armory/dinghy/pkg/settings/global"
+	"github.com/armory/dinghy/pkg/util"
+	"github.com/gorilla/mux"
+	gogitlab "github.com/xanzy/go-gitlab"
+)
+
+const (
+	DefaultResyncConcurrency = 4
+	MaxResyncConcurrency     = 32
+	// MaxResyncJobs is how many resyncs an instance remembers, the oldest
+	// finished ones are forgotten first
+	MaxResyncJobs = 20
+
+	ResyncSuccess = "success"
+	ResyncError   = "error"
+	ResyncSkipped = "skipped"
+)
+
+var ErrResyncUnsupported = errors.New("the configured persistence backend can't list dinghyfiles")
+
+// ResyncRequest selects the dinghyfiles a resync reprocesses, empty filters
+// match everything
+type ResyncRequest struct {
+	Org         string `json:"org,omitempty"`
+	Repo        string `json:"repo,omitempty"`
+	Application string `json:"application,omitempty"`
+	// ValidateOnly renders and validates without writing to Spinnaker
+	ValidateOnly bool `json:"validateOnly,omitempty"`
+	Concurrency  int  `json:"concurrency,omitempty"`
+}
+
+// ResyncResult is the outcome of reprocessing a single dinghyfile
+type ResyncResult struct {
+	URL    string `json:"url"`
+	Org    string `json:"org,omitempty"`
+	Repo   string `json:"repo,omitempty"`
+	Path   string `json:"path,omitempty"`
+	Branch string `json:"branch,omitempty"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// ResyncJob is the progress of a resync, it runs in the background. Done
+// counts every root looked at so far, skipped and failed ones included
+type ResyncJob struct {
+	ID         string         `json:"id"`
+	Request    ResyncRequest  `json:"request"`
+	Caller     string         `json:"caller,omitempty"`
+	Total      int            `json:"total"`
+	Done       int            `json:"done"`
+	Skipped    int            `json:"skipped"`
+	Failed     int            `json:"failed"`
+	Finished   bool           `json:"finished"`
+	StartedAt  int64          `json:"startedAt"`
+	FinishedAt int64          `json:"finishedAt,omitempty"`
+	Results    []ResyncResult `json:"results"`
+
+	mu sync.Mutex
+}
+
+func (j *ResyncJob) add(result ResyncResult) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.Results = append(j.Results, result)
+	j.Done++
+	switch result.Status {
+	case ResyncSkipped:
+		j.Skipped++
+	case ResyncError:
+		j.Failed++
+	}
+}
+
+func (j *ResyncJob) finished() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.Finished
+}
+
+func (j *ResyncJob) finish() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.Finished = true
+	j.FinishedAt = time.Now().UnixNano() / int64(time.Millisecond)
+}
+
+func (j *ResyncJob) MarshalJSON() ([]byte, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	type job ResyncJob
+	return json.Marshal((*job)(j))
+}
+
+// resyncJobs keeps the last MaxResyncJobs resyncs started on this instance,
+// they aren't shared between replicas
+type resyncJobs struct {
+	mu    sync.Mutex
+	jobs  map[string]*ResyncJob
+	order []string
+	next  int
+}
+
+func (r *resyncJobs) start(req ResyncRequest, caller string) *ResyncJob {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.jobs == nil {
+		r.jobs = map[string]*ResyncJob{}
+	}
+	r.next++
+	job := &ResyncJob{
+		ID:        fmt.Sprintf("resync-%d-%d", time.Now().Unix(), r.next),
+		Request:   req,
+		Caller:    caller,
+		StartedAt: time.Now().UnixNano() / int64(time.Millisecond),
+		Results:   []ResyncResult{},
+	}
+	r.jobs[job.ID] = job
+	r.order = append(r.order, job.ID)
+	r.evict()
+	return job
+}
+
+// evict forgets the oldest finished jobs over MaxResyncJobs, running jobs
+// are kept
+func (r *resyncJobs) evict() {
+	over := len(r.order) - MaxResyncJobs
+	kept := r.order[:0]
+	for _, id := range r.order {
+		if over > 0 && r.jobs[id].finished() {
+			delete(r.jobs, id)
+			over--
+			continue
+		}
+		kept = append(kept, id)
+	}
+	r.order = kept
+}
+
+func (r *resyncJobs) get(id string) *ResyncJob {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.jobs[id]
+}
+
+// startResync reprocesses every root dinghyfile of the dependency graph in
+// the background, the job it returns can be polled for progress
+func (wa *WebAPI) startResync(w http.ResponseWriter, r *http.Request) {
+	logger := DecorateLogger(wa.Logger, RequestContextFields(r.Context()))
+	dinghyLog := dinghylog.NewDinghyLogs(logger)
+	settings, plankClient, err := wa.SourceConfig.GetSettings(r, wa.Logr)
+	if err != nil {
+		dinghyLog.Errorf("Failed to get the settings: %s", err)
+		util.WriteHTTPError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	caller, ok := wa.authorizeAdmin(w, r, settings, plankClient, dinghyLog)
+	if !ok {
+		return
+	}
+	lister, ok := wa.Cache.(dinghyfile.RootLister)
+	if !ok {
+		util.WriteHTTPError(w, http.StatusNotImplemented, ErrResyncUnsupported)
+		return
+	}
+
+	var req ResyncRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			util.WriteHTTPError(w, http.StatusUnprocessableEntity, err)
+			return
+		}
+	}
+	if req.Concurrency <= 0 {
+		req.Concurrency = DefaultResyncConcurrency
+	}
+	if req.Concurrency > MaxResyncConcurrency {
+		req.Concurrency = MaxResyncConcurrency
+	}
+	roots, err := lister.ListRoots()
+	if err != nil {
+		util.WriteHTTPError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	job := wa.resyncJobs.start(req, caller)
+	job.Total = len(roots)
+	dinghyLog.Infof("Resync %s of %d dinghyfiles started by %s", job.ID, len(roots), caller)
+	go wa.resync(job, roots, resyncDownloaders(settings, dinghyLog), settings, plankClient)
+
+	bytesResult, _ := json.Marshal(job)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	w.Write(bytesResult)
+}
+
+func (wa *WebAPI) getResync(w http.ResponseWriter, r *http.Request) {
+	logger := DecorateLogger(wa.Logger, RequestContextFields(r.Context()))
+	dinghyLog := dinghylog.NewDinghyLogs(logger)
+	settings, plankClient, err := wa.SourceConfig.GetSettings(r, wa.Logr)
+	if err != nil {
+		dinghyLog.Errorf("Failed to get the settings: %s", err)
+		util.WriteHTTPError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	if _, ok := wa.authorizeAdmin(w, r, settings, plankClient, dinghyLog); !ok {
+		return
+	}
+
+	job := wa.resyncJobs.get(mux.Vars(r)["id"])
+	if job == nil {
+		util.WriteHTTPError(w, http.StatusNotFound, errors.New("no resync "+mux.Vars(r)["id"]))
+		return
+	}
+	bytesResult, _ := json.Marshal(job)
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(bytesResult)
+}
+
+func (wa *WebAPI) resync(job *ResyncJob, roots []string, downloaders []dinghyfile.Downloader, s *global.Settings, pc util.PlankClient) {
+	defer job.finish()
+	logger := wa.Logger.WithField("resync", job.ID)
+
+	concurrency := job.Request.Concurrency
+	if _, ok := wa.Parser.(*dinghyfile.DinghyfileParser); !ok && concurrency > 1 {
+		// other parsers can't be copied, and are shared by every builder
+		logger.Infof("Resync %s runs sequentially with this dinghyfile parser", job.ID)
+		concurrency = 1
+	}
+
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for _, url := range roots {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(url string) {
+			defer func() { <-sem; wg.Done() }()
+			job.add(wa.resyncOne(job, url, downloaders, s, pc))
+		}(url)
+	}
+	wg.Wait()
+	logger.Infof("Resync %s finished, %d of %d dinghyfiles reprocessed, %d skipped, %d failed", job.ID, job.Done-job.Skipped, job.Total, job.Skipped, job.Failed)
+}
+
+func (wa *WebAPI) resyncOne(job *ResyncJob, url string, downloaders []dinghyfile.Downloader, s *global.Settings, pc util.PlankClient) ResyncResult {
+	result := ResyncResult{URL: url, Status: ResyncSkipped}
+	d, org, repo, file, branch, ok := decodeDinghyfileURL(downloaders, url)
+	if !ok {
+		result.Error = "no configured git provider matches this url"
+		return result
+	}
+	result.Org, result.Repo, result.Path, result.Branch = org, repo, file, branch
+	// roots are modules too once every dinghyfile using them is gone
+	if path.Base(file) != s.DinghyFilename {
+		return result
+	}
+	if (job.Request.Org != "" && job.Request.Org != org) || (job.Request.Repo != "" && job.Request.Repo != repo) {
+		return result
+	}
+	if job.Request.Application != "" && !wa.manages(url, job.Request.Application) {
+		return result
+	}
+
+	l := dinghylog.NewDinghyLogs(wa.Logger.WithField("resync", job.ID))
+	builder := wa.resyncBuilder(d, l, s, pc, job.Request.ValidateOnly)
+	builder.LoadRawData(url)
+	if _, err := builder.ProcessDinghyfile(org, repo, file, branch, job.Caller); err != nil {
+		result.Status = ResyncError
+		result.Error = err.Error()
+		return result
+	}
+	result.Status = ResyncSuccess
+	return result
+}
+
+func (wa *WebAPI) resyncBuilder(d dinghyfile.Downloader, l dinghylog.DinghyLog, s *global.Settings, pc util.PlankClient, validateOnly bool) *dinghyfile.PipelineBuilder {
+	builder := &dinghyfile.PipelineBuilder{
+		Downloader:                         d,
+		Depman:                             wa.Cache,
+		TemplateRepo:                       s.TemplateRepo,
+		TemplateOrg:                        s.TemplateOrg,
+		DinghyfileName:                     s.DinghyFilename,
+		AutolockPipelines:                  s.AutoLockPipelines,
+		Client:                             pc,
+		EventClient:                        wa.EventClient,
+		Logger:                             l,
+		Ums:                                wa.Ums,
+		Notifiers:                          wa.Notifiers,
+		RepositoryRawdataProcessing:        s.RepositoryRawdataProcessing,
+		Action:                             pipebuilder.Process,
+		JsonValidationDisabled:             s.JsonValidationDisabled,
+		UpsertPipelineUsingOrcaTaskEnabled: s.UpsertPipelineUsingOrcaTaskEnabled,
+		StalePipelinesDryRun:               s.DeleteStalePipelinesDryRun,
+		RollbackOnFailure:                  s.RollbackOnFailureEnabled,
+		RenderHistoryLimit:                 s.RenderHistoryLimit,
+		// there is no push, the push data stored for every dinghyfile is kept
+		RebuildingModules: true,
+		Ctx:               context.Background(),
+	}
+	if validateOnly {
+		builder.Client = wa.ClientReadOnly
+		builder.Depman = wa.CacheReadOnly
+		builder.Action = pipebuilder.Validate
+	}
+	builder.OwnershipPolicy = ownershipPolicy(s, builder.Depman)
+	if store, ok := builder.Depman.(managed.Store); ok {
+		builder.Managed = store
+	}
+	if store, ok := builder.Depman.(history.Store); ok {
+		builder.History = store
+	}
+
+	if _, ok := wa.Parser.(*dinghyfile.DinghyfileParser); ok {
+		builder.Parser = dinghyfile.NewDinghyfileParser(builder)
+	} else {
+		builder.Parser = wa.Parser
+		builder.Parser.SetBuilder(builder)
+	}
+	return builder
+}
+
+// manages reports whether the dinghyfile last applied application
+func (wa *WebAPI) manages(url, application string) bool {
+	store, ok := wa.Cache.(managed.Store)
+	if !ok {
+		return false
+	}
+	state, err := store.GetManaged(url)
+	return err == nil && state != nil && strings.EqualFold(state.Application, application)
+}
+
+// resyncDownloaders returns a downloader for every configured git provider
+func resyncDownloaders(s *global.Settings, l dinghylog.DinghyLog) []dinghyfile.Downloader {
+	gh := github.Config{Endpoint: s.GithubEndpoint, Token: s.GitHubToken}
+	downloaders := []dinghyfile.Downloader{&github.FileService{GitHub: &gh, Logger: l}}
+	if s.GitLabToken != "" {
+		if client, err := gogitlab.NewClient(s.GitLabToken, gogitlab.WithBaseURL(s.GitLabEndpoint)); err == nil {
+			downloaders = append(downloaders, &gitlab.FileService{Client: client, Logger: l})
+		} else {
+			l.Warnf("Resync can't reach gitlab: %s", err.Error())
+		}
+	}
+	if s.StashEndpoint != "" {
+		downloaders = append(downloaders,
+			&stash.FileService{Config: stash.Config{Endpoint: s.StashEndpoint, Username: s.StashUsername, Token: s.StashToken, Logger: l}, Logger: l},
+			&bbcloud.FileService{Config: bbcloud.Config{Endpoint: s.StashEndpoint, Username: s.StashUsername, Token: s.StashToken, Logger: l}, Logger: l},
+		)
+	}
+	return downloaders
+}
+
+// decodeDinghyfileURL finds the downloader that encoded url
+func decodeDinghyfileURL(downloaders []dinghyfile.Downloader, url string) (d dinghyfile.Downloader, org, repo, file, branch string, ok bool) {
+	for _, d := range downloaders {
+		if org, repo, file, branch, ok = dinghyfile.DecodeURL(d, url); ok {
+			return d, org, repo, file, branch, true
+		}
+	}
+	return nil, "", "", "", "", false
+}
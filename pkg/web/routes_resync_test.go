@@ -0,0 +1,162 @@
+/*
+* Copyright 2026 Armory, Inc.
+
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+
+*    http://www.apache.org/licenses/LICENSE-2.0
+
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+package web
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/armory/dinghy/pkg/cache"
+	"github.com/armory/dinghy/pkg/dinghyfile"
+	"github.com/armory/dinghy/pkg/events"
+	"github.com/armory/dinghy/pkg/git/dummy"
+	"github.com/armory/dinghy/pkg/settings/global"
+	"github.com/armory/dinghy/pkg/settings/source"
+	"github.com/armory/plank/v4"
+	"github.com/golang/mock/gomock"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeDinghyfileURL(t *testing.T) {
+	s := &global.Settings{GithubEndpoint: "https://api.github.com", StashEndpoint: "https://stash.example.org"}
+	downloaders := resyncDownloaders(s, nil)
+
+	cases := map[string][]string{
+		"https://api.github.com/repos/org/repo/contents/apps/dinghyfile?ref=main":           {"org", "repo", "apps/dinghyfile", "main"},
+		"https://stash.example.org/projects/org/repos/repo/browse/dinghyfile?at=master&raw": {"org", "repo", "dinghyfile", "master"},
+		"https://stash.example.org/repositories/org/repo/src/master/dinghyfile?raw":         {"org", "repo", "dinghyfile", "master"},
+	}
+	for url, expected := range cases {
+		d, org, repo, file, branch, ok := decodeDinghyfileURL(downloaders, url)
+		if !assert.True(t, ok, url) {
+			continue
+		}
+		assert.Equal(t, url, d.EncodeURL(org, repo, file, branch))
+		assert.Equal(t, expected, []string{org, repo, file, branch})
+	}
+
+	_, _, _, _, _, ok := decodeDinghyfileURL(downloaders, "https://gitlab.example.org/unknown")
+	assert.False(t, ok)
+}
+
+func TestResync(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	client := dinghyfile.NewMockPlankClient(ctrl)
+	client.EXPECT().GetApplication("app1", gomock.Any()).Return(&plank.Application{Name: "app1"}, nil).Times(1)
+	client.EXPECT().GetPipelines("app1", gomock.Any()).Return([]plank.Pipeline{}, nil).Times(1)
+	client.EXPECT().UpsertPipeline(gomock.Any(), "", gomock.Any()).Return(nil).Times(1)
+	// the broken dinghyfile looks its notifications up to report the failure
+	client.EXPECT().GetApplicationNotifications(gomock.Any(), gomock.Any()).Return(nil, errors.New("not found")).AnyTimes()
+
+	files := dummy.FileService{"master": {
+		"dinghyfile":        `{"application": "app1", "pipelines": [{"name": "deploy", "application": "app1"}]}`,
+		"broken/dinghyfile": `{"application": `,
+	}}
+	depman := cache.NewMemoryCache()
+	wa := NewWebAPI(nil, depman, nil, logrus.New(), nil, nil, nil, nil)
+	wa.AddDinghyfileUnmarshaller(&dinghyfile.DinghyJsonUnmarshaller{})
+	wa.SetDinghyfileParser(dinghyfile.NewDinghyfileParser(&dinghyfile.PipelineBuilder{}))
+	echo := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer echo.Close()
+	es := &global.Settings{}
+	es.SpinnakerSupplied.Echo.BaseURL = echo.URL
+	wa.EventClient = events.NewEventClient(context.Background(), es, false)
+
+	roots := []string{
+		files.EncodeURL("org", "repo", "dinghyfile", "master"),
+		files.EncodeURL("org", "repo", "broken/dinghyfile", "master"),
+		files.EncodeURL("other", "repo", "dinghyfile", "master"),
+		files.EncodeURL("org", "repo", "module.json", "master"),
+		"https://unknown.example.org/dinghyfile",
+	}
+	// the push data of the last push survives the resync
+	rawData := `{"pusher":{"name":"octocat"}}`
+	depman.SetDeps(roots[0], []string{})
+	depman.SetRawData(roots[0], rawData)
+	s := &global.Settings{DinghyFilename: "dinghyfile"}
+	job := wa.resyncJobs.start(ResyncRequest{Org: "org", Concurrency: 2}, "ops")
+	job.Total = len(roots)
+	wa.resync(job, roots, []dinghyfile.Downloader{files}, s, client)
+	stored, err := depman.GetRawData(roots[0])
+	assert.Nil(t, err)
+	assert.Equal(t, rawData, stored)
+
+	assert.True(t, job.Finished)
+	assert.Equal(t, len(roots), job.Done)
+	assert.Equal(t, 3, job.Skipped)
+	assert.Equal(t, 1, job.Failed)
+	statuses := map[string]string{}
+	for _, r := range job.Results {
+		statuses[r.URL] = r.Status
+	}
+	assert.Equal(t, map[string]string{
+		roots[0]: ResyncSuccess,
+		roots[1]: ResyncError,
+		roots[2]: ResyncSkipped,
+		roots[3]: ResyncSkipped,
+		roots[4]: ResyncSkipped,
+	}, statuses)
+}
+
+func TestResyncJobsEviction(t *testing.T) {
+	var jobs resyncJobs
+	running := jobs.start(ResyncRequest{}, "ops")
+	first := jobs.start(ResyncRequest{}, "ops")
+	first.finish()
+	for i := 0; i < MaxResyncJobs-1; i++ {
+		jobs.start(ResyncRequest{}, "ops").finish()
+	}
+
+	assert.Len(t, jobs.jobs, MaxResyncJobs)
+	assert.Len(t, jobs.order, MaxResyncJobs)
+	assert.Equal(t, running, jobs.get(running.ID))
+	assert.Nil(t, jobs.get(first.ID))
+}
+
+func TestResyncRoutes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	sc := source.NewMockSourceConfiguration(ctrl)
+	sc.EXPECT().GetSettings(gomock.Any(), gomock.Any()).Return(anonymousAdmin, dinghyfile.NewMockPlankClient(ctrl), nil).AnyTimes()
+
+	wa := NewWebAPI(sc, cache.NewMemoryCache(), nil, logrus.New(), nil, nil, nil, nil)
+	wa.MetricsHandler = new(NoOpMetricsHandler)
+	router := wa.Router(new(global.Settings))
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("POST", "/v1/resync", bytes.NewBufferString(`{"application": "app1", "validateOnly": true}`)))
+	assert.Equal(t, http.StatusAccepted, rr.Code)
+	var job ResyncJob
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &job))
+	assert.Equal(t, ResyncRequest{Application: "app1", ValidateOnly: true, Concurrency: DefaultResyncConcurrency}, job.Request)
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/v1/resync/"+job.ID, nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/v1/resync/unknown", nil))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
@@ -0,0 +1,94 @@
+/*
+* Copyright 2026 Armory, Inc.
+
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+
+*    http://www.apache.org/licenses/LICENSE-2.0
+
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	dinghylog "github.com/armory/dinghy/pkg/log"
+	"github.com/armory/dinghy/pkg/managed"
+	"github.com/armory/dinghy/pkg/util"
+	"github.com/gorilla/mux"
+)
+
+var ErrManagedUnsupported = errors.New("the configured persistence backend does not record managed pipelines")
+
+// listStalePipelines returns the outcome of the last dry run of every
+// dinghyfile that wrote pipelines to the application, limited to the
+// pipelines still in Spinnaker
+func (wa *WebAPI) listStalePipelines(w http.ResponseWriter, r *http.Request) {
+	logger := DecorateLogger(wa.Logger, RequestContextFields(r.Context()))
+	dinghyLog := dinghylog.NewDinghyLogs(logger)
+	settings, plankClient, err := wa.SourceConfig.GetSettings(r, wa.Logr)
+	if err != nil {
+		dinghyLog.Errorf("Failed to get the settings: %s", err)
+		util.WriteHTTPError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	if _, ok := wa.authorizeAdmin(w, r, settings, plankClient, dinghyLog); !ok {
+		return
+	}
+	store, ok := wa.Cache.(managed.Store)
+	if !ok {
+		util.WriteHTTPError(w, http.StatusNotImplemented, ErrManagedUnsupported)
+		return
+	}
+
+	application := strings.ToLower(mux.Vars(r)["application"])
+	pipelines, err := plankClient.GetPipelines(application, "")
+	if err != nil {
+		util.WriteHTTPError(w, http.StatusInternalServerError, err)
+		return
+	}
+	existing := map[string]bool{}
+	urls := []string{}
+	seen := map[string]bool{}
+	for _, p := range pipelines {
+		existing[p.Name] = true
+		if marker, ok := managed.ParseMarker(p.Description); ok && marker.URL != "" && !seen[marker.URL] {
+			seen[marker.URL] = true
+			urls = append(urls, marker.URL)
+		}
+	}
+
+	found := []managed.StalePipelines{}
+	for _, url := range urls {
+		state, err := store.GetManaged(url)
+		if err != nil {
+			util.WriteHTTPError(w, http.StatusInternalServerError, err)
+			return
+		}
+		if state == nil || !strings.EqualFold(state.Application, application) {
+			continue
+		}
+		stale := managed.StalePipelines{URL: url, Org: state.Org, Repo: state.Repo, Path: state.Path, Commit: state.Commit, Pipelines: []string{}}
+		for _, p := range state.Stale {
+			if existing[p.Name] {
+				stale.Pipelines = append(stale.Pipelines, p.Name)
+			}
+		}
+		if len(stale.Pipelines) > 0 {
+			found = append(found, stale)
+		}
+	}
+	bytesResult, _ := json.Marshal(found)
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(bytesResult)
+}
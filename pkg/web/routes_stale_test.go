@@ -0,0 +1,86 @@
+/*
+* Copyright 2026 Armory, Inc.
+
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+
+*    http://www.apache.org/licenses/LICENSE-2.0
+
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/armory/dinghy/pkg/cache"
+	"github.com/armory/dinghy/pkg/dinghyfile"
+	"github.com/armory/dinghy/pkg/managed"
+	"github.com/armory/dinghy/pkg/mock"
+	"github.com/armory/dinghy/pkg/settings/global"
+	"github.com/armory/dinghy/pkg/settings/source"
+	"github.com/armory/plank/v4"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListStalePipelines(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	logger := mock.NewMockFieldLogger(ctrl)
+	logger.EXPECT().WithFields(gomock.Any()).AnyTimes()
+
+	url := "https://github.com/org/repo/dinghyfile"
+	marker := managed.Marker{URL: url, Org: "org", Repo: "repo", Path: "dinghyfile"}
+	client := dinghyfile.NewMockPlankClient(ctrl)
+	client.EXPECT().GetPipelines("testapp", gomock.Any()).Return([]plank.Pipeline{
+		{Name: "deploy", Application: "testapp", Description: managed.Mark("", marker)},
+		{Name: "old", Application: "testapp", Description: managed.Mark("", marker)},
+		{Name: "manual", Application: "testapp"},
+	}, nil).Times(1)
+
+	sc := source.NewMockSourceConfiguration(ctrl)
+	sc.EXPECT().GetSettings(gomock.Any(), gomock.Any()).Return(anonymousAdmin, client, nil).AnyTimes()
+
+	store := &historyCache{MemoryCache: cache.NewMemoryCache(), managed: map[string]managed.Dinghyfile{
+		url: {
+			Org: "org", Repo: "repo", Path: "dinghyfile", Commit: "abc", Application: "testapp",
+			Pipelines: []managed.Pipeline{{Name: "deploy"}},
+			// gone was deleted by hand since the dry run
+			Stale: []managed.Pipeline{{Name: "old"}, {Name: "gone"}},
+		},
+	}}
+
+	wa := NewWebAPI(sc, store, nil, logger, nil, nil, nil, nil)
+	wa.MetricsHandler = new(NoOpMetricsHandler)
+	rr := httptest.NewRecorder()
+	wa.Router(new(global.Settings)).ServeHTTP(rr, httptest.NewRequest("GET", "/v1/applications/TestApp/stale", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `[{"url":"https://github.com/org/repo/dinghyfile","org":"org","repo":"repo","path":"dinghyfile","commit":"abc","pipelines":["old"]}]`, rr.Body.String())
+}
+
+func TestListStalePipelinesUnsupported(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	logger := mock.NewMockFieldLogger(ctrl)
+	logger.EXPECT().WithFields(gomock.Any()).AnyTimes()
+
+	sc := source.NewMockSourceConfiguration(ctrl)
+	sc.EXPECT().GetSettings(gomock.Any(), gomock.Any()).Return(anonymousAdmin, dinghyfile.NewMockPlankClient(ctrl), nil).AnyTimes()
+
+	wa := NewWebAPI(sc, cache.NewMemoryCache(), nil, logger, nil, nil, nil, nil)
+	wa.MetricsHandler = new(NoOpMetricsHandler)
+	rr := httptest.NewRecorder()
+	wa.Router(new(global.Settings)).ServeHTTP(rr, httptest.NewRequest("GET", "/v1/applications/testapp/stale", nil))
+	assert.Equal(t, http.StatusNotImplemented, rr.Code)
+}
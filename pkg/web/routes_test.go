@@ -18,9 +18,12 @@ package web
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"errors"
 	"github.com/armory/dinghy/pkg/dinghyfile"
 	"github.com/armory/dinghy/pkg/git/github"
+	"github.com/armory/dinghy/pkg/health"
 	dinghylog "github.com/armory/dinghy/pkg/log"
 	"github.com/armory/dinghy/pkg/logevents"
 	"github.com/armory/dinghy/pkg/settings/global"
@@ -71,12 +74,55 @@ func testHealthCheckLogging(t *testing.T, endpoint string) {
 
 func TestHealthCheckLogging(t *testing.T) {
 	// This is served by multiple endpoints, so we'll iterate over all of them.
-	endpoints := []string{"/", "/health", "/healthcheck"}
+	endpoints := []string{"/", "/health", "/healthcheck", "/live"}
 	for _, e := range endpoints {
 		testHealthCheckLogging(t, e)
 	}
 }
 
+func TestReadiness(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	failing := true
+	monitor := health.NewMonitor(0, 0, nil)
+	monitor.AddCheck(health.NewCheck("redis", func(ctx context.Context) error {
+		if failing {
+			return errors.New("connection refused")
+		}
+		return nil
+	}))
+	monitor.RunChecks(context.Background())
+
+	wa := NewWebAPI(nil, nil, nil, mock.NewMockFieldLogger(ctrl), nil, nil, nil, nil)
+	wa.Readiness = monitor
+
+	rr := httptest.NewRecorder()
+	wa.readiness(rr, httptest.NewRequest("GET", "/ready", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	var body struct {
+		Status string                   `json:"status"`
+		Checks map[string]health.Result `json:"checks"`
+	}
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &body))
+	assert.Equal(t, health.StatusFailing, body.Status)
+	assert.Equal(t, "connection refused", body.Checks["redis"].LastError)
+
+	failing = false
+	monitor.RunChecks(context.Background())
+	rr = httptest.NewRecorder()
+	wa.readiness(rr, httptest.NewRequest("GET", "/ready", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestReadinessWithoutChecks(t *testing.T) {
+	wa := &WebAPI{}
+	rr := httptest.NewRecorder()
+	wa.readiness(rr, httptest.NewRequest("GET", "/ready", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `{"checks":{},"status":"ok"}`, rr.Body.String())
+}
+
 // Github webhook tests
 func TestGithubWebhookHandlerBadJSON(t *testing.T) {
 	ctrl := gomock.NewController(t)
@@ -375,6 +421,41 @@ func TestLogevents(t *testing.T) {
 	assert.Equal(t, `[{"org":"org","repo":"repo","files":["file1"],"message":"","date":0,"commits":["12345"],"status":"mystatus","rawdata":"raw","rendereddinghyfile":"dinghyfile","pullrequest":"https://github/pr"}]`, rr.Body.String())
 }
 
+func TestLogeventsFilters(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	lec := logevents.NewMockLogEventsClient(ctrl)
+	lec.EXPECT().GetLogEvents().AnyTimes().Return([]logevents.LogEvent{
+		{Org: "org", Repo: "repo", Date: 1, Commits: []string{"abc"}},
+		{Org: "org", Repo: "repo", Date: 2, Commits: []string{"def", "ghi"}},
+		{Org: "org", Repo: "other", Date: 3, Commits: []string{"ghi"}},
+	}, nil)
+	wa := NewWebAPI(nil, nil, nil, mock.NewMockFieldLogger(ctrl), nil, nil, lec, nil)
+
+	cases := map[string][]int64{
+		"":                             {1, 2, 3},
+		"org=org":                      {1, 2, 3},
+		"repo=repo":                    {1, 2},
+		"org=org&repo=repo&commit=ghi": {2},
+		"commit=ghi":                   {2, 3},
+		"org=nope":                     {},
+	}
+	for query, want := range cases {
+		rr := httptest.NewRecorder()
+		wa.logevents(rr, httptest.NewRequest("GET", "/v1/logevents?"+query, nil))
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		var got []logevents.LogEvent
+		assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &got))
+		dates := []int64{}
+		for _, e := range got {
+			dates = append(dates, e.Date)
+		}
+		assert.Equal(t, want, dates, query)
+	}
+}
+
 func Test_contains(t *testing.T) {
 	type args struct {
 		whvalidations []string
@@ -712,7 +793,7 @@ func TestBuildPipelinesWhenDinghyIgnoreRegexp2Enabled(t *testing.T) {
 	d := dinghyfile.NewMockDownloader(c)
 	d.EXPECT().Download("test_org", "test_repo", ".dinghyignore", "test_branch").Return("file.(js|css|html)", nil)
 
-	wa.buildPipelines(&p, []byte("{}"), d, r, dl, "", nil, s)
+	wa.buildPipelines(context.Background(), &p, []byte("{}"), d, r, dl, "", nil, s)
 
 	assert.Equal(t, http.StatusOK, r.Code)
 	assert.Equal(t, `{"status":"accepted"}`, r.Body.String())
@@ -769,8 +850,40 @@ func TestBuildPipelinesWhenDinghyIgnoreRegexp2Disabled(t *testing.T) {
 	d := dinghyfile.NewMockDownloader(c)
 	d.EXPECT().Download("test_org", "test_repo", ".dinghyignore", "test_branch").Return("file.(js|css|html)", nil)
 
-	wa.buildPipelines(&p, []byte("{}"), d, r, dl, "", nil, s)
+	wa.buildPipelines(context.Background(), &p, []byte("{}"), d, r, dl, "", nil, s)
 
 	assert.Equal(t, http.StatusOK, r.Code)
 	assert.Equal(t, `{"status":"accepted"}`, r.Body.String())
 }
+
+func TestTemplateBranch(t *testing.T) {
+	p := github.Push{Repository: github.Repository{Name: "templates"}, Ref: "refs/heads/feature"}
+	s := &global.Settings{TemplateRepo: "templates"}
+	assert.Equal(t, "master", templateBranch(&p, s))
+
+	s.RepoConfig = []global.RepoConfig{{Provider: "github", Repo: "templates", Branch: "main"}}
+	assert.Equal(t, "main", templateBranch(&p, s))
+}
+
+func TestModuleRootsError(t *testing.T) {
+	roots := []dinghyfile.RootValidation{
+		{URL: "a", Org: "org", Repo: "app1", Path: "dinghyfile", Branch: "master"},
+		{URL: "b", Org: "org", Repo: "app2", Path: "dinghyfile", Branch: "main", Error: "waitTime isn't set"},
+	}
+	err := errors.New("1 of 2 dinghyfiles using the changed modules failed validation")
+	failed := failedModuleRoots(roots)
+	assert.Equal(t, roots[1:], failed)
+	assert.Equal(t, "1 of 2 dinghyfiles using the changed modules failed validation: org/app2/dinghyfile", moduleRootsStatus(err, failed))
+	assert.Equal(t, "invalid", moduleRootsStatus(errors.New("invalid"), failedModuleRoots(nil)))
+
+	rr := httptest.NewRecorder()
+	writeModuleRootsError(rr, err, failed)
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	var body struct {
+		Error  string                      `json:"error"`
+		Failed []dinghyfile.RootValidation `json:"failed"`
+	}
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &body))
+	assert.Equal(t, err.Error(), body.Error)
+	assert.Equal(t, failed, body.Failed)
+}
@@ -0,0 +1,94 @@
+package test
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/armory/dinghy/cmd"
+	"github.com/armory/dinghy/pkg/settings/global"
+	"github.com/go-redis/redis"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedisClientSingleNode(t *testing.T) {
+	client, err := dinghy.NewRedisClient(global.Redis{
+		BaseURL:  "redis://redis:6379",
+		Username: "dinghy",
+		Password: "bob",
+		Database: 2,
+	})
+	assert.Nil(t, err)
+	defer client.Close()
+	if assert.IsType(t, &redis.Client{}, client) {
+		options := client.(*redis.Client).Options()
+		assert.Equal(t, "redis:6379", options.Addr)
+		assert.Equal(t, 2, options.DB)
+		assert.Empty(t, options.Password, "the ACL user authenticates on connect")
+		assert.NotNil(t, options.OnConnect)
+		assert.Nil(t, options.TLSConfig)
+	}
+}
+
+func TestRedisClientSentinel(t *testing.T) {
+	client, err := dinghy.NewRedisClient(global.Redis{
+		Password: "bob",
+		Sentinel: global.RedisSentinel{MasterName: "dinghy", Addresses: []string{"sentinel-1:26379", "sentinel-2:26379"}},
+	})
+	assert.Nil(t, err)
+	defer client.Close()
+	if assert.IsType(t, &redis.Client{}, client) {
+		assert.Equal(t, "FailoverClient", client.(*redis.Client).Options().Addr)
+		assert.Equal(t, "bob", client.(*redis.Client).Options().Password)
+	}
+
+	_, err = dinghy.NewRedisClient(global.Redis{Sentinel: global.RedisSentinel{MasterName: "dinghy"}})
+	assert.EqualError(t, err, "redis sentinel needs the addresses of the sentinels")
+}
+
+func TestRedisClientCluster(t *testing.T) {
+	client, err := dinghy.NewRedisClient(global.Redis{
+		BaseURL: "rediss://redis:6379",
+		Cluster: global.RedisCluster{Enabled: true},
+	})
+	assert.Nil(t, err)
+	defer client.Close()
+	if assert.IsType(t, &redis.ClusterClient{}, client) {
+		options := client.(*redis.ClusterClient).Options()
+		assert.Equal(t, []string{"redis:6379"}, options.Addrs)
+		assert.NotNil(t, options.TLSConfig)
+	}
+
+	_, err = dinghy.NewRedisClient(global.Redis{Database: 1, Cluster: global.RedisCluster{Enabled: true}})
+	assert.EqualError(t, err, "redis cluster only supports database 0")
+}
+
+func TestRedisClientTLS(t *testing.T) {
+	server := httptest.NewTLSServer(http.NotFoundHandler())
+	defer server.Close()
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	assert.Nil(t, os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0600))
+
+	client, err := dinghy.NewRedisClient(global.Redis{
+		BaseURL: "redis:6379",
+		TLS:     global.RedisTLS{Enabled: true, CAFile: caFile, ServerName: "redis.internal"},
+	})
+	assert.Nil(t, err)
+	defer client.Close()
+	tlsConfig := client.(*redis.Client).Options().TLSConfig
+	if assert.NotNil(t, tlsConfig) {
+		assert.NotNil(t, tlsConfig.RootCAs)
+		assert.Equal(t, "redis.internal", tlsConfig.ServerName)
+	}
+
+	_, err = dinghy.NewRedisClient(global.Redis{TLS: global.RedisTLS{Enabled: true, CAFile: filepath.Join(t.TempDir(), "missing.pem")}})
+	assert.NotNil(t, err)
+
+	empty := filepath.Join(t.TempDir(), "empty.pem")
+	assert.Nil(t, os.WriteFile(empty, nil, 0600))
+	_, err = dinghy.NewRedisClient(global.Redis{TLS: global.RedisTLS{Enabled: true, CAFile: empty}})
+	assert.EqualError(t, err, "no certificate found in "+empty)
+}